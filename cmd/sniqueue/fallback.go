@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/jsimonetti/sniqueue/sniparse"
+)
+
+// fallbackPolicy selects the QUIC packets that are dropped, so the client
+// falls back to TLS over TCP.
+type fallbackPolicy struct {
+	// matched drops QUIC packets to matched domains and fingerprints
+	matched bool
+	// unparseable drops QUIC packets that could not be decrypted or parsed
+	unparseable bool
+	// all drops every QUIC packet
+	all bool
+}
+
+// parseFallbackPolicy parses a comma separated list of matched and
+// unparseable, or all or none.
+func parseFallbackPolicy(value string) (fallbackPolicy, error) {
+	var policy fallbackPolicy
+	for _, name := range strings.Split(value, ",") {
+		switch strings.TrimSpace(name) {
+		case "matched":
+			policy.matched = true
+		case "unparseable":
+			policy.unparseable = true
+		case "all":
+			policy.all = true
+		case "none":
+		default:
+			return policy, fmt.Errorf("unknown policy '%s', must be matched, unparseable, all or none", name)
+		}
+	}
+	return policy, nil
+}
+
+func (p fallbackPolicy) String() string {
+	if p.all {
+		return "all"
+	}
+	var names []string
+	if p.matched {
+		names = append(names, "matched")
+	}
+	if p.unparseable {
+		names = append(names, "unparseable")
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ",")
+}
+
+// drops reports whether the policy drops the packet pkt, parsed with err,
+// whatever its name, and which part of the policy does. The Initials that
+// carry nothing to extract, such as the ones that continue a hello split
+// over several packets, are not unparseable.
+func (p fallbackPolicy) drops(pkt *sniparse.Packet, err error) (fallbackReason, bool) {
+	switch {
+	case p.all && isQUIC(pkt, err):
+		return fallbackAll, true
+	case err != nil && p.unparseable && isQUIC(pkt, err) && !errors.Is(err, sniparse.NotInterestingError):
+		return fallbackUnparseable, true
+	}
+	return 0, false
+}
+
+// fallbackReason is the part of the policy that made a packet be dropped.
+type fallbackReason int
+
+const (
+	fallbackMatched fallbackReason = iota
+	fallbackUnparseable
+	fallbackAll
+	fallbackReasons
+)
+
+func (r fallbackReason) String() string {
+	switch r {
+	case fallbackMatched:
+		return "matched"
+	case fallbackUnparseable:
+		return "unparseable"
+	case fallbackAll:
+		return "all"
+	}
+	return "unknown"
+}
+
+// fallbackCount counts the QUIC packets dropped to force a fallback to TCP,
+// by reason.
+var fallbackCount [fallbackReasons]atomic.Uint64
+
+// logStats logs the counters.
+func logStats() {
+	logger.Printf("Forced TCP fallbacks: %d matched, %d unparseable, %d all",
+		fallbackCount[fallbackMatched].Load(),
+		fallbackCount[fallbackUnparseable].Load(),
+		fallbackCount[fallbackAll].Load())
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/binary"
+	"os"
+	"testing"
+
+	"github.com/jsimonetti/sniqueue/sniparse"
+)
+
+func TestParseFallbackPolicy(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    fallbackPolicy
+		wantErr bool
+	}{
+		{value: "none", want: fallbackPolicy{}},
+		{value: "matched", want: fallbackPolicy{matched: true}},
+		{value: "matched, unparseable", want: fallbackPolicy{matched: true, unparseable: true}},
+		{value: "all", want: fallbackPolicy{all: true}},
+		{value: "", wantErr: true},
+		{value: "matched,everything", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseFallbackPolicy(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFallbackPolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Fatalf("parseFallbackPolicy() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFallbackPolicy_String(t *testing.T) {
+	for _, value := range []string{"none", "matched", "unparseable", "matched,unparseable", "all"} {
+		policy, err := parseFallbackPolicy(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if policy.String() != value {
+			t.Errorf("String() = %q, want %q", policy.String(), value)
+		}
+	}
+}
+
+// TestFallbackPolicy_drops runs the Initials of a connection through the
+// parser of handle, so the policy sees the errors handle does.
+func TestFallbackPolicy_drops(t *testing.T) {
+	initials := splitInitials(t)
+	tests := []struct {
+		name       string
+		policy     string
+		packet     []byte
+		wantReason fallbackReason
+		wantDrop   bool
+	}{
+		{
+			name:   "Initial with a name",
+			policy: "unparseable",
+			packet: ip4Packet(17, 443, initials[0]),
+		},
+		{
+			name:   "Continuation Initial",
+			policy: "unparseable",
+			packet: ip4Packet(17, 443, initials[1]),
+		},
+		{
+			name:       "Undecryptable Initial",
+			policy:     "unparseable",
+			packet:     ip4Packet(17, 443, corrupt(initials[0])),
+			wantReason: fallbackUnparseable,
+			wantDrop:   true,
+		},
+		{
+			name:   "Undecryptable Initial to another port",
+			policy: "unparseable",
+			packet: ip4Packet(17, 8443, corrupt(initials[0])),
+		},
+		{
+			name:   "Matched policy",
+			policy: "matched",
+			packet: ip4Packet(17, 443, corrupt(initials[0])),
+		},
+		{
+			name:       "Continuation Initial with all",
+			policy:     "all",
+			packet:     ip4Packet(17, 443, initials[1]),
+			wantReason: fallbackAll,
+			wantDrop:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := parseFallbackPolicy(tt.policy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			parser := sniparse.NewParser(sniparse.Options{QUICKeys: 8})
+			// The Initial before it caches the keys of the connection
+			_, _ = parser.Parse(ip4Packet(17, 443, initials[0]))
+
+			pkt, err := parser.Parse(tt.packet)
+			reason, drop := policy.drops(pkt, err)
+			if drop != tt.wantDrop || reason != tt.wantReason {
+				t.Fatalf("drops() = %s, %v, want %s, %v (err %v)", reason, drop, tt.wantReason, tt.wantDrop, err)
+			}
+		})
+	}
+}
+
+// splitInitials reads the two Initials of a ClientHello that does not fit
+// one packet, see the quic package tests.
+func splitInitials(t *testing.T) [][]byte {
+	var initials [][]byte
+	for _, name := range []string{"quic-initial-1", "quic-initial-2"} {
+		initial, err := os.ReadFile("../../internal/parse/quic/testdata/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		initials = append(initials, initial)
+	}
+	return initials
+}
+
+// corrupt returns a copy of an Initial whose authentication tag is wrong.
+func corrupt(initial []byte) []byte {
+	initial = append([]byte(nil), initial...)
+	initial[len(initial)-1] ^= 0xff
+	return initial
+}
+
+// ip4Packet builds an IPv4 packet from 192.0.2.1:50000 to 198.51.100.1 with
+// a TCP segment or UDP datagram carrying data.
+func ip4Packet(protocol byte, port uint16, data []byte) []byte {
+	packet := []byte{
+		0x45, 0x00, 0x00, 0x00, 0x00, 0x00, 0x40, 0x00,
+		0x40, protocol, 0x00, 0x00, 192, 0, 2, 1,
+		198, 51, 100, 1,
+	}
+	transport := make([]byte, 8)
+	if protocol == 6 {
+		transport = make([]byte, 20)
+		transport[12] = 5 << 4
+		transport[13] = 0x18 // PSH, ACK
+	} else {
+		binary.BigEndian.PutUint16(transport[4:6], uint16(8+len(data)))
+	}
+	binary.BigEndian.PutUint16(transport[0:2], 50000)
+	binary.BigEndian.PutUint16(transport[2:4], port)
+	packet = append(append(packet, transport...), data...)
+	binary.BigEndian.PutUint16(packet[2:4], uint16(len(packet)))
+	return packet
+}
@@ -2,19 +2,21 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"net"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/jsimonetti/sniqueue/internal/pcap"
 
-	"github.com/jsimonetti/sniqueue/internal/parse"
-	"github.com/jsimonetti/sniqueue/internal/parse/tls"
-	"github.com/jsimonetti/sniqueue/internal/tree"
+	"github.com/jsimonetti/sniqueue/matcher"
+	"github.com/jsimonetti/sniqueue/sniparse"
 
 	"github.com/florianl/go-nfqueue"
 )
@@ -23,27 +25,84 @@ var queueNumber int
 var markBadNumber int
 var markGoodNumber int
 var dropPackets bool
+var echAction string
+var vpnAction string
+var vpnList string
+var vpnConfidence string
+var sniOnly bool
+var maxFragments int
+var fragmentTimeout time.Duration
+var maxTunnels int
+var quicKeys int
+var quicVersions string
+var quicFlows int
+var quicFlowTimeout time.Duration
+var quicFallback string
+var statsInterval time.Duration
+var dnsNames int
+var startTLSPorts string
+var startTLSFlows int
+var startTLSTimeout time.Duration
+var httpFlows int
+var httpFlowTimeout time.Duration
+var serverCertFlows int
+var serverCertTimeout time.Duration
 var debug bool
 var debugpeer string
 var blog bool
 var blogBad bool
 var debugwrite bool
 var loadList listFlags
+var loadFingerprints listFlags
 var ipnet *net.IPNet
 
 func init() {
 	flag.IntVar(&queueNumber, "queue", 100, "queue number to listen on")
 	flag.IntVar(&markBadNumber, "mark", 1, "mark matched packets")
 	flag.BoolVar(&dropPackets, "drop", false, "drop matched packets (has precedence over mark)")
+	flag.StringVar(&echAction, "ech", "allow", "action for packets using Encrypted Client Hello (allow, mark or drop; Chrome and Firefox send a GREASE ECH extension on every connection, so mark and drop hit all their traffic)")
+	flag.StringVar(&vpnAction, "vpn", "allow", "action for packets starting a WireGuard, OpenVPN, SSH or Tor connection (allow, mark or drop, Tor is only recognised with low confidence and not with -snionly)")
+	flag.StringVar(&vpnList, "vpnprotocols", "wireguard,openvpn,ssh,tor", "comma separated list of the VPN protocols the vpn action applies to")
+	flag.StringVar(&vpnConfidence, "vpnconfidence", "medium", "minimum confidence of the VPN classification the vpn action applies to (low, medium or high)")
+	flag.BoolVar(&sniOnly, "snionly", false, "only extract the SNI from ClientHellos (faster, no ALPN in logs)")
+	flag.IntVar(&maxFragments, "fragments", 64, "maximum number of fragmented datagrams to reassemble at once (0 disables reassembly)")
+	flag.DurationVar(&fragmentTimeout, "fragtimeout", time.Second, "how long to hold the fragments of an incomplete datagram")
+	flag.IntVar(&maxTunnels, "tunnels", 0, "number of nested IPIP, GRE and VXLAN headers to strip to reach the inner packet (0 disables decapsulation)")
+	flag.IntVar(&quicKeys, "quickeys", 256, "number of QUIC connections to cache the Initial keys of (0 disables)")
+	flag.StringVar(&quicVersions, "quicversions", "accept", "action for QUIC packets to UDP port 443 of unknown or greased versions (accept, or drop so the client falls back to TCP)")
+	flag.IntVar(&quicFlows, "quicflows", 0, "number of QUIC connections on port 443 to follow, to correlate Retry and Version Negotiation packets with the client's Initials (0 disables, the server's packets must be queued too)")
+	flag.DurationVar(&quicFlowTimeout, "quicflowtimeout", 30*time.Second, "how long to follow an idle QUIC connection")
+	flag.StringVar(&quicFallback, "quicfallback", "none", "drop QUIC on UDP port 443 so the client falls back to TLS over TCP: comma separated list of matched (matched domains and fingerprints) and unparseable (packets that could not be decrypted or parsed), or all or none")
+	flag.DurationVar(&statsInterval, "stats", 0, "interval at which to log statistics (0 disables)")
+	flag.IntVar(&dnsNames, "dnsnames", 0, "number of DNS answers to remember, to match connections without SNI, or with ECH, by the name that resolved to their destination too (0 disables)")
+	flag.StringVar(&startTLSPorts, "starttls", "", "follow STARTTLS on these protocol:port pairs, e.g. smtp:25,smtp:587,imap:143,pop3:110,xmpp:5222 (smtp, imap, pop3 or xmpp)")
+	flag.IntVar(&startTLSFlows, "starttlsflows", 1024, "maximum number of STARTTLS flows to follow at once")
+	flag.DurationVar(&startTLSTimeout, "starttlstimeout", 30*time.Second, "how long to follow a STARTTLS flow before giving up")
+	flag.IntVar(&httpFlows, "httpflows", 1024, "number of HTTP requests split before their Host header to reassemble at once (0 disables, such requests are then accepted)")
+	flag.DurationVar(&httpFlowTimeout, "httpflowtimeout", 10*time.Second, "how long to wait for the Host header of a split HTTP request, and to judge the client's packets by it")
+	flag.IntVar(&serverCertFlows, "servercerts", 0, "number of TLS flows without SNI to follow, to match the names of the certificate of TLS 1.2 servers instead (0 disables, the server's packets must be queued too)")
+	flag.DurationVar(&serverCertTimeout, "servercerttimeout", 30*time.Second, "how long to follow a TLS flow without SNI, and to judge the server's packets by its certificate")
 	flag.BoolVar(&debug, "debug", false, "additional logging")
 	flag.StringVar(&debugpeer, "debugpeer", "0.0.0.0/0", "debug this peer only")
 	flag.BoolVar(&debugwrite, "debugwrite", false, "write unknown packets to pcap file")
 	flag.BoolVar(&blog, "log", false, "log all SNI actions")
 	flag.BoolVar(&blogBad, "logbad", false, "log bad SNI domains")
 	flag.Var(&loadList, "list", "list of domains to load (use multiple times to load more files)")
+	flag.Var(&loadFingerprints, "fingerprints", "list of JA3/JA4 fingerprints to load (use multiple times to load more files)")
 }
 
-var list tree.Tree
+var list matcher.Tree
+var fingerprints matcher.Set
+var reassembler *sniparse.Reassembler
+var startTLS *sniparse.StartTLS
+var quicConns *sniparse.QUICConnections
+var httpRequests *sniparse.HTTPRequests
+var serverCerts *sniparse.ServerCertificates
+var fallback fallbackPolicy
+var vpnProtocols map[sniparse.VPNProtocol]bool
+var vpnMinConfidence sniparse.Confidence
+var parser *sniparse.Parser
+var names *sniparse.Names
 var logger *log.Logger
 
 var pcapV4 *pcap.Writer
@@ -60,6 +119,30 @@ func main() {
 	if _, ipnet, err = net.ParseCIDR(debugpeer); err != nil {
 		logger.Fatalf("unable to parse debugpeer %s as CIDR", debugpeer)
 	}
+	switch echAction {
+	case "allow", "mark", "drop":
+	default:
+		logger.Fatalf("invalid ech action '%s', must be allow, mark or drop", echAction)
+	}
+	switch quicVersions {
+	case "accept", "drop":
+	default:
+		logger.Fatalf("invalid quicversions action '%s', must be accept or drop", quicVersions)
+	}
+	switch vpnAction {
+	case "allow", "mark", "drop":
+	default:
+		logger.Fatalf("invalid vpn action '%s', must be allow, mark or drop", vpnAction)
+	}
+	if vpnProtocols, err = parseVPNProtocols(vpnList); err != nil {
+		logger.Fatalf("invalid vpnprotocols '%s': %s", vpnList, err)
+	}
+	if vpnMinConfidence, err = parseConfidence(vpnConfidence); err != nil {
+		logger.Fatalf("invalid vpnconfidence '%s': %s", vpnConfidence, err)
+	}
+	if fallback, err = parseFallbackPolicy(quicFallback); err != nil {
+		logger.Fatalf("invalid quicfallback policy '%s': %s", quicFallback, err)
+	}
 
 	if debug {
 		logger.SetPrefix("[DEBUG] ")
@@ -85,7 +168,7 @@ func main() {
 	if !dropPackets {
 		verdict = fmt.Sprintf("mark %d (known bad) %d (known good)", markBadNumber, markGoodNumber)
 	}
-	logger.Printf("Starting on queue %d with verdict '%s'", queueNumber, verdict)
+	logger.Printf("Starting on queue %d with verdict '%s' (ech: %s, vpn: %s, quicfallback: %s)", queueNumber, verdict, echAction, vpnAction, fallback)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -97,7 +180,7 @@ func main() {
 		cancel()
 	}()
 
-	list = tree.New()
+	list = matcher.New()
 	for _, file := range loadList {
 		logger.Printf("loading domains from '%s'", file)
 		if err := list.LoadFile(file); err != nil {
@@ -106,6 +189,76 @@ func main() {
 	}
 	logger.Printf("domain list contains %d entries", list.Size())
 
+	fingerprints = matcher.NewSet()
+	for _, file := range loadFingerprints {
+		logger.Printf("loading fingerprints from '%s'", file)
+		if err := fingerprints.LoadFile(file); err != nil {
+			logger.Fatalf("error loading file '%s': %s", file, err)
+		}
+	}
+	if fingerprints.Size() > 0 {
+		if sniOnly {
+			logger.Fatalf("fingerprints cannot be matched with -snionly")
+		}
+		logger.Printf("fingerprint list contains %d entries", fingerprints.Size())
+	}
+
+	if maxFragments > 0 {
+		if fragmentTimeout <= 0 {
+			logger.Fatalf("invalid fragtimeout %s, must be positive", fragmentTimeout)
+		}
+		reassembler = sniparse.NewReassembler(maxFragments, fragmentTimeout)
+	}
+
+	if dnsNames > 0 {
+		names = sniparse.NewNames(dnsNames)
+	}
+
+	if startTLSPorts != "" {
+		ports, err := parseStartTLSPorts(startTLSPorts)
+		if err != nil {
+			logger.Fatalf("invalid starttls ports '%s': %s", startTLSPorts, err)
+		}
+		if startTLSTimeout <= 0 {
+			logger.Fatalf("invalid starttlstimeout %s, must be positive", startTLSTimeout)
+		}
+		startTLS = sniparse.NewStartTLS(ports, startTLSFlows, startTLSTimeout)
+		logger.Printf("following STARTTLS on %d ports", len(ports))
+	}
+
+	if quicFlows > 0 {
+		if quicFlowTimeout <= 0 {
+			logger.Fatalf("invalid quicflowtimeout %s, must be positive", quicFlowTimeout)
+		}
+		quicConns = sniparse.NewQUICConnections([]uint16{443}, quicFlows, quicFlowTimeout)
+	}
+
+	if httpFlows > 0 {
+		if httpFlowTimeout <= 0 {
+			logger.Fatalf("invalid httpflowtimeout %s, must be positive", httpFlowTimeout)
+		}
+		httpRequests = sniparse.NewHTTPRequests(httpFlows, httpFlowTimeout)
+	}
+
+	if serverCertFlows > 0 {
+		if serverCertTimeout <= 0 {
+			logger.Fatalf("invalid servercerttimeout %s, must be positive", serverCertTimeout)
+		}
+		serverCerts = sniparse.NewServerCertificates(serverCertFlows, serverCertTimeout)
+	}
+
+	// Packets are handled one at a time, so they can share the parser state
+	parser = sniparse.NewParser(sniparse.Options{
+		SNIOnly:    sniOnly,
+		MaxTunnels: maxTunnels,
+		StartTLS:   startTLS,
+		HTTP:       httpRequests,
+		QUICKeys:   quicKeys,
+		QUIC:       quicConns,
+
+		ServerCertificates: serverCerts,
+	})
+
 	// Set configuration options for nfqueue
 	config := nfqueue.Config{
 		NfQueue:      uint16(queueNumber),
@@ -137,6 +290,25 @@ func main() {
 		return
 	}
 
+	if reassembler != nil {
+		go runEvery(ctx, fragmentTimeout/2, func() { expireFragments(nf) })
+	}
+	if startTLS != nil {
+		go runEvery(ctx, startTLSTimeout/2, startTLS.Expire)
+	}
+	if httpRequests != nil {
+		go runEvery(ctx, httpFlowTimeout/2, httpRequests.Expire)
+	}
+	if quicConns != nil {
+		go runEvery(ctx, quicFlowTimeout/2, quicConns.Expire)
+	}
+	if serverCerts != nil {
+		go runEvery(ctx, serverCertTimeout/2, serverCerts.Expire)
+	}
+	if statsInterval > 0 {
+		go runEvery(ctx, statsInterval, logStats)
+	}
+
 	select {
 	case <-c:
 		cancel()
@@ -144,18 +316,106 @@ func main() {
 	case <-ctx.Done():
 		logger.Print("context done, closing")
 	}
+	if statsInterval > 0 {
+		logStats()
+	}
+}
+
+// runEvery calls fn at every interval until ctx is done.
+func runEvery(ctx context.Context, interval time.Duration, fn func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			fn()
+		}
+	}
+}
+
+// expireFragments releases the held verdicts of datagrams that did not
+// complete in time.
+func expireFragments(queue *nfqueue.Nfqueue) {
+	ids := reassembler.Expire()
+	if debug && len(ids) > 0 {
+		logger.Printf("Accepted %d fragments of incomplete datagrams", len(ids))
+	}
+	setVerdict(queue, ids, nfqueue.NfAccept)
 }
 
 func handle(queue *nfqueue.Nfqueue, payload []byte, id uint32) {
-	pkt, err := parse.Parse(payload)
+	ids := []uint32{id}
+	if reassembler != nil && sniparse.IsFragment(payload) {
+		datagram, released, err := reassembler.Add(payload, id)
+		if err != nil {
+			if debug {
+				logger.Printf("Reassembly error: %s", err)
+			}
+			_ = queue.SetVerdict(id, nfqueue.NfAccept)
+			return
+		}
+		if datagram == nil {
+			// Hold the verdict until the datagram is complete or expires
+			return
+		}
+		payload, ids = datagram, released
+	}
+
+	pkt, err := parser.Parse(payload)
+	switch {
+	case errors.Is(err, sniparse.StartTLSPendingError):
+		// Leave the flow unjudged until the ClientHello arrives
+		setVerdict(queue, ids, nfqueue.NfAccept)
+		return
+	case errors.Is(err, sniparse.HTTPPendingError):
+		// Leave the flow unjudged until the Host header arrives
+		setVerdict(queue, ids, nfqueue.NfAccept)
+		return
+	case errors.Is(err, sniparse.ServerCertificatePendingError):
+		// Leave the flow unjudged until the certificate is complete
+		setVerdict(queue, ids, nfqueue.NfAccept)
+		return
+	case errors.Is(err, sniparse.StartTLSDeclinedError):
+		if (debug || blog) && ipnet.Contains(pkt.Source) {
+			logger.Printf("Accepted packet without STARTTLS to '%s'", pkt.Destination)
+		}
+		if dropPackets {
+			setVerdict(queue, ids, nfqueue.NfAccept)
+			return
+		}
+		setVerdictWithMark(queue, ids, markGoodNumber)
+		return
+	case quicVersions == "drop" && isUnknownQUICVersion(pkt, err):
+		if (debug || blog) && pkt != nil && ipnet.Contains(pkt.Source) {
+			kind := "unknown"
+			if sniparse.IsGreasedQUICVersion(pkt.QUIC.Version) {
+				kind = "greased"
+			}
+			logger.Printf("Dropped QUIC packet of %s version %#x to '%s'", kind, pkt.QUIC.Version, pkt.Destination)
+		}
+		setVerdict(queue, ids, nfqueue.NfDrop)
+		return
+	}
+	if reason, ok := fallback.drops(pkt, err); ok {
+		forceFallback(queue, ids, pkt, reason)
+		return
+	}
 	if err != nil {
-		if debug && ipnet.Contains(pkt.Src()) {
-			logger.Printf("Parse error: %s", err)
-			if err != tls.UnmarshalNoTLSError && err != tls.UnmarshalNoTLSHandshakeError {
+		if debug && pkt != nil && ipnet.Contains(pkt.Source) {
+			var parseErr *sniparse.Error
+			if errors.As(err, &parseErr) {
+				logger.Printf("Parse error (%s, %s): %s", parseErr.Reason, detection(pkt), err)
+			} else {
+				logger.Printf("Parse error (%s): %s", detection(pkt), err)
+			}
+			// Keep the packets that could not be parsed for inspection
+			if !errors.Is(err, sniparse.NotInterestingError) {
 				if debugwrite {
-					if pkt.Version() == 4 {
+					if pkt.IPVersion == 4 {
 						pcapV4.WritePacket(payload)
-					} else if pkt.Version() == 6 {
+					} else if pkt.IPVersion == 6 {
 						pcapV6.WritePacket(payload)
 					}
 				}
@@ -164,36 +424,245 @@ func handle(queue *nfqueue.Nfqueue, payload []byte, id uint32) {
 				}
 			}
 		}
-		_ = queue.SetVerdict(id, nfqueue.NfAccept)
+		setVerdict(queue, ids, nfqueue.NfAccept)
 		return
 	}
 
-	if list.Match(pkt.DomainName()) {
+	name := pkt.Name
+	if pkt.HTTP != nil {
+		// Requests may name several hosts to confuse filters
+		name = listedName(pkt.HTTP.Hosts, name)
+	}
+	if pkt.Server != nil {
+		// The client sent no SNI, judge the flow by the certificate
+		name = listedName(pkt.Server.Names(), "")
+	}
+	if names != nil && pkt.DNS != nil {
+		names.Record(pkt.DNS)
+	}
+
+	if listed(pkt, name) || fingerprints.Match(pkt.JA3()) || fingerprints.Match(pkt.JA4()) {
+		if fallback.matched && isQUIC(pkt, nil) {
+			forceFallback(queue, ids, pkt, fallbackMatched)
+			return
+		}
 		if dropPackets {
-			if (debug || blog || blogBad) && ipnet.Contains(pkt.Src()) {
-				logger.Printf("Dropped packet (sni: '%s') to '%s'", pkt.DomainName(), pkt.Dst())
+			if (debug || blog || blogBad) && ipnet.Contains(pkt.Source) {
+				logger.Printf("Dropped packet (%s) to '%s'", describe(pkt), pkt.Destination)
+			}
+			setVerdict(queue, ids, nfqueue.NfDrop)
+			return
+		}
+
+		if (debug || blog || blogBad) && ipnet.Contains(pkt.Source) {
+			logger.Printf("Marked packet with %d (%s) to '%s'", markBadNumber, describe(pkt), pkt.Destination)
+		}
+
+		setVerdictWithMark(queue, ids, markBadNumber)
+		return
+	}
+
+	if hello := pkt.Hello; pkt.ECH() && echAction != "allow" {
+		if echAction == "drop" {
+			if (debug || blog || blogBad) && ipnet.Contains(pkt.Source) {
+				logger.Printf("Dropped ECH packet (outer sni: '%s', config id: %d) to '%s'", hello.ECH.OuterSNI, hello.ECH.ConfigID, pkt.Destination)
+			}
+			setVerdict(queue, ids, nfqueue.NfDrop)
+			return
+		}
+
+		if (debug || blog || blogBad) && ipnet.Contains(pkt.Source) {
+			logger.Printf("Marked ECH packet with %d (outer sni: '%s', config id: %d) to '%s'", markBadNumber, hello.ECH.OuterSNI, hello.ECH.ConfigID, pkt.Destination)
+		}
+		setVerdictWithMark(queue, ids, markBadNumber)
+		return
+	}
+
+	if vpnAction != "allow" && vpnProtocols[pkt.VPN] && pkt.VPNConfidence >= vpnMinConfidence {
+		if vpnAction == "drop" {
+			if (debug || blog || blogBad) && ipnet.Contains(pkt.Source) {
+				logger.Printf("Dropped %s packet (%s confidence, %s) to '%s'", pkt.VPN, pkt.VPNConfidence, describe(pkt), pkt.Destination)
 			}
-			_ = queue.SetVerdict(id, nfqueue.NfDrop)
+			setVerdict(queue, ids, nfqueue.NfDrop)
 			return
 		}
 
-		if (debug || blog || blogBad) && ipnet.Contains(pkt.Src()) {
-			logger.Printf("Marked packet with %d (sni: '%s') to '%s'", markBadNumber, pkt.DomainName(), pkt.Dst())
+		if (debug || blog || blogBad) && ipnet.Contains(pkt.Source) {
+			logger.Printf("Marked %s packet with %d (%s confidence, %s) to '%s'", pkt.VPN, markBadNumber, pkt.VPNConfidence, describe(pkt), pkt.Destination)
 		}
+		setVerdictWithMark(queue, ids, markBadNumber)
+		return
+	}
 
-		_ = queue.SetVerdictWithMark(id, nfqueue.NfAccept, markBadNumber)
+	if serverCerts != nil && pkt.Hello != nil && pkt.Protocol == sniparse.ProtocolTLS && pkt.Name == "" {
+		// Leave the flow unjudged, so the packets of the server are
+		// queued until its certificate can be matched
+		if (debug || blog) && ipnet.Contains(pkt.Source) {
+			logger.Printf("Accepted packet without SNI (%s) to '%s', waiting for the certificate", describe(pkt), pkt.Destination)
+		}
+		setVerdict(queue, ids, nfqueue.NfAccept)
 		return
 	}
 
-	if (debug || blog) && ipnet.Contains(pkt.Src()) {
-		logger.Printf("Accepted packet (sni: '%s') to '%s'", pkt.DomainName(), pkt.Dst())
+	if (debug || blog) && ipnet.Contains(pkt.Source) {
+		logger.Printf("Accepted packet (%s) to '%s'", describe(pkt), pkt.Destination)
 	}
 
 	if dropPackets {
-		_ = queue.SetVerdict(id, nfqueue.NfAccept)
+		setVerdict(queue, ids, nfqueue.NfAccept)
 		return
 	}
-	_ = queue.SetVerdictWithMark(id, nfqueue.NfAccept, markGoodNumber)
+	setVerdictWithMark(queue, ids, markGoodNumber)
+}
+
+// listed reports whether name, the name pkt is judged by, is in the domain
+// list. When the real name may be hidden, the name that last resolved to the
+// destination is matched as well, and alone when there is no name. The
+// public name of ECH is still matched: browsers send GREASE ECH on every
+// connection, and the destination may be shared by many sites.
+func listed(pkt *sniparse.Packet, name string) bool {
+	if names == nil || pkt.DNS != nil || (name != "" && !pkt.ECH()) {
+		return list.Match(name)
+	}
+	resolved := names.Lookup(pkt.Destination)
+	if resolved != "" && debug && ipnet.Contains(pkt.Source) {
+		logger.Printf("Packet to '%s' attributed to '%s' from DNS", pkt.Destination, resolved)
+	}
+	return list.Match(name) || list.Match(resolved)
+}
+
+// listedName returns the first of names that is in the domain list, or the
+// first of names when none is. fallback is returned when names is empty.
+func listedName(names []string, fallback string) string {
+	for _, name := range names {
+		if list.Match(name) {
+			return name
+		}
+	}
+	if len(names) == 0 {
+		return fallback
+	}
+	return names[0]
+}
+
+// setVerdict sets the verdict for all packets in ids.
+func setVerdict(queue *nfqueue.Nfqueue, ids []uint32, verdict int) {
+	for _, id := range ids {
+		_ = queue.SetVerdict(id, verdict)
+	}
+}
+
+// setVerdictWithMark accepts all packets in ids with the given mark.
+func setVerdictWithMark(queue *nfqueue.Nfqueue, ids []uint32, mark int) {
+	for _, id := range ids {
+		_ = queue.SetVerdictWithMark(id, nfqueue.NfAccept, mark)
+	}
+}
+
+// isQUIC reports whether pkt is a datagram to UDP port 443 that was parsed as
+// QUIC, or failed to parse as QUIC.
+func isQUIC(pkt *sniparse.Packet, err error) bool {
+	if pkt == nil || pkt.Transport != sniparse.UDP || pkt.DestinationPort != 443 {
+		return false
+	}
+	if pkt.Protocol == sniparse.ProtocolQUIC {
+		return true
+	}
+	var parseErr *sniparse.Error
+	return errors.As(err, &parseErr) && parseErr.Layer == sniparse.LayerQUIC
+}
+
+// isUnknownQUICVersion reports whether pkt is a datagram to UDP port 443 of a
+// QUIC version that cannot be parsed. Other UDP traffic may look like a
+// QUIC long header of an unknown version.
+func isUnknownQUICVersion(pkt *sniparse.Packet, err error) bool {
+	return errors.Is(err, sniparse.UnsupportedQUICVersionError) && isQUIC(pkt, err)
+}
+
+// forceFallback drops the QUIC packets in ids, so the client retries the
+// connection with TLS over TCP where the ClientHello can be inspected.
+func forceFallback(queue *nfqueue.Nfqueue, ids []uint32, pkt *sniparse.Packet, reason fallbackReason) {
+	fallbackCount[reason].Add(1)
+	if (debug || blog || blogBad) && ipnet.Contains(pkt.Source) {
+		if pkt.Protocol == sniparse.ProtocolQUIC {
+			logger.Printf("Forced TCP fallback (%s) by dropping QUIC packet (%s) to '%s'", reason, describe(pkt), pkt.Destination)
+		} else {
+			logger.Printf("Forced TCP fallback (%s) by dropping QUIC packet to '%s'", reason, pkt.Destination)
+		}
+	}
+	setVerdict(queue, ids, nfqueue.NfDrop)
+}
+
+// describe formats the ClientHello details of pkt for logging.
+func describe(pkt *sniparse.Packet) string {
+	alpn, ja3, ja4 := "-", "-", "-"
+	if len(pkt.ALPN) > 0 {
+		alpn = strings.Join(pkt.ALPN, ",")
+	}
+	if pkt.JA3() != "" {
+		ja3, ja4 = pkt.JA3(), pkt.JA4()
+	}
+	if pkt.Server != nil {
+		return fmt.Sprintf("%s, certificate: '%s'", detection(pkt), strings.Join(pkt.Server.Names(), ","))
+	}
+	return fmt.Sprintf("%s, sni: '%s', alpn: %s, ja3: %s, ja4: %s", detection(pkt), pkt.Name, alpn, ja3, ja4)
+}
+
+// detection formats the protocol of pkt and how sure its detection by
+// content is.
+func detection(pkt *sniparse.Packet) string {
+	if pkt.Detected == sniparse.ProtocolUnknown {
+		return fmt.Sprintf("proto: %s", pkt.Protocol)
+	}
+	return fmt.Sprintf("proto: %s (%s confidence)", pkt.Detected, pkt.Confidence)
+}
+
+// parseVPNProtocols parses a comma separated list of VPN protocol names.
+func parseVPNProtocols(value string) (map[sniparse.VPNProtocol]bool, error) {
+	names := make(map[string]sniparse.VPNProtocol)
+	for _, protocol := range []sniparse.VPNProtocol{sniparse.VPNWireGuard, sniparse.VPNOpenVPN, sniparse.VPNSSH, sniparse.VPNTor} {
+		names[protocol.String()] = protocol
+	}
+	protocols := make(map[sniparse.VPNProtocol]bool)
+	for _, name := range strings.Split(value, ",") {
+		protocol, ok := names[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown protocol '%s', must be wireguard, openvpn, ssh or tor", name)
+		}
+		protocols[protocol] = true
+	}
+	return protocols, nil
+}
+
+// parseConfidence parses the name of a confidence level.
+func parseConfidence(value string) (sniparse.Confidence, error) {
+	for _, confidence := range []sniparse.Confidence{sniparse.ConfidenceLow, sniparse.ConfidenceMedium, sniparse.ConfidenceHigh} {
+		if value == confidence.String() {
+			return confidence, nil
+		}
+	}
+	return sniparse.ConfidenceNone, fmt.Errorf("must be low, medium or high")
+}
+
+// parseStartTLSPorts parses a comma separated list of protocol:port pairs.
+func parseStartTLSPorts(value string) (map[uint16]sniparse.StartTLSProtocol, error) {
+	ports := make(map[uint16]sniparse.StartTLSProtocol)
+	for _, pair := range strings.Split(value, ",") {
+		name, port, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok {
+			return nil, fmt.Errorf("'%s' is not a protocol:port pair", pair)
+		}
+		protocol, err := sniparse.ParseStartTLSProtocol(name)
+		if err != nil {
+			return nil, err
+		}
+		number, err := strconv.ParseUint(port, 10, 16)
+		if err != nil || number == 0 {
+			return nil, fmt.Errorf("invalid port '%s'", port)
+		}
+		ports[uint16(number)] = protocol
+	}
+	return ports, nil
 }
 
 type listFlags []string
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jsimonetti/sniqueue/matcher"
+	"github.com/jsimonetti/sniqueue/sniparse"
+)
+
+func TestIsQUIC(t *testing.T) {
+	initials := splitInitials(t)
+	tests := []struct {
+		name   string
+		packet []byte
+		want   bool
+	}{
+		{
+			name:   "Initial",
+			packet: ip4Packet(17, 443, initials[0]),
+			want:   true,
+		},
+		{
+			name:   "Continuation Initial",
+			packet: ip4Packet(17, 443, initials[1]),
+			want:   true,
+		},
+		{
+			name:   "Undecryptable Initial",
+			packet: ip4Packet(17, 443, corrupt(initials[0])),
+			want:   true,
+		},
+		{
+			name:   "Initial to another port",
+			packet: ip4Packet(17, 8443, initials[0]),
+		},
+		{
+			name:   "DNS",
+			packet: ip4Packet(17, 443, []byte{0x12, 0x34, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}),
+		},
+		{
+			name:   "TCP",
+			packet: ip4Packet(6, 443, initials[0]),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkt, err := sniparse.Parse(tt.packet)
+			if got := isQUIC(pkt, err); got != tt.want {
+				t.Fatalf("isQUIC() = %v, want %v (err %v)", got, tt.want, err)
+			}
+		})
+	}
+}
+
+func TestIsUnknownQUICVersion(t *testing.T) {
+	// A long header of a greased version, as sent by clients to check
+	// that servers negotiate
+	greased := append([]byte{0xc0, 0x1a, 0x2a, 0x3a, 0x4a, 0x08, 1, 2, 3, 4, 5, 6, 7, 8, 0x00}, make([]byte, 1185)...)
+	tests := []struct {
+		name   string
+		packet []byte
+		want   bool
+	}{
+		{
+			name:   "Greased version",
+			packet: ip4Packet(17, 443, greased),
+			want:   true,
+		},
+		{
+			name:   "Greased version to another port",
+			packet: ip4Packet(17, 4500, greased),
+		},
+		{
+			name:   "Version 1",
+			packet: ip4Packet(17, 443, splitInitials(t)[0]),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkt, err := sniparse.Parse(tt.packet)
+			if got := isUnknownQUICVersion(pkt, err); got != tt.want {
+				t.Fatalf("isUnknownQUICVersion() = %v, want %v (err %v)", got, tt.want, err)
+			}
+		})
+	}
+}
+
+func TestRunEvery(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		runEvery(ctx, time.Millisecond, func() { calls <- struct{}{} })
+		close(done)
+	}()
+
+	for i := 0; i < 3; i++ {
+		<-calls
+	}
+	cancel()
+	// A tick may be pending when ctx is done
+	for {
+		select {
+		case <-calls:
+		case <-done:
+			return
+		case <-time.After(10 * time.Second):
+			t.Fatalf("runEvery did not return after ctx was done")
+		}
+	}
+}
+
+func TestListed(t *testing.T) {
+	list = matcher.New()
+	list.Append([]string{"blocked.example"})
+	names = sniparse.NewNames(8)
+	defer func() { list, names = matcher.Tree{}, nil }()
+
+	// A CDN address that last resolved for an allowed site
+	shared := net.IP{198, 51, 100, 1}
+	recordAnswer(t, "allowed.example", shared)
+	// An address that last resolved for a listed site
+	resolvedBlocked := net.IP{198, 51, 100, 2}
+	recordAnswer(t, "blocked.example", resolvedBlocked)
+	ech := &sniparse.ClientHello{ECH: &sniparse.EncryptedClientHello{}}
+
+	tests := []struct {
+		name string
+		pkt  *sniparse.Packet
+		want bool
+	}{
+		{
+			name: "Listed SNI",
+			pkt:  &sniparse.Packet{Destination: shared, Name: "blocked.example"},
+			want: true,
+		},
+		{
+			name: "Listed SNI with GREASE ECH to a shared address",
+			pkt:  &sniparse.Packet{Destination: shared, Name: "blocked.example", Hello: ech},
+			want: true,
+		},
+		{
+			name: "ECH to an address resolved for a listed name",
+			pkt:  &sniparse.Packet{Destination: resolvedBlocked, Name: "public.example", Hello: ech},
+			want: true,
+		},
+		{
+			name: "Unlisted SNI to an address resolved for a listed name",
+			pkt:  &sniparse.Packet{Destination: resolvedBlocked, Name: "public.example"},
+		},
+		{
+			name: "No SNI to an address resolved for a listed name",
+			pkt:  &sniparse.Packet{Destination: resolvedBlocked},
+			want: true,
+		},
+		{
+			name: "No SNI to a shared address",
+			pkt:  &sniparse.Packet{Destination: shared},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := listed(tt.pkt, tt.pkt.Name); got != tt.want {
+				t.Fatalf("listed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// recordAnswer records a DNS response that resolves name to the IPv4 address
+// ip.
+func recordAnswer(t *testing.T, name string, ip net.IP) {
+	message := []byte{0x12, 0x34, 0x81, 0x80, 0x00, 0x01, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00}
+	for _, label := range strings.Split(name, ".") {
+		message = append(append(message, byte(len(label))), label...)
+	}
+	message = append(message, 0x00, 0x00, 0x01, 0x00, 0x01)
+	// An A record for the question name with a TTL of 300 seconds
+	message = append(message, 0xc0, 0x0c, 0x00, 0x01, 0x00, 0x01, 0x00, 0x00, 0x01, 0x2c, 0x00, 0x04)
+	message = append(message, ip.To4()...)
+
+	pkt, err := sniparse.Parse(ip4Packet(17, 53, message))
+	if err != nil || pkt.DNS == nil {
+		t.Fatalf("unexpected DNS response: %v", err)
+	}
+	names.Record(pkt.DNS)
+}
@@ -1,7 +1,6 @@
 module github.com/jsimonetti/sniqueue
 
-go 1.21
-toolchain go1.23.5
+go 1.23.0
 
 require (
 	github.com/Lochnair/go-patricia v2.3.3+incompatible
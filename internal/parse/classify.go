@@ -0,0 +1,178 @@
+package parse
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+
+	"github.com/jsimonetti/sniqueue/internal/parse/tls"
+)
+
+// VPNProtocol is a tunnel or proxy protocol that can carry traffic past the
+// inspection of the hellos.
+type VPNProtocol int
+
+const (
+	VPNNone VPNProtocol = iota
+	VPNWireGuard
+	VPNOpenVPN
+	VPNSSH
+	VPNTor
+)
+
+func (p VPNProtocol) String() string {
+	switch p {
+	case VPNWireGuard:
+		return "wireguard"
+	case VPNOpenVPN:
+		return "openvpn"
+	case VPNSSH:
+		return "ssh"
+	case VPNTor:
+		return "tor"
+	}
+	return "none"
+}
+
+// Classification is the VPN protocol whose handshake a packet carries. Only
+// the handshakes are recognised, the packets that follow them are not.
+type Classification struct {
+	Protocol   VPNProtocol
+	Confidence Confidence
+}
+
+// WireGuard message types and their sizes (WireGuard whitepaper, section 5.4)
+const (
+	wireGuardInitiation     = 1
+	wireGuardResponse       = 2
+	wireGuardCookieReply    = 3
+	wireGuardInitiationSize = 148
+	wireGuardResponseSize   = 92
+	wireGuardCookieSize     = 64
+)
+
+// OpenVPN opcodes of the packets a client starts a session with
+const (
+	openVPNHardResetClientV2 = 7
+	openVPNHardResetClientV3 = 10
+	// openVPNSessionIDEnd is the end of the opcode and the session id
+	openVPNSessionIDEnd = 1 + 8
+)
+
+// openVPNHMACSizes are the HMAC sizes of tls-auth with SHA1, SHA256 and
+// SHA512.
+var openVPNHMACSizes = [...]int{20, 32, 64}
+
+// ClassifyTCP recognises the SSH banner and the OpenVPN session reset of a
+// client at the start of the data of a TCP segment.
+func ClassifyTCP(data []byte) Classification {
+	if isSSHBanner(data) {
+		return Classification{Protocol: VPNSSH, Confidence: ConfidenceHigh}
+	}
+	// OpenVPN over TCP prefixes its packets with their length
+	if len(data) > 2 && int(binary.BigEndian.Uint16(data[0:2])) == len(data)-2 {
+		if c := classifyOpenVPN(data[2:]); c != ConfidenceNone {
+			return Classification{Protocol: VPNOpenVPN, Confidence: c}
+		}
+	}
+	return Classification{}
+}
+
+// ClassifyUDP recognises the WireGuard handshake and the OpenVPN session
+// reset of a client in the data of a UDP datagram.
+func ClassifyUDP(data []byte) Classification {
+	if c := classifyWireGuard(data); c != ConfidenceNone {
+		return Classification{Protocol: VPNWireGuard, Confidence: c}
+	}
+	if c := classifyOpenVPN(data); c != ConfidenceNone {
+		return Classification{Protocol: VPNOpenVPN, Confidence: c}
+	}
+	return Classification{}
+}
+
+// ClassifyHello recognises the ClientHello of Tor, which connects to relays
+// with a random server name and without ALPN. Any client without ALPN may
+// use a name that looks like it, so the confidence is low.
+func ClassifyHello(hello *tls.ClientHello) Classification {
+	if len(hello.ALPN) > 0 {
+		return Classification{}
+	}
+	if !isTorHostname(hello.SNI) {
+		return Classification{}
+	}
+	return Classification{Protocol: VPNTor, Confidence: ConfidenceLow}
+}
+
+// isSSHBanner reports whether data starts with the protocol version exchange
+// of SSH (RFC 4253, section 4.2): SSH-2.0, SSH-1.99 or SSH-1.5.
+func isSSHBanner(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("SSH-2.0-")) || bytes.HasPrefix(data, []byte("SSH-1.99-")) ||
+		bytes.HasPrefix(data, []byte("SSH-1.5-"))
+}
+
+// classifyWireGuard recognises the handshake messages of WireGuard, which
+// have a fixed size and three reserved zero bytes after the type.
+func classifyWireGuard(b []byte) Confidence {
+	if len(b) < 4 || b[1] != 0 || b[2] != 0 || b[3] != 0 {
+		return ConfidenceNone
+	}
+	switch {
+	case b[0] == wireGuardInitiation && len(b) == wireGuardInitiationSize,
+		b[0] == wireGuardResponse && len(b) == wireGuardResponseSize,
+		b[0] == wireGuardCookieReply && len(b) == wireGuardCookieSize:
+		return ConfidenceHigh
+	}
+	return ConfidenceNone
+}
+
+// classifyOpenVPN recognises the hard reset packet a client starts an OpenVPN
+// session with, without authentication, with tls-auth and with tls-crypt.
+func classifyOpenVPN(b []byte) Confidence {
+	if len(b) < openVPNSessionIDEnd+5 {
+		return ConfidenceNone
+	}
+	opcode, keyID := b[0]>>3, b[0]&0x07
+	if keyID != 0 || (opcode != openVPNHardResetClientV2 && opcode != openVPNHardResetClientV3) {
+		return ConfidenceNone
+	}
+	// The session id is followed by an empty ack array and packet id 0
+	isReset := func(b []byte) bool {
+		return len(b) >= 5 && b[0] == 0 && binary.BigEndian.Uint32(b[1:5]) == 0
+	}
+	if isReset(b[openVPNSessionIDEnd:]) {
+		return ConfidenceHigh
+	}
+	// tls-auth puts a HMAC, the replay packet id 1 and a timestamp in
+	// between
+	for _, size := range openVPNHMACSizes {
+		replay := openVPNSessionIDEnd + size
+		if len(b) >= replay+8 && binary.BigEndian.Uint32(b[replay:replay+4]) == 1 && isReset(b[replay+8:]) {
+			return ConfidenceHigh
+		}
+	}
+	// tls-crypt puts the replay packet id and the timestamp before a tag,
+	// and encrypts the rest
+	if len(b) >= openVPNSessionIDEnd+8+32 && binary.BigEndian.Uint32(b[openVPNSessionIDEnd:openVPNSessionIDEnd+4]) == 1 {
+		return ConfidenceMedium
+	}
+	return ConfidenceNone
+}
+
+// isTorHostname reports whether name looks like a server name generated by
+// Tor: www., 4 to 25 base32 characters and .com.
+func isTorHostname(name string) bool {
+	random, ok := strings.CutPrefix(name, "www.")
+	if !ok {
+		return false
+	}
+	random, ok = strings.CutSuffix(random, ".com")
+	if !ok || len(random) < 4 || len(random) > 25 {
+		return false
+	}
+	for i := 0; i < len(random); i++ {
+		if c := random[i]; (c < 'a' || c > 'z') && (c < '2' || c > '7') {
+			return false
+		}
+	}
+	return true
+}
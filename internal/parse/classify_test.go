@@ -0,0 +1,127 @@
+package parse
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jsimonetti/sniqueue/internal/parse/tls"
+)
+
+func TestClassify(t *testing.T) {
+	wireGuard := func(messageType byte, size int) []byte {
+		return append([]byte{messageType, 0x00, 0x00, 0x00}, make([]byte, size-4)...)
+	}
+	sessionID := []byte{0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88}
+	reset := append(append([]byte{0x38}, sessionID...), 0x00, 0x00, 0x00, 0x00, 0x00)
+	tlsAuth := append(append([]byte{0x50}, sessionID...), bytes.Repeat([]byte{0xaa}, 20)...)
+	tlsAuth = append(tlsAuth, 0x00, 0x00, 0x00, 0x01, 0x65, 0x43, 0x21, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00)
+	tlsCrypt := append(append([]byte{0x50}, sessionID...), 0x00, 0x00, 0x00, 0x01, 0x65, 0x43, 0x21, 0x00)
+	tlsCrypt = append(tlsCrypt, bytes.Repeat([]byte{0xbb}, 64)...)
+	tcpFramed := func(packet []byte) []byte {
+		return append([]byte{byte(len(packet) >> 8), byte(len(packet))}, packet...)
+	}
+
+	tests := []struct {
+		name    string
+		udp     bool
+		payload []byte
+		want    Classification
+	}{
+		{name: "SSH 2.0", payload: []byte("SSH-2.0-OpenSSH_9.6\r\n"), want: Classification{VPNSSH, ConfidenceHigh}},
+		{name: "SSH 1.99", payload: []byte("SSH-1.99-Cisco-1.25\r\n"), want: Classification{VPNSSH, ConfidenceHigh}},
+		{name: "SSH other version", payload: []byte("SSH-3.0-x\r\n")},
+		{name: "OpenVPN TCP", payload: tcpFramed(reset), want: Classification{VPNOpenVPN, ConfidenceHigh}},
+		{name: "OpenVPN TCP wrong length", payload: append(tcpFramed(reset), 0x00)},
+		{name: "HTTP", payload: []byte("GET / HTTP/1.1\r\n")},
+		{name: "WireGuard initiation", udp: true, payload: wireGuard(1, 148), want: Classification{VPNWireGuard, ConfidenceHigh}},
+		{name: "WireGuard response", udp: true, payload: wireGuard(2, 92), want: Classification{VPNWireGuard, ConfidenceHigh}},
+		{name: "WireGuard cookie", udp: true, payload: wireGuard(3, 64), want: Classification{VPNWireGuard, ConfidenceHigh}},
+		{name: "WireGuard data", udp: true, payload: wireGuard(4, 64)},
+		{name: "WireGuard initiation size", udp: true, payload: wireGuard(1, 92)},
+		{name: "OpenVPN reset", udp: true, payload: reset, want: Classification{VPNOpenVPN, ConfidenceHigh}},
+		{name: "OpenVPN tls-auth", udp: true, payload: tlsAuth, want: Classification{VPNOpenVPN, ConfidenceHigh}},
+		{name: "OpenVPN tls-crypt", udp: true, payload: tlsCrypt, want: Classification{VPNOpenVPN, ConfidenceMedium}},
+		{name: "OpenVPN key id", udp: true, payload: append([]byte{0x39}, reset[1:]...)},
+		{name: "OpenVPN data", udp: true, payload: append([]byte{0x48}, reset[1:]...)},
+		{name: "QUIC short header", udp: true, payload: []byte{0x50, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			classify := ClassifyTCP
+			if tt.udp {
+				classify = ClassifyUDP
+			}
+			if diff := cmp.Diff(tt.want, classify(tt.payload)); diff != "" {
+				t.Fatalf("unexpected classification (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestClassifyHello(t *testing.T) {
+	tests := []struct {
+		name  string
+		hello tls.ClientHello
+		want  Classification
+	}{
+		{name: "Tor", hello: tls.ClientHello{SNI: "www.k7nvz3ixg4mq.com"}, want: Classification{VPNTor, ConfidenceLow}},
+		{name: "Tor without digits", hello: tls.ClientHello{SNI: "www.qmfdrtuw.com"}, want: Classification{VPNTor, ConfidenceLow}},
+		{name: "ALPN", hello: tls.ClientHello{SNI: "www.k7nvz3ixg4mq.com", ALPN: []string{"h2"}}},
+		{name: "Net", hello: tls.ClientHello{SNI: "www.k7nvz3ixg4mq.net"}},
+		{name: "Not base32", hello: tls.ClientHello{SNI: "www.k7nvz1ixg4mq.com"}},
+		{name: "Too long", hello: tls.ClientHello{SNI: "www.abcdefghijklmnopqrstuvwxyz.com"}},
+		{name: "No SNI"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, ClassifyHello(&tt.hello)); diff != "" {
+				t.Fatalf("unexpected classification (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParse_vpn(t *testing.T) {
+	src, dst := net.IP{192, 0, 2, 1}, net.IP{198, 51, 100, 1}
+	initiation := append([]byte{0x01, 0x00, 0x00, 0x00}, make([]byte, 144)...)
+
+	tests := []struct {
+		name    string
+		payload []byte
+		want    Classification
+	}{
+		{
+			name:    "WireGuard",
+			payload: ip4Packet(t, src, dst, 17, udpHeader(50000, 51820, initiation)),
+			want:    Classification{VPNWireGuard, ConfidenceHigh},
+		},
+		{
+			name:    "SSH on 443",
+			payload: ip4Packet(t, src, dst, 6, tcpHeader(50000, 443, []byte("SSH-2.0-OpenSSH_9.6\r\n"))),
+			want:    Classification{VPNSSH, ConfidenceHigh},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Parse(tt.payload)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var got Classification
+			switch transport := p.(*IPv4).Transport.(type) {
+			case *TCP:
+				got = transport.VPN
+			case *UDP:
+				got = transport.VPN
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Fatalf("unexpected classification (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package parse
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+)
+
+var unmarshalGREError = errors.New("insufficient bytes to Unmarshal GRE")
+var unmarshalVXLANError = errors.New("insufficient bytes to Unmarshal VXLAN")
+var unmarshalEthernetError = errors.New("insufficient bytes to Unmarshal Ethernet")
+var unmarshalTunnelDepthError = errors.New("too many nested tunnels")
+
+// IP protocols and ports of the tunnels we can strip.
+const (
+	protocolIPIP = 4
+	protocolIPv6 = 41
+	protocolGRE  = 47
+	protocolUDP  = 17
+	vxlanPort    = 4789
+)
+
+// EtherTypes as carried in GRE and Ethernet headers.
+const (
+	etherTypeIPv4        = 0x0800
+	etherTypeIPv6        = 0x86dd
+	etherTypeVLAN        = 0x8100
+	etherTypeQinQ        = 0x88a8
+	etherTypeTransparent = 0x6558
+)
+
+// Tunnel describes an outer header that was stripped to reach the packet.
+type Tunnel struct {
+	// Protocol is the IP protocol of the tunnel: 4 (IPIP), 41 (IPv6 in IP),
+	// 47 (GRE) or 17 for VXLAN over UDP.
+	Protocol    int
+	Source      net.IP
+	Destination net.IP
+	// ID is the GRE key or the VXLAN network identifier, if present.
+	ID uint32
+}
+
+// decapsulate strips at most depth tunnel headers from payload. It returns
+// the innermost packet it reached and the stripped tunnels, outermost first.
+func decapsulate(payload []byte, depth int) ([]byte, []Tunnel, error) {
+	var tunnels []Tunnel
+	for {
+		tunnel, inner, err := unwrap(payload)
+		if err != nil || inner == nil {
+			return payload, tunnels, err
+		}
+		if len(tunnels) == depth {
+			return payload, tunnels, unmarshalTunnelDepthError
+		}
+		tunnels = append(tunnels, tunnel)
+		payload = inner
+	}
+}
+
+// unwrap returns the packet encapsulated in payload, or nil if payload is
+// not a tunnel packet. Anything that cannot be parsed as an IP header is left
+// for the regular parser to report.
+func unwrap(payload []byte) (Tunnel, []byte, error) {
+	var tunnel Tunnel
+	var body []byte
+	if len(payload) < 1 {
+		return tunnel, nil, nil
+	}
+
+	switch payload[0] >> 4 {
+	case 4:
+		headerLength := int(payload[0]&0x0F) * 4
+		if len(payload) < 20 || headerLength < 20 || headerLength > len(payload) {
+			return tunnel, nil, nil
+		}
+		// Only the first fragment carries the tunnel header
+		if binary.BigEndian.Uint16(payload[6:8])&0x1fff != 0 {
+			return tunnel, nil, nil
+		}
+		tunnel.Protocol = int(payload[9])
+		tunnel.Source = payload[12:16]
+		tunnel.Destination = payload[16:20]
+		body = payload[headerLength:]
+	case 6:
+		if len(payload) < 40 {
+			return tunnel, nil, nil
+		}
+		p := IPv6{Inet: Inet{Protocol: int(payload[6])}}
+		cursor, err := p.walkExtensionHeaders(payload)
+		if err != nil {
+			return tunnel, nil, nil
+		}
+		tunnel.Protocol = p.Protocol
+		tunnel.Source = payload[8:24]
+		tunnel.Destination = payload[24:40]
+		body = payload[cursor:]
+	default:
+		return tunnel, nil, nil
+	}
+
+	switch tunnel.Protocol {
+	case protocolIPIP, protocolIPv6:
+		return tunnel, body, nil
+	case protocolGRE:
+		id, inner, err := unwrapGRE(body)
+		tunnel.ID = id
+		return tunnel, inner, err
+	case protocolUDP:
+		if len(body) < 8 || binary.BigEndian.Uint16(body[2:4]) != vxlanPort {
+			return tunnel, nil, nil
+		}
+		id, inner, err := unwrapVXLAN(body[8:])
+		tunnel.ID = id
+		return tunnel, inner, err
+	}
+	return tunnel, nil, nil
+}
+
+// unwrapGRE returns the key and the packet carried in a GRE header
+// (RFC 2784, RFC 2890). Only version 0 GRE carrying IP or Ethernet is
+// unwrapped.
+func unwrapGRE(payload []byte) (uint32, []byte, error) {
+	if len(payload) < 4 {
+		return 0, nil, unmarshalGREError
+	}
+	flags := binary.BigEndian.Uint16(payload[0:2])
+	etherType := binary.BigEndian.Uint16(payload[2:4])
+	// Source routing (RFC 1701) and enhanced GRE (PPTP) are not supported
+	if flags&0x4000 != 0 || flags&0x0007 != 0 {
+		return 0, nil, nil
+	}
+
+	cursor := 4
+	if flags&0x8000 != 0 { // checksum present
+		cursor += 4
+	}
+	var key uint32
+	if flags&0x2000 != 0 { // key present
+		if cursor+4 > len(payload) {
+			return 0, nil, unmarshalGREError
+		}
+		key = binary.BigEndian.Uint32(payload[cursor : cursor+4])
+		cursor += 4
+	}
+	if flags&0x1000 != 0 { // sequence number present
+		cursor += 4
+	}
+	if cursor > len(payload) {
+		return 0, nil, unmarshalGREError
+	}
+
+	switch etherType {
+	case etherTypeIPv4, etherTypeIPv6:
+		return key, payload[cursor:], nil
+	case etherTypeTransparent:
+		inner, err := etherPayload(payload[cursor:])
+		return key, inner, err
+	}
+	return 0, nil, nil
+}
+
+// unwrapVXLAN returns the network identifier and the packet carried in a
+// VXLAN header (RFC 7348).
+func unwrapVXLAN(payload []byte) (uint32, []byte, error) {
+	if len(payload) < 8 {
+		return 0, nil, unmarshalVXLANError
+	}
+	// The I flag must be set for a valid VNI
+	if payload[0]&0x08 == 0 {
+		return 0, nil, nil
+	}
+	vni := binary.BigEndian.Uint32(payload[4:8]) >> 8
+	inner, err := etherPayload(payload[8:])
+	return vni, inner, err
+}
+
+// etherPayload returns the IP packet in an Ethernet frame, skipping any
+// 802.1Q and 802.1ad tags. It returns nil for non-IP frames.
+func etherPayload(frame []byte) ([]byte, error) {
+	var link Link
+	payload, err := link.unmarshal(frame, LinkEthernet)
+	if err == unmarshalNonIPError {
+		return nil, nil
+	}
+	return payload, err
+}
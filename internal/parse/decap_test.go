@@ -0,0 +1,187 @@
+package parse
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParse_tunnels(t *testing.T) {
+	outerSrc, outerDst := net.IP{192, 0, 2, 1}, net.IP{192, 0, 2, 2}
+	innerSrc4, innerDst4 := net.IP{10, 0, 0, 1}, net.IP{10, 0, 0, 2}
+	innerSrc6 := net.ParseIP("2001:db8::1")
+	ip4TCP := func() []byte { return ip4Packet(t, innerSrc4, innerDst4, 6, tcpSegment) }
+	ip6TCP := func() []byte { return ip6Packet(t, nil, nil, 6, tcpSegment) }
+
+	tests := []struct {
+		name       string
+		payload    []byte
+		maxTunnels int
+		wantErr    error
+		wantSrc    net.IP
+		want       []Tunnel
+	}{
+		{
+			name:       "IPIP",
+			payload:    ip4Packet(t, outerSrc, outerDst, protocolIPIP, ip4TCP()),
+			maxTunnels: 1,
+			wantSrc:    innerSrc4,
+			want:       []Tunnel{{Protocol: protocolIPIP, Source: outerSrc, Destination: outerDst}},
+		},
+		{
+			name:       "IPv6 in IPv4",
+			payload:    ip4Packet(t, outerSrc, outerDst, protocolIPv6, ip6TCP()),
+			maxTunnels: 1,
+			wantSrc:    innerSrc6,
+			want:       []Tunnel{{Protocol: protocolIPv6, Source: outerSrc, Destination: outerDst}},
+		},
+		{
+			name:       "GRE with key and sequence over IPv6",
+			payload:    ip6Packet(t, nil, nil, protocolGRE, grePacket(0x3000, 0xcafe, etherTypeIPv4, ip4TCP())),
+			maxTunnels: 1,
+			wantSrc:    innerSrc4,
+			want: []Tunnel{{
+				Protocol:    protocolGRE,
+				Source:      net.ParseIP("2001:db8::1"),
+				Destination: net.ParseIP("2001:db8::2"),
+				ID:          0xcafe,
+			}},
+		},
+		{
+			name:       "GRE transparent Ethernet with VLAN tag",
+			payload:    ip4Packet(t, outerSrc, outerDst, protocolGRE, grePacket(0x8000, 0, etherTypeTransparent, etherFrame(etherTypeIPv6, ip6TCP(), 42))),
+			maxTunnels: 1,
+			wantSrc:    innerSrc6,
+			want:       []Tunnel{{Protocol: protocolGRE, Source: outerSrc, Destination: outerDst}},
+		},
+		{
+			name:       "VXLAN",
+			payload:    ip4Packet(t, outerSrc, outerDst, protocolUDP, vxlanPacket(0x123456, etherFrame(etherTypeIPv4, ip4TCP()))),
+			maxTunnels: 1,
+			wantSrc:    innerSrc4,
+			want:       []Tunnel{{Protocol: protocolUDP, Source: outerSrc, Destination: outerDst, ID: 0x123456}},
+		},
+		{
+			name: "VXLAN in GRE in IPIP",
+			payload: ip4Packet(t, outerSrc, outerDst, protocolIPIP,
+				ip4Packet(t, outerDst, outerSrc, protocolGRE, grePacket(0, 0, etherTypeIPv4,
+					ip4Packet(t, outerSrc, outerDst, protocolUDP, vxlanPacket(7, etherFrame(etherTypeIPv4, ip4TCP())))))),
+			maxTunnels: 3,
+			wantSrc:    innerSrc4,
+			want: []Tunnel{
+				{Protocol: protocolIPIP, Source: outerSrc, Destination: outerDst},
+				{Protocol: protocolGRE, Source: outerDst, Destination: outerSrc},
+				{Protocol: protocolUDP, Source: outerSrc, Destination: outerDst, ID: 7},
+			},
+		},
+		{
+			name: "Too many tunnels",
+			payload: ip4Packet(t, outerSrc, outerDst, protocolIPIP,
+				ip4Packet(t, outerDst, outerSrc, protocolIPIP, ip4TCP())),
+			maxTunnels: 1,
+			wantErr:    unmarshalTunnelDepthError,
+			wantSrc:    outerDst,
+			want:       []Tunnel{{Protocol: protocolIPIP, Source: outerSrc, Destination: outerDst}},
+		},
+		{
+			name:    "Decapsulation disabled",
+			payload: ip4Packet(t, outerSrc, outerDst, protocolIPIP, ip4TCP()),
+			wantErr: unmarshalNonIPError,
+			wantSrc: outerSrc,
+		},
+		{
+			name:       "Truncated GRE key",
+			payload:    ip4Packet(t, outerSrc, outerDst, protocolGRE, grePacket(0x2000, 1, etherTypeIPv4, nil)[:6]),
+			maxTunnels: 1,
+			wantErr:    unmarshalGREError,
+			wantSrc:    outerSrc,
+		},
+		{
+			name:       "GRE carrying MPLS",
+			payload:    ip4Packet(t, outerSrc, outerDst, protocolGRE, grePacket(0, 0, 0x8847, ip4TCP())),
+			maxTunnels: 1,
+			wantErr:    unmarshalNonIPError,
+			wantSrc:    outerSrc,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseWithOptions(tt.payload, Options{MaxTunnels: tt.maxTunnels})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !got.Src().Equal(tt.wantSrc) {
+				t.Errorf("Src() = %s, want %s", got.Src(), tt.wantSrc)
+			}
+			var tunnels []Tunnel
+			switch p := got.(type) {
+			case *IPv4:
+				tunnels = p.Tunnels
+			case *IPv6:
+				tunnels = p.Tunnels
+			}
+			if diff := cmp.Diff(tt.want, tunnels); diff != "" {
+				t.Fatalf("unexpected tunnels (-want +got):\n%s", diff)
+			}
+			if err == nil && got.DomainName() != "dns.google" {
+				t.Errorf("DomainName() = %q, want %q", got.DomainName(), "dns.google")
+			}
+		})
+	}
+}
+
+// ip4Packet builds an IPv4 packet carrying payload.
+func ip4Packet(t testing.TB, src, dst net.IP, protocol int, payload []byte) []byte {
+	t.Helper()
+	packet := []byte{
+		0x45, 0x00, 0x00, 0x00, 0x12, 0x34, 0x00, 0x00, 0x40, byte(protocol), 0x00, 0x00,
+	}
+	packet = append(packet, src.To4()...)
+	packet = append(packet, dst.To4()...)
+	packet = append(packet, payload...)
+	binary.BigEndian.PutUint16(packet[2:4], uint16(len(packet)))
+	return packet
+}
+
+// grePacket builds a GRE header with the given flags, followed by payload.
+func grePacket(flags uint16, key uint32, etherType uint16, payload []byte) []byte {
+	packet := binary.BigEndian.AppendUint16(nil, flags)
+	packet = binary.BigEndian.AppendUint16(packet, etherType)
+	if flags&0x8000 != 0 {
+		packet = append(packet, 0x00, 0x00, 0x00, 0x00)
+	}
+	if flags&0x2000 != 0 {
+		packet = binary.BigEndian.AppendUint32(packet, key)
+	}
+	if flags&0x1000 != 0 {
+		packet = append(packet, 0x00, 0x00, 0x00, 0x01)
+	}
+	return append(packet, payload...)
+}
+
+// vxlanPacket builds a UDP datagram to the VXLAN port carrying frame.
+func vxlanPacket(vni uint32, frame []byte) []byte {
+	packet := []byte{0xc0, 0x00, 0x12, 0xb5, 0x00, 0x00, 0x00, 0x00}
+	packet = append(packet, 0x08, 0x00, 0x00, 0x00)
+	packet = binary.BigEndian.AppendUint32(packet, vni<<8)
+	packet = append(packet, frame...)
+	binary.BigEndian.PutUint16(packet[4:6], uint16(len(packet)))
+	return packet
+}
+
+// etherFrame builds an Ethernet frame with the given VLAN tags carrying
+// payload.
+func etherFrame(etherType uint16, payload []byte, vlans ...uint16) []byte {
+	frame := []byte{
+		0x02, 0x00, 0x00, 0x00, 0x00, 0x02, 0x02, 0x00, 0x00, 0x00, 0x00, 0x01,
+	}
+	for _, vlan := range vlans {
+		frame = binary.BigEndian.AppendUint16(frame, etherTypeVLAN)
+		frame = binary.BigEndian.AppendUint16(frame, vlan)
+	}
+	frame = binary.BigEndian.AppendUint16(frame, etherType)
+	return append(frame, payload...)
+}
@@ -0,0 +1,225 @@
+package parse
+
+import (
+	"encoding/binary"
+
+	"github.com/jsimonetti/sniqueue/internal/parse/quic"
+)
+
+// DetectedProtocol is the handshake protocol a transport payload was
+// recognised as by its content.
+type DetectedProtocol int
+
+const (
+	DetectedUnknown DetectedProtocol = iota
+	DetectedTLS
+	DetectedDTLS
+	DetectedQUIC
+)
+
+func (p DetectedProtocol) String() string {
+	switch p {
+	case DetectedTLS:
+		return "tls"
+	case DetectedDTLS:
+		return "dtls"
+	case DetectedQUIC:
+		return "quic"
+	}
+	return "unknown"
+}
+
+// Confidence is how sure the content detection is of a protocol.
+type Confidence int
+
+const (
+	// ConfidenceNone is returned for payloads that are not the protocol.
+	ConfidenceNone Confidence = iota
+	// ConfidenceLow means the first bytes match the record or packet
+	// header of the protocol, as they do for many other payloads.
+	ConfidenceLow
+	// ConfidenceMedium means the header is that of a ClientHello or an
+	// Initial, with consistent lengths.
+	ConfidenceMedium
+	// ConfidenceHigh means the start of the hello or the Initial itself is
+	// valid too.
+	ConfidenceHigh
+)
+
+func (c Confidence) String() string {
+	switch c {
+	case ConfidenceLow:
+		return "low"
+	case ConfidenceMedium:
+		return "medium"
+	case ConfidenceHigh:
+		return "high"
+	}
+	return "none"
+}
+
+// Detection is the protocol a transport payload looks like, regardless of
+// the ports it was sent from and to.
+type Detection struct {
+	Protocol   DetectedProtocol
+	Confidence Confidence
+}
+
+const (
+	// maxTLSRecordLength is the largest record allowed by RFC 8446, with
+	// the expansion of a protected record.
+	maxTLSRecordLength = 1<<14 + 256
+	// minClientHelloLength is the legacy version, random, session id,
+	// cipher suites and compression methods of the smallest hello.
+	minClientHelloLength = 2 + 32 + 1 + 2 + 1
+	// minQUICInitialDatagram is the size a client pads the datagrams with
+	// its Initials to (RFC 9000, section 14.1).
+	minQUICInitialDatagram = 1200
+	// maxQUICConnectionIDLength is the longest connection id of QUIC
+	// version 1.
+	maxQUICConnectionIDLength = 20
+	// minQUICInitialDCIDLength is the shortest destination connection id
+	// of the first Initial of a client (RFC 9000, section 7.2).
+	minQUICInitialDCIDLength = 8
+
+	handshakeTypeClientHello = 0x01
+	tlsRecordTypeHandshake   = 0x16
+)
+
+// DetectTCP returns the protocol of the data of a TCP segment. It only reads
+// the first bytes of data, so payloads of other protocols are rejected
+// cheaply.
+func DetectTCP(data []byte) Detection {
+	if c := detectTLS(data); c != ConfidenceNone {
+		return Detection{Protocol: DetectedTLS, Confidence: c}
+	}
+	return Detection{}
+}
+
+// DetectUDP returns the protocol of the data of a UDP datagram. It only
+// reads the headers, so payloads of other protocols are rejected cheaply.
+func DetectUDP(data []byte) Detection {
+	if c := detectDTLS(data); c != ConfidenceNone {
+		return Detection{Protocol: DetectedDTLS, Confidence: c}
+	}
+	if c := detectQUIC(data); c != ConfidenceNone {
+		return Detection{Protocol: DetectedQUIC, Confidence: c}
+	}
+	return Detection{}
+}
+
+// detectTLS recognises the handshake record that starts a TLS stream.
+func detectTLS(b []byte) Confidence {
+	// The record version is 0x0300 to 0x0304, most clients send 0x0301
+	if len(b) < 3 || b[0] != tlsRecordTypeHandshake || b[1] != 0x03 || b[2] > 0x04 {
+		return ConfidenceNone
+	}
+	if len(b) < 6 {
+		return ConfidenceLow
+	}
+	recordLength := int(binary.BigEndian.Uint16(b[3:5]))
+	if recordLength == 0 || recordLength > maxTLSRecordLength || b[5] != handshakeTypeClientHello {
+		return ConfidenceLow
+	}
+	if recordLength < 6 || len(b) < 11 {
+		// The hello is fragmented over tiny records
+		return ConfidenceMedium
+	}
+	helloLength := int(b[6])<<16 | int(b[7])<<8 | int(b[8])
+	if helloLength < minClientHelloLength || b[9] != 0x03 || b[10] > 0x04 {
+		return ConfidenceMedium
+	}
+	return ConfidenceHigh
+}
+
+// detectDTLS recognises the handshake record that starts a DTLS exchange.
+func detectDTLS(b []byte) Confidence {
+	if len(b) < 3 || b[0] != tlsRecordTypeHandshake || b[1] != 0xfe || (b[2] != 0xff && b[2] != 0xfd && b[2] != 0xfc) {
+		return ConfidenceNone
+	}
+	// The record header is followed by the handshake header, the
+	// ClientHello is sent in epoch 0
+	if len(b) < 14 || b[3] != 0 || b[4] != 0 || b[13] != handshakeTypeClientHello {
+		return ConfidenceLow
+	}
+	if len(b) < 27 {
+		return ConfidenceMedium
+	}
+	// The ClientHello starts with its version
+	if b[25] != 0xfe || b[26] < 0xfc {
+		return ConfidenceMedium
+	}
+	return ConfidenceHigh
+}
+
+// detectQUIC recognises the Initial packets of IETF QUIC and the long and
+// public headers of Google QUIC. Short header packets have no header to
+// check and are reported with ConfidenceLow.
+func detectQUIC(b []byte) Confidence {
+	if len(b) == 0 {
+		return ConfidenceNone
+	}
+	if quic.IsGQUIC(b) {
+		return ConfidenceMedium
+	}
+	// A Version Negotiation packet has no fixed bit (RFC 8999)
+	if b[0]&0x80 != 0 && len(b) >= 5 && binary.BigEndian.Uint32(b[1:5]) == 0 {
+		return ConfidenceLow
+	}
+	// The fixed bit is set in all other QUIC packets, a client can only
+	// grease it after the handshake (RFC 9287)
+	if b[0]&0x40 == 0 {
+		return ConfidenceNone
+	}
+	if b[0]&0x80 == 0 || len(b) < 7 {
+		return ConfidenceLow
+	}
+
+	version := binary.BigEndian.Uint32(b[1:5])
+	if !quic.IsSupportedVersion(quic.SupportedVersions, version) {
+		return ConfidenceLow
+	}
+	dcidLength := int(b[5])
+	if dcidLength > maxQUICConnectionIDLength || len(b) < 6+dcidLength+1 {
+		return ConfidenceLow
+	}
+	scidLength := int(b[6+dcidLength])
+	if scidLength > maxQUICConnectionIDLength {
+		return ConfidenceLow
+	}
+	if quic.PacketType((b[0]&0x30)>>4) != quic.PacketInitial {
+		return ConfidenceLow
+	}
+
+	cursor := 6 + dcidLength + 1 + scidLength
+	tokenLength, n := readVarInt(b[min(cursor, len(b)):])
+	if n == 0 || tokenLength > uint64(len(b)-cursor-n) {
+		return ConfidenceLow
+	}
+	cursor += n + int(tokenLength)
+	length, n := readVarInt(b[cursor:])
+	if n == 0 || length > uint64(len(b)-cursor-n) {
+		return ConfidenceLow
+	}
+	if len(b) < minQUICInitialDatagram || dcidLength < minQUICInitialDCIDLength {
+		return ConfidenceMedium
+	}
+	return ConfidenceHigh
+}
+
+// readVarInt reads a QUIC variable-length integer from the start of b. It
+// returns the number of bytes read, which is 0 when b is too short.
+func readVarInt(b []byte) (uint64, int) {
+	if len(b) == 0 {
+		return 0, 0
+	}
+	n := 1 << (b[0] >> 6)
+	if len(b) < n {
+		return 0, 0
+	}
+	v := uint64(b[0] & 0x3f)
+	for _, c := range b[1:n] {
+		v = v<<8 | uint64(c)
+	}
+	return v, n
+}
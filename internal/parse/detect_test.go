@@ -0,0 +1,105 @@
+package parse
+
+import (
+	"net"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDetect(t *testing.T) {
+	hello := tcpHello4[52:]
+	initial := ip6QUICPacket[48:]
+	dtlsHello := []byte{
+		0x16, 0xfe, 0xfd, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x51,
+		0x01, 0x00, 0x00, 0x45, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x45,
+		0xfe, 0xfd,
+	}
+	// A smaller Initial of a client that does not pad its datagrams
+	shortInitial := initialPacket(0x00000001, []byte{1, 2, 3, 4, 5, 6, 7, 8}, nil, nil)
+
+	tests := []struct {
+		name    string
+		udp     bool
+		payload []byte
+		want    Detection
+	}{
+		{name: "TLS hello", payload: hello, want: Detection{DetectedTLS, ConfidenceHigh}},
+		{name: "TLS record header", payload: hello[:5], want: Detection{DetectedTLS, ConfidenceLow}},
+		{name: "TLS hello header", payload: hello[:9], want: Detection{DetectedTLS, ConfidenceMedium}},
+		{name: "TLS hello in tiny records", payload: []byte{0x16, 0x03, 0x01, 0x00, 0x01, 0x01, 0x16, 0x03, 0x01, 0x00, 0x01, 0x00}, want: Detection{DetectedTLS, ConfidenceMedium}},
+		{name: "TLS alert", payload: []byte{0x15, 0x03, 0x03, 0x00, 0x02, 0x02, 0x28}},
+		{name: "TLS ServerHello", payload: []byte{0x16, 0x03, 0x03, 0x00, 0x5a, 0x02, 0x00, 0x00, 0x56, 0x03, 0x03}, want: Detection{DetectedTLS, ConfidenceLow}},
+		{name: "HTTP", payload: []byte("GET / HTTP/1.1\r\n")},
+		{name: "SSH", payload: []byte("SSH-2.0-OpenSSH_9.6\r\n")},
+		{name: "Empty", payload: []byte{}},
+		{name: "QUIC Initial", udp: true, payload: initial, want: Detection{DetectedQUIC, ConfidenceHigh}},
+		{name: "QUIC Initial not padded", udp: true, payload: shortInitial, want: Detection{DetectedQUIC, ConfidenceMedium}},
+		{name: "QUIC Initial truncated", udp: true, payload: initial[:100], want: Detection{DetectedQUIC, ConfidenceLow}},
+		{name: "QUIC unknown version", udp: true, payload: longHeader(0xc0, 0x1a2a3a4a, []byte{1}, nil, nil), want: Detection{DetectedQUIC, ConfidenceLow}},
+		{name: "QUIC Handshake", udp: true, payload: longHeader(0xe0, 0x00000001, []byte{1}, nil, []byte{0x01, 0x00}), want: Detection{DetectedQUIC, ConfidenceLow}},
+		{name: "QUIC Version Negotiation", udp: true, payload: longHeader(0x80, 0, []byte{1}, nil, []byte{0, 0, 0, 1}), want: Detection{DetectedQUIC, ConfidenceLow}},
+		{name: "QUIC short header", udp: true, payload: []byte{0x40, 0x01, 0x02, 0x03}, want: Detection{DetectedQUIC, ConfidenceLow}},
+		{name: "QUIC connection id too long", udp: true, payload: longHeader(0xc0, 0x00000001, make([]byte, 21), nil, nil), want: Detection{DetectedQUIC, ConfidenceLow}},
+		{name: "gQUIC", udp: true, payload: []byte{0xc3, 'Q', '0', '4', '6', 0x50}, want: Detection{DetectedQUIC, ConfidenceMedium}},
+		{name: "DTLS hello", udp: true, payload: dtlsHello, want: Detection{DetectedDTLS, ConfidenceHigh}},
+		{name: "DTLS application data", udp: true, payload: []byte{0x16, 0xfe, 0xfd, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x10, 0x14}, want: Detection{DetectedDTLS, ConfidenceLow}},
+		{name: "WireGuard", udp: true, payload: []byte{0x01, 0x00, 0x00, 0x00, 0x12, 0x34, 0x56, 0x78}},
+		{name: "TLS over UDP", udp: true, payload: hello},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detect := DetectTCP
+			if tt.udp {
+				detect = DetectUDP
+			}
+			if diff := cmp.Diff(tt.want, detect(tt.payload)); diff != "" {
+				t.Fatalf("unexpected detection (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParse_anyPort(t *testing.T) {
+	src, dst := net.IP{192, 0, 2, 1}, net.IP{198, 51, 100, 1}
+	datagram := func(port uint16, data []byte) []byte {
+		return ip4Packet(t, src, dst, 17, udpHeader(50000, port, data))
+	}
+	initial := make([]byte, len(ip6QUICPacket)-48)
+
+	tests := []struct {
+		name    string
+		payload func() []byte
+		want    string
+	}{
+		{
+			name: "TLS on 8443",
+			payload: func() []byte {
+				return ip4Packet(t, src, dst, 6, tcpHeader(50000, 8443, tcpHello4[52:]))
+			},
+			want: "dns.google",
+		},
+		{
+			name: "QUIC on 4433",
+			payload: func() []byte {
+				// The Initial is decrypted in place
+				copy(initial, ip6QUICPacket[48:])
+				return datagram(4433, initial)
+			},
+			want: "r2---sn-fxc25nn-nwje.googlevideo.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Parse(tt.payload())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := p.DomainName(); got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,7 @@
+package dns
+
+import "errors"
+
+var UnmarshalDNSError = errors.New("insufficient bytes to Unmarshal DNS")
+var UnmarshalDNSNameError = errors.New("malformed DNS name")
+var UnmarshalNoDNSQuestionError = errors.New("DNS message without question")
@@ -0,0 +1,46 @@
+package dns
+
+import (
+	"bytes"
+	"testing"
+)
+
+func FuzzMessage_Unmarshal(f *testing.F) {
+	f.Add(dnsQuery, false)
+	f.Add(dnsResponse, false)
+	f.Add(append([]byte{0x00, byte(len(dnsResponse))}, dnsResponse...), true)
+
+	f.Fuzz(func(t *testing.T, data []byte, tcp bool) {
+		orig := bytes.Clone(data)
+		var m Message
+		var err error
+		if tcp {
+			err = m.UnmarshalTCP(data)
+		} else {
+			err = m.Unmarshal(data)
+		}
+		if !bytes.Equal(data, orig) {
+			t.Fatalf("payload was modified")
+		}
+		if err != nil {
+			return
+		}
+		if len(m.Questions) > maxQuestions {
+			t.Fatalf("%d questions decoded", len(m.Questions))
+		}
+		for _, a := range m.Answers {
+			if len(a.Name) > maxNameLength || len(a.Target) > maxNameLength {
+				t.Fatalf("name longer than %d bytes decoded", maxNameLength)
+			}
+			if (a.Type == TypeA || a.Type == TypeAAAA) && a.IP == nil {
+				t.Fatalf("address record without address decoded")
+			}
+		}
+		// The answers of a response are remembered by their address
+		names := NewNames(4)
+		names.Record(&m)
+		for _, a := range m.Answers {
+			names.Lookup(a.IP)
+		}
+	})
+}
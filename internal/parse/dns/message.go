@@ -0,0 +1,194 @@
+package dns
+
+import (
+	"encoding/binary"
+	"net"
+	"strings"
+)
+
+// Resource record types we decode.
+const (
+	TypeA     uint16 = 1
+	TypeCNAME uint16 = 5
+	TypeAAAA  uint16 = 28
+)
+
+const (
+	// maxNameLength is the maximum length of a name in presentation format
+	maxNameLength = 255
+	// maxPointers bounds the compression pointers followed for one name
+	maxPointers = 16
+	// maxQuestions bounds the questions decoded from one message
+	maxQuestions = 16
+)
+
+// Message is a DNS query or response.
+type Message struct {
+	ID       uint16
+	Response bool
+	Opcode   uint8
+	RCode    uint8
+	// Questions is set for queries and responses. Answers only holds the
+	// A, AAAA and CNAME records of responses.
+	Questions []Question
+	Answers   []Answer
+}
+
+type Question struct {
+	Name  string
+	Type  uint16
+	Class uint16
+}
+
+type Answer struct {
+	Name string
+	Type uint16
+	TTL  uint32
+	// IP is set for A and AAAA records
+	IP net.IP
+	// Target is set for CNAME records
+	Target string
+}
+
+// Name returns the name of the first question, which is the name that is
+// being resolved.
+func (m *Message) Name() string {
+	if len(m.Questions) == 0 {
+		return ""
+	}
+	return m.Questions[0].Name
+}
+
+// Unmarshal decodes a DNS message as carried in UDP.
+func (m *Message) Unmarshal(payload []byte) error {
+	if len(payload) < 12 {
+		return UnmarshalDNSError
+	}
+	m.ID = binary.BigEndian.Uint16(payload[0:2])
+	flags := binary.BigEndian.Uint16(payload[2:4])
+	m.Response = flags&0x8000 != 0
+	m.Opcode = uint8(flags>>11) & 0x0f
+	m.RCode = uint8(flags & 0x0f)
+	questions := int(binary.BigEndian.Uint16(payload[4:6]))
+	answers := int(binary.BigEndian.Uint16(payload[6:8]))
+	if questions == 0 {
+		return UnmarshalNoDNSQuestionError
+	}
+
+	cursor := 12
+	for i := 0; i < questions && i < maxQuestions; i++ {
+		name, next, err := readName(payload, cursor)
+		if err != nil {
+			return err
+		}
+		if next+4 > len(payload) {
+			return UnmarshalDNSError
+		}
+		m.Questions = append(m.Questions, Question{
+			Name:  name,
+			Type:  binary.BigEndian.Uint16(payload[next : next+2]),
+			Class: binary.BigEndian.Uint16(payload[next+2 : next+4]),
+		})
+		cursor = next + 4
+	}
+	if !m.Response || questions > maxQuestions {
+		return nil
+	}
+
+	for i := 0; i < answers; i++ {
+		name, next, err := readName(payload, cursor)
+		if err != nil {
+			return err
+		}
+		if next+10 > len(payload) {
+			return UnmarshalDNSError
+		}
+		rrType := binary.BigEndian.Uint16(payload[next : next+2])
+		ttl := binary.BigEndian.Uint32(payload[next+4 : next+8])
+		length := int(binary.BigEndian.Uint16(payload[next+8 : next+10]))
+		data := next + 10
+		if data+length > len(payload) {
+			return UnmarshalDNSError
+		}
+		cursor = data + length
+
+		answer := Answer{Name: name, Type: rrType, TTL: ttl}
+		switch {
+		case rrType == TypeA && length == net.IPv4len, rrType == TypeAAAA && length == net.IPv6len:
+			answer.IP = net.IP(payload[data:cursor])
+		case rrType == TypeCNAME:
+			if answer.Target, _, err = readName(payload, data); err != nil {
+				return err
+			}
+		default:
+			continue
+		}
+		m.Answers = append(m.Answers, answer)
+	}
+	return nil
+}
+
+// UnmarshalTCP decodes a DNS message as carried in TCP, prefixed with its
+// length.
+func (m *Message) UnmarshalTCP(payload []byte) error {
+	if len(payload) < 2 {
+		return UnmarshalDNSError
+	}
+	length := int(binary.BigEndian.Uint16(payload[0:2]))
+	payload = payload[2:]
+	if length < len(payload) {
+		payload = payload[:length]
+	}
+	return m.Unmarshal(payload)
+}
+
+// readName reads the possibly compressed name at offset in message. It
+// returns the name without the trailing dot and the offset after the name.
+func readName(message []byte, offset int) (string, int, error) {
+	var name strings.Builder
+	next := -1
+	pointers := 0
+	for {
+		if offset >= len(message) {
+			return "", 0, UnmarshalDNSError
+		}
+		length := int(message[offset])
+		switch length & 0xc0 {
+		case 0x00:
+			if length == 0 {
+				if next < 0 {
+					next = offset + 1
+				}
+				return name.String(), next, nil
+			}
+			if offset+1+length > len(message) {
+				return "", 0, UnmarshalDNSError
+			}
+			if name.Len() > 0 {
+				name.WriteByte('.')
+			}
+			name.Write(message[offset+1 : offset+1+length])
+			if name.Len() > maxNameLength {
+				return "", 0, UnmarshalDNSNameError
+			}
+			offset += 1 + length
+		case 0xc0:
+			if offset+2 > len(message) {
+				return "", 0, UnmarshalDNSError
+			}
+			pointer := int(binary.BigEndian.Uint16(message[offset:offset+2]) & 0x3fff)
+			// Pointers must point backwards, which also prevents loops
+			if pointer >= offset || pointers >= maxPointers {
+				return "", 0, UnmarshalDNSNameError
+			}
+			pointers++
+			if next < 0 {
+				next = offset + 2
+			}
+			offset = pointer
+		default:
+			// The extended label types are obsolete
+			return "", 0, UnmarshalDNSNameError
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package dns
+
+import (
+	"net"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMessage_Unmarshal(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload []byte
+		want    *Message
+		wantErr error
+	}{
+		{
+			name:    "Query",
+			payload: dnsQuery,
+			want: &Message{
+				ID:        0x1a2b,
+				Questions: []Question{{Name: "www.example.com", Type: TypeA, Class: 1}},
+			},
+		},
+		{
+			name:    "Response with CNAME",
+			payload: dnsResponse,
+			want: &Message{
+				ID:        0x1a2b,
+				Response:  true,
+				Questions: []Question{{Name: "www.example.com", Type: TypeA, Class: 1}},
+				Answers: []Answer{
+					{Name: "www.example.com", Type: TypeCNAME, TTL: 300, Target: "edge.example.net"},
+					{Name: "edge.example.net", Type: TypeA, TTL: 60, IP: net.IP{93, 184, 216, 34}},
+					{Name: "edge.example.net", Type: TypeAAAA, TTL: 7200, IP: net.ParseIP("2606:2800:220:1:248:1893:25c8:1946")},
+				},
+			},
+		},
+		{
+			name:    "Truncated answer",
+			payload: dnsResponse[:len(dnsResponse)-4],
+			wantErr: UnmarshalDNSError,
+		},
+		{
+			name: "No question",
+			payload: []byte{
+				0x1a, 0x2b, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+			},
+			wantErr: UnmarshalNoDNSQuestionError,
+		},
+		{
+			name: "Pointer loop",
+			payload: []byte{
+				0x1a, 0x2b, 0x01, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x01, 0x61, 0xc0, 0x0c, 0x00, 0x01, 0x00, 0x01,
+			},
+			wantErr: UnmarshalDNSNameError,
+		},
+		{
+			name: "Label beyond message",
+			payload: []byte{
+				0x1a, 0x2b, 0x01, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x3f, 0x61,
+			},
+			wantErr: UnmarshalDNSError,
+		},
+		{
+			name:    "Short header",
+			payload: []byte{0x1a, 0x2b, 0x01},
+			wantErr: UnmarshalDNSError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := &Message{}
+			err := got.Unmarshal(tt.payload)
+			if err != tt.wantErr {
+				t.Fatalf("Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Fatalf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMessage_UnmarshalTCP(t *testing.T) {
+	payload := append([]byte{0x00, byte(len(dnsQuery))}, dnsQuery...)
+	got := &Message{}
+	if err := got.UnmarshalTCP(payload); err != nil {
+		t.Fatalf("UnmarshalTCP() error = %v", err)
+	}
+	if got.Name() != "www.example.com" {
+		t.Errorf("Name() = %q, want %q", got.Name(), "www.example.com")
+	}
+}
+
+// dnsQuery is a query for the A record of www.example.com with an EDNS0
+// OPT record.
+var dnsQuery = []byte{
+	0x1a, 0x2b, 0x01, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01,
+	0x03, 0x77, 0x77, 0x77, 0x07, 0x65, 0x78, 0x61, 0x6d, 0x70, 0x6c, 0x65,
+	0x03, 0x63, 0x6f, 0x6d, 0x00, 0x00, 0x01, 0x00, 0x01, 0x00, 0x00, 0x29,
+	0x04, 0xd0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+}
+
+// dnsResponse answers dnsQuery with a CNAME to edge.example.net and its A
+// and AAAA records, using name compression.
+var dnsResponse = []byte{
+	0x1a, 0x2b, 0x81, 0x80, 0x00, 0x01, 0x00, 0x03, 0x00, 0x00, 0x00, 0x00,
+	0x03, 0x77, 0x77, 0x77, 0x07, 0x65, 0x78, 0x61, 0x6d, 0x70, 0x6c, 0x65,
+	0x03, 0x63, 0x6f, 0x6d, 0x00, 0x00, 0x01, 0x00, 0x01, 0xc0, 0x0c, 0x00,
+	0x05, 0x00, 0x01, 0x00, 0x00, 0x01, 0x2c, 0x00, 0x12, 0x04, 0x65, 0x64,
+	0x67, 0x65, 0x07, 0x65, 0x78, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x03, 0x6e,
+	0x65, 0x74, 0x00, 0xc0, 0x2d, 0x00, 0x01, 0x00, 0x01, 0x00, 0x00, 0x00,
+	0x3c, 0x00, 0x04, 0x5d, 0xb8, 0xd8, 0x22, 0xc0, 0x2d, 0x00, 0x1c, 0x00,
+	0x01, 0x00, 0x00, 0x1c, 0x20, 0x00, 0x10, 0x26, 0x06, 0x28, 0x00, 0x02,
+	0x20, 0x00, 0x01, 0x02, 0x48, 0x18, 0x93, 0x25, 0xc8, 0x19, 0x46,
+}
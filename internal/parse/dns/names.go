@@ -0,0 +1,94 @@
+package dns
+
+import (
+	"net"
+	"net/netip"
+	"sync"
+	"time"
+)
+
+// maxTTL caps how long an answer is remembered, regardless of its TTL.
+const maxTTL = time.Hour
+
+type nameEntry struct {
+	name    string
+	expires time.Time
+}
+
+// Names remembers the names that resolved to addresses, so that later
+// connections to an address can be attributed to the name. It is safe for
+// concurrent use.
+type Names struct {
+	mu      sync.Mutex
+	size    int
+	entries map[netip.Addr]nameEntry
+
+	// now is replaced in tests
+	now func() time.Time
+}
+
+// NewNames returns a Names that remembers at most size addresses.
+func NewNames(size int) *Names {
+	return &Names{
+		size:    size,
+		entries: make(map[netip.Addr]nameEntry),
+		now:     time.Now,
+	}
+}
+
+// Record remembers the A and AAAA answers of a response. The addresses are
+// attributed to the question name, also when they were reached through a
+// CNAME.
+func (n *Names) Record(m *Message) {
+	if !m.Response || m.Name() == "" {
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	now := n.now()
+	for _, answer := range m.Answers {
+		addr, ok := netip.AddrFromSlice(answer.IP)
+		if !ok {
+			continue
+		}
+		ttl := time.Duration(answer.TTL) * time.Second
+		if ttl > maxTTL {
+			ttl = maxTTL
+		}
+		if _, exists := n.entries[addr.Unmap()]; !exists && len(n.entries) >= n.size {
+			n.expire(now)
+			if len(n.entries) >= n.size {
+				continue
+			}
+		}
+		n.entries[addr.Unmap()] = nameEntry{name: m.Name(), expires: now.Add(ttl)}
+	}
+}
+
+// Lookup returns the name that last resolved to ip, or an empty string.
+func (n *Names) Lookup(ip net.IP) string {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return ""
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	entry, ok := n.entries[addr.Unmap()]
+	if !ok || !n.now().Before(entry.expires) {
+		return ""
+	}
+	return entry.name
+}
+
+// expire removes the entries whose TTL has passed.
+func (n *Names) expire(now time.Time) {
+	for addr, entry := range n.entries {
+		if !now.Before(entry.expires) {
+			delete(n.entries, addr)
+		}
+	}
+}
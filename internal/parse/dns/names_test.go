@@ -0,0 +1,49 @@
+package dns
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestNames(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	n := NewNames(2)
+	n.now = func() time.Time { return now }
+
+	response := &Message{}
+	if err := response.Unmarshal(dnsResponse); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	n.Record(response)
+
+	v4, v6 := net.IP{93, 184, 216, 34}, net.ParseIP("2606:2800:220:1:248:1893:25c8:1946")
+	for _, ip := range []net.IP{v4, v4.To16(), v6} {
+		if got := n.Lookup(ip); got != "www.example.com" {
+			t.Errorf("Lookup(%s) = %q, want %q", ip, got, "www.example.com")
+		}
+	}
+
+	// The table is full until the A record expires after 60s
+	other := &Message{
+		Response:  true,
+		Questions: []Question{{Name: "other.example"}},
+		Answers:   []Answer{{Type: TypeA, TTL: 60, IP: net.IP{192, 0, 2, 1}}},
+	}
+	n.Record(other)
+	if got := n.Lookup(net.IP{192, 0, 2, 1}); got != "" {
+		t.Errorf("Lookup() = %q in a full table", got)
+	}
+
+	now = now.Add(time.Minute)
+	if got := n.Lookup(v4); got != "" {
+		t.Errorf("Lookup(%s) = %q after the TTL", v4, got)
+	}
+	n.Record(other)
+	if got := n.Lookup(net.IP{192, 0, 2, 1}); got != "other.example" {
+		t.Errorf("Lookup() = %q, want %q", got, "other.example")
+	}
+	if got := n.Lookup(v6); got != "www.example.com" {
+		t.Errorf("Lookup(%s) = %q, want %q", v6, got, "www.example.com")
+	}
+}
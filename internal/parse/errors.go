@@ -0,0 +1,226 @@
+package parse
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jsimonetti/sniqueue/internal/parse/dns"
+	"github.com/jsimonetti/sniqueue/internal/parse/http"
+	"github.com/jsimonetti/sniqueue/internal/parse/quic"
+	"github.com/jsimonetti/sniqueue/internal/parse/tls"
+)
+
+// The classes of parse errors. Every error returned by Parse matches one of
+// them when compared with errors.Is.
+var (
+	// NotInterestingError matches packets that are valid, but carry nothing
+	// to extract: segments without data, protocols other than the supported
+	// ones, QUIC packets other than Initials and so on.
+	NotInterestingError = errors.New("nothing to extract from packet")
+	// MalformedError matches packets that violate their protocol.
+	MalformedError = errors.New("malformed packet")
+	// TruncatedError matches packets that end before a header or message is
+	// complete.
+	TruncatedError = errors.New("truncated packet")
+	// UnsupportedError matches packets that use a protocol version or
+	// feature that cannot be parsed, or that exceed a configured limit.
+	UnsupportedError = errors.New("unsupported packet")
+)
+
+// Layer is the protocol layer a parse error occurred in.
+type Layer int
+
+const (
+	LayerLink Layer = iota + 1
+	LayerIP
+	LayerTunnel
+	LayerTCP
+	LayerUDP
+	LayerTLS
+	LayerDTLS
+	LayerQUIC
+	LayerHTTP
+	LayerDNS
+	LayerStartTLS
+	LayerCertificate
+)
+
+var layerNames = map[Layer]string{
+	LayerLink:        "link",
+	LayerIP:          "ip",
+	LayerTunnel:      "tunnel",
+	LayerTCP:         "tcp",
+	LayerUDP:         "udp",
+	LayerTLS:         "tls",
+	LayerDTLS:        "dtls",
+	LayerQUIC:        "quic",
+	LayerHTTP:        "http",
+	LayerDNS:         "dns",
+	LayerStartTLS:    "starttls",
+	LayerCertificate: "certificate",
+}
+
+func (l Layer) String() string {
+	if name, ok := layerNames[l]; ok {
+		return name
+	}
+	return fmt.Sprintf("Layer(%d)", int(l))
+}
+
+// Reason classifies a parse error as one of NotInterestingError,
+// MalformedError, TruncatedError or UnsupportedError.
+type Reason int
+
+const (
+	ReasonNotInteresting Reason = iota + 1
+	ReasonMalformed
+	ReasonTruncated
+	ReasonUnsupported
+)
+
+var reasonErrors = map[Reason]error{
+	ReasonNotInteresting: NotInterestingError,
+	ReasonMalformed:      MalformedError,
+	ReasonTruncated:      TruncatedError,
+	ReasonUnsupported:    UnsupportedError,
+}
+
+func (r Reason) String() string {
+	switch r {
+	case ReasonNotInteresting:
+		return "not interesting"
+	case ReasonMalformed:
+		return "malformed"
+	case ReasonTruncated:
+		return "truncated"
+	case ReasonUnsupported:
+		return "unsupported"
+	}
+	return fmt.Sprintf("Reason(%d)", int(r))
+}
+
+// Error is the error returned by Parse. It wraps the error of the layer that
+// failed, so errors.Is matches both that error and the class of the Reason.
+type Error struct {
+	Layer  Layer
+	Reason Reason
+	// Offset is the position in the parsed buffer where the failing header
+	// or message starts, or where the truncated field starts when known.
+	Offset int
+	Err    error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s at offset %d: %s", e.Layer, e.Offset, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is the class of the error.
+func (e *Error) Is(target error) bool {
+	return reasonErrors[e.Reason] == target
+}
+
+// reasons classifies the errors of all layers.
+var reasons = map[error]Reason{
+	unmarshalIPError:           ReasonTruncated,
+	unmarshalIP4Error:          ReasonMalformed,
+	unmarshalIP6Error:          ReasonTruncated,
+	unmarshalNonIPError:        ReasonNotInteresting,
+	unmarshalIP6ExtensionError: ReasonTruncated,
+	unmarshalFragmentError:     ReasonNotInteresting,
+	unmarshalIP6HeaderError:    ReasonNotInteresting,
+	unmarshalGREError:          ReasonTruncated,
+	unmarshalVXLANError:        ReasonTruncated,
+	unmarshalEthernetError:     ReasonTruncated,
+	unmarshalTunnelDepthError:  ReasonUnsupported,
+	unmarshalSLLError:          ReasonTruncated,
+	unmarshalLinkTypeError:     ReasonUnsupported,
+	unmarshalTCPError:          ReasonMalformed,
+	unmarshalTCPControlError:   ReasonNotInteresting,
+	unmarshalUDPError:          ReasonMalformed,
+	errTruncatedPacket:         ReasonTruncated,
+	StartTLSPendingError:       ReasonNotInteresting,
+	StartTLSDeclinedError:      ReasonNotInteresting,
+	startTLSFullError:          ReasonUnsupported,
+
+	HTTPPendingError: ReasonNotInteresting,
+
+	ServerCertificatePendingError: ReasonNotInteresting,
+	serverFlightSizeError:         ReasonUnsupported,
+
+	tls.UnmarshalNoTLSError:          ReasonNotInteresting,
+	tls.UnmarshalNoTLSHandshakeError: ReasonNotInteresting,
+	tls.UnmarshalClientHelloError:    ReasonMalformed,
+	tls.UnmarshalTLSVersionError:     ReasonUnsupported,
+	tls.UnmarshalECHError:            ReasonMalformed,
+	tls.UnmarshalExtensionError:      ReasonMalformed,
+
+	tls.UnmarshalServerHelloError:          ReasonTruncated,
+	tls.UnmarshalEncryptedCertificateError: ReasonUnsupported,
+	tls.UnmarshalNoCertificateError:        ReasonNotInteresting,
+	tls.UnmarshalCertificateError:          ReasonMalformed,
+
+	quic.UnmarshalQUICError:              ReasonMalformed,
+	quic.UnmarshalNoQUICError:            ReasonNotInteresting,
+	quic.UnmarshalNoQUICInitialError:     ReasonNotInteresting,
+	quic.UnmarshalQUICBitsError:          ReasonMalformed,
+	quic.UnmarshalQUICUnsupportedVersion: ReasonUnsupported,
+	quic.UnmarshalQUICFrameError:         ReasonUnsupported,
+	quic.UnmarshalNoQUICCryptoError:      ReasonNotInteresting,
+	quic.UnmarshalNoCHLOError:            ReasonNotInteresting,
+	quic.UnmarshalCHLOError:              ReasonMalformed,
+
+	quic.UnmarshalQUICContinuationError: ReasonNotInteresting,
+
+	http.UnmarshalRequestError:    ReasonMalformed,
+	http.UnmarshalHostError:       ReasonMalformed,
+	http.UnmarshalHeaderSizeError: ReasonUnsupported,
+	http.UnmarshalIncompleteError: ReasonTruncated,
+
+	dns.UnmarshalDNSError:           ReasonTruncated,
+	dns.UnmarshalNoDNSQuestionError: ReasonNotInteresting,
+	dns.UnmarshalDNSNameError:       ReasonMalformed,
+}
+
+// reason returns the class of an error of any layer. Errors that are not
+// known, such as decryption failures, are malformed.
+func reason(err error) Reason {
+	if _, ok := err.(*tls.TruncatedError); ok {
+		return ReasonTruncated
+	}
+	for err != nil {
+		if r, ok := reasons[err]; ok {
+			return r
+		}
+		err = errors.Unwrap(err)
+	}
+	return ReasonMalformed
+}
+
+// wrapError returns err as an *Error of the given layer, which starts at
+// offset in the parsed buffer. An *Error of an inner layer keeps its layer
+// and has its offset moved by offset.
+func (o Options) wrapError(layer Layer, offset int, err error) error {
+	if err == nil {
+		return nil
+	}
+	if e, ok := err.(*Error); ok {
+		e.Offset += offset
+		return e
+	}
+
+	var e *Error
+	if o.parser != nil {
+		e = &o.parser.err
+	} else {
+		e = &Error{}
+	}
+	*e = Error{Layer: layer, Reason: reason(err), Offset: offset, Err: err}
+	if truncated, ok := err.(*tls.TruncatedError); ok {
+		e.Offset += truncated.Offset
+	}
+	return e
+}
@@ -0,0 +1,140 @@
+package parse
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/jsimonetti/sniqueue/internal/parse/quic"
+	"github.com/jsimonetti/sniqueue/internal/parse/tls"
+)
+
+func TestParse_errors(t *testing.T) {
+	src, dst := net.IP{192, 0, 2, 1}, net.IP{192, 0, 2, 2}
+	hello := tcpHello4[52:]
+
+	tests := []struct {
+		name    string
+		payload []byte
+		opts    Options
+		want    Error
+		wantErr error
+		class   error
+	}{
+		{
+			name:    "Empty",
+			payload: []byte{},
+			want:    Error{Layer: LayerIP, Reason: ReasonTruncated},
+			wantErr: unmarshalIPError,
+			class:   TruncatedError,
+		},
+		{
+			name:    "IPv4 header length",
+			payload: ip4Packet(t, src, dst, 6, nil)[:19],
+			want:    Error{Layer: LayerIP, Reason: ReasonMalformed},
+			wantErr: unmarshalIP4Error,
+			class:   MalformedError,
+		},
+		{
+			name:    "TCP without data",
+			payload: ip4Packet(t, src, dst, 6, tcpHeader(50000, 443, nil)),
+			want:    Error{Layer: LayerTCP, Reason: ReasonNotInteresting, Offset: 20},
+			wantErr: tls.UnmarshalNoTLSError,
+			class:   NotInterestingError,
+		},
+		{
+			name:    "TLS truncated",
+			payload: ip4Packet(t, src, dst, 6, tcpHeader(50000, 443, hello[:20])),
+			want:    Error{Layer: LayerTLS, Reason: ReasonTruncated, Offset: 40 + 11},
+			wantErr: tls.UnmarshalClientHelloError,
+			class:   TruncatedError,
+		},
+		{
+			name:    "QUIC short header",
+			payload: ip4Packet(t, src, dst, 17, udpHeader(50000, 443, []byte{0x40, 0x01, 0x02, 0x03})),
+			want:    Error{Layer: LayerQUIC, Reason: ReasonNotInteresting, Offset: 28},
+			wantErr: quic.UnmarshalNoQUICInitialError,
+			class:   NotInterestingError,
+		},
+		{
+			name:    "UDP without handshake",
+			payload: ip4Packet(t, src, dst, 17, udpHeader(50000, 51820, []byte{0x01, 0x00, 0x00, 0x00})),
+			want:    Error{Layer: LayerUDP, Reason: ReasonNotInteresting, Offset: 28},
+			wantErr: tls.UnmarshalNoTLSError,
+			class:   NotInterestingError,
+		},
+		{
+			name:    "Ethernet ARP",
+			payload: etherFrame(0x0806, make([]byte, 28)),
+			opts:    Options{Link: LinkEthernet},
+			want:    Error{Layer: LayerLink, Reason: ReasonNotInteresting},
+			wantErr: unmarshalNonIPError,
+			class:   NotInterestingError,
+		},
+		{
+			name:    "Ethernet TCP without data",
+			payload: etherFrame(etherTypeIPv4, ip4Packet(t, src, dst, 6, tcpHeader(50000, 443, nil)), 100),
+			opts:    Options{Link: LinkEthernet},
+			want:    Error{Layer: LayerTCP, Reason: ReasonNotInteresting, Offset: 18 + 20},
+			wantErr: tls.UnmarshalNoTLSError,
+			class:   NotInterestingError,
+		},
+		{
+			name:    "Tunnel depth",
+			payload: ip4Packet(t, src, dst, protocolIPIP, ip4Packet(t, src, dst, protocolIPIP, tcpHello4)),
+			opts:    Options{MaxTunnels: 1},
+			want:    Error{Layer: LayerTunnel, Reason: ReasonUnsupported, Offset: 20},
+			wantErr: unmarshalTunnelDepthError,
+			class:   UnsupportedError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, parser := range []func([]byte) (networkLayer, error){
+				func(payload []byte) (networkLayer, error) {
+					return ParseWithOptions(payload, tt.opts)
+				},
+				NewParser(tt.opts).Parse,
+			} {
+				_, err := parser(tt.payload)
+				var got *Error
+				if !errors.As(err, &got) {
+					t.Fatalf("expected an *Error, got %T: %v", err, err)
+				}
+				if diff := cmp.Diff(tt.want, *got, cmpopts.IgnoreFields(Error{}, "Err")); diff != "" {
+					t.Fatalf("unexpected error (-want +got):\n%s", diff)
+				}
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected error to match %v, got %v", tt.wantErr, err)
+				}
+				if !errors.Is(err, tt.class) {
+					t.Fatalf("expected error to match %v, got %v", tt.class, err)
+				}
+			}
+		})
+	}
+}
+
+func TestParser_errorAllocs(t *testing.T) {
+	parser := NewParser(Options{})
+	packet := ip4Packet(t, net.IP{192, 0, 2, 1}, net.IP{192, 0, 2, 2}, 6, tcpHeader(50000, 443, nil))
+	allocs := testing.AllocsPerRun(100, func() {
+		if _, err := parser.Parse(packet); !errors.Is(err, NotInterestingError) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	if allocs != 0 {
+		t.Fatalf("expected no allocations, got %v per packet", allocs)
+	}
+}
+
+// udpHeader builds a UDP datagram carrying data.
+func udpHeader(src, dst uint16, data []byte) []byte {
+	return append([]byte{
+		byte(src >> 8), byte(src), byte(dst >> 8), byte(dst),
+		byte((8 + len(data)) >> 8), byte(8 + len(data)), 0x00, 0x00,
+	}, data...)
+}
@@ -0,0 +1,291 @@
+package parse
+
+import (
+	"encoding/binary"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+var reassemblyFullError = errors.New("too many IP datagrams being reassembled")
+var reassemblyFragmentError = errors.New("invalid IP fragment")
+
+const (
+	// maxFragments bounds the number of fragments held for one datagram.
+	maxFragments = 64
+	// maxDatagramLength is the largest IP payload that can be reassembled.
+	maxDatagramLength = 0xFFFF
+)
+
+// Reassembler collects IPv4 and IPv6 fragments until their datagram is
+// complete. Fragments are tagged with the caller's packet id, so the verdicts
+// for all fragments can be held until the datagram can be judged as a whole.
+// It is safe for concurrent use.
+type Reassembler struct {
+	mu        sync.Mutex
+	size      int
+	timeout   time.Duration
+	datagrams map[fragmentKey]*datagram
+
+	// now is replaced in tests
+	now func() time.Time
+}
+
+// NewReassembler returns a Reassembler that holds at most size incomplete
+// datagrams, each for at most timeout.
+func NewReassembler(size int, timeout time.Duration) *Reassembler {
+	return &Reassembler{
+		size:      size,
+		timeout:   timeout,
+		datagrams: make(map[fragmentKey]*datagram),
+		now:       time.Now,
+	}
+}
+
+type fragmentKey struct {
+	version  int
+	protocol int
+	id       uint32
+	src      [16]byte
+	dst      [16]byte
+}
+
+type fragment struct {
+	offset int
+	data   []byte
+}
+
+type datagram struct {
+	added time.Time
+	// header is the unfragmentable part of the first fragment, with the
+	// fragment header removed
+	header    []byte
+	fragments []fragment
+	// length is the length of the reassembled payload, or 0 until the last
+	// fragment has been seen
+	length int
+	ids    []uint32
+}
+
+// IsFragment reports whether packet is an IPv4 or IPv6 fragment.
+func IsFragment(packet []byte) bool {
+	_, _, _, ok, _ := parseFragment(packet)
+	return ok
+}
+
+// Add stores the fragment in packet, tagged with id. Once every fragment of
+// the datagram has been added it returns the reassembled packet and the ids
+// of all its fragments. Until then it returns a nil packet and the verdict
+// for id should be held. A packet that is not a fragment is returned as is.
+func (r *Reassembler) Add(packet []byte, id uint32) ([]byte, []uint32, error) {
+	key, frag, more, ok, err := parseFragment(packet)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ok {
+		return packet, []uint32{id}, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	d := r.datagrams[key]
+	if d == nil {
+		if len(r.datagrams) >= r.size {
+			return nil, nil, reassemblyFullError
+		}
+		d = &datagram{added: r.now()}
+		r.datagrams[key] = d
+	}
+	if len(d.fragments) >= maxFragments {
+		return nil, nil, reassemblyFragmentError
+	}
+
+	end := frag.offset + len(frag.data)
+	if !more {
+		if d.length != 0 && d.length != end {
+			// conflicting last fragments
+			return nil, nil, reassemblyFragmentError
+		}
+		d.length = end
+	}
+	if frag.offset == 0 {
+		d.header = fragmentHeader(packet)
+	}
+	if d.tooLong(key.version) {
+		return nil, nil, reassemblyFragmentError
+	}
+	// The packet buffer is reused by the caller, keep a copy of the data
+	frag.data = append([]byte(nil), frag.data...)
+	d.fragments = append(d.fragments, frag)
+	d.ids = append(d.ids, id)
+
+	if !d.complete() {
+		return nil, nil, nil
+	}
+	delete(r.datagrams, key)
+	return d.assemble(key.version), d.ids, nil
+}
+
+// Expire removes the datagrams that did not complete within the timeout and
+// returns the ids of their fragments, so their verdicts can be released.
+func (r *Reassembler) Expire() []uint32 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var ids []uint32
+	now := r.now()
+	for key, d := range r.datagrams {
+		if now.Sub(d.added) >= r.timeout {
+			ids = append(ids, d.ids...)
+			delete(r.datagrams, key)
+		}
+	}
+	return ids
+}
+
+// complete reports whether the fragments cover the whole datagram.
+func (d *datagram) complete() bool {
+	if d.length == 0 || d.header == nil {
+		return false
+	}
+	sort.Slice(d.fragments, func(i, j int) bool { return d.fragments[i].offset < d.fragments[j].offset })
+	covered := 0
+	for _, f := range d.fragments {
+		if f.offset > covered {
+			return false
+		}
+		if end := f.offset + len(f.data); end > covered {
+			covered = end
+		}
+	}
+	return covered >= d.length
+}
+
+// tooLong reports whether the header and the payload of the datagram do not
+// fit the length field of its IP header, once both are known.
+func (d *datagram) tooLong(version int) bool {
+	if d.length == 0 || d.header == nil {
+		return false
+	}
+	length := len(d.header) + d.length
+	if version == 6 {
+		// The payload length does not count the fixed header
+		length -= 40
+	}
+	return length > 0xFFFF
+}
+
+// assemble builds the reassembled packet. Overlapping data is taken from the
+// fragment with the lowest offset.
+func (d *datagram) assemble(version int) []byte {
+	packet := make([]byte, len(d.header)+d.length)
+	copy(packet, d.header)
+	payload := packet[len(d.header):]
+	for i := len(d.fragments) - 1; i >= 0; i-- {
+		f := d.fragments[i]
+		copy(payload[f.offset:], f.data)
+	}
+
+	if version == 4 {
+		binary.BigEndian.PutUint16(packet[2:4], uint16(len(packet)))
+		// clear the flags and fragment offset
+		binary.BigEndian.PutUint16(packet[6:8], 0)
+	} else {
+		binary.BigEndian.PutUint16(packet[4:6], uint16(len(packet)-40))
+	}
+	return packet
+}
+
+// parseFragment returns the reassembly key, the fragment data and whether
+// more fragments follow. ok is false when packet is not a fragment.
+func parseFragment(packet []byte) (key fragmentKey, frag fragment, more bool, ok bool, err error) {
+	if len(packet) < 1 {
+		return key, frag, false, false, nil
+	}
+	switch packet[0] >> 4 {
+	case 4:
+		if len(packet) < 20 {
+			return key, frag, false, false, nil
+		}
+		flags := binary.BigEndian.Uint16(packet[6:8])
+		more = flags&0x2000 != 0
+		frag.offset = int(flags&0x1fff) * 8
+		if !more && frag.offset == 0 {
+			return key, frag, false, false, nil
+		}
+
+		headerLength := int(packet[0]&0x0F) * 4
+		length := int(binary.BigEndian.Uint16(packet[2:4]))
+		if headerLength < 20 || length < headerLength || length > len(packet) {
+			return key, frag, false, true, reassemblyFragmentError
+		}
+		key.version = 4
+		key.protocol = int(packet[9])
+		key.id = uint32(binary.BigEndian.Uint16(packet[4:6]))
+		copy(key.src[:], packet[12:16])
+		copy(key.dst[:], packet[16:20])
+		frag.data = packet[headerLength:length]
+	case 6:
+		if len(packet) < 40 {
+			return key, frag, false, false, nil
+		}
+		cursor, _, found := ip6FragmentHeader(packet)
+		if !found {
+			return key, frag, false, false, nil
+		}
+
+		length := 40 + int(binary.BigEndian.Uint16(packet[4:6]))
+		if length < cursor+8 || length > len(packet) {
+			return key, frag, false, true, reassemblyFragmentError
+		}
+		offset := binary.BigEndian.Uint16(packet[cursor+2 : cursor+4])
+		more = offset&0x1 != 0
+		frag.offset = int(offset>>3) * 8
+		key.version = 6
+		key.protocol = int(packet[cursor])
+		key.id = binary.BigEndian.Uint32(packet[cursor+4 : cursor+8])
+		copy(key.src[:], packet[8:24])
+		copy(key.dst[:], packet[24:40])
+		frag.data = packet[cursor+8 : length]
+	default:
+		return key, frag, false, false, nil
+	}
+
+	if frag.offset+len(frag.data) > maxDatagramLength || more && len(frag.data)%8 != 0 {
+		return key, frag, more, true, reassemblyFragmentError
+	}
+	return key, frag, more, true, nil
+}
+
+// ip6FragmentHeader walks the extension headers of an IPv6 packet and
+// returns the offset of the Fragment header and the offset of the next header
+// field that points to it.
+func ip6FragmentHeader(packet []byte) (cursor int, nextHeader int, found bool) {
+	cursor, nextHeader = 40, 6
+	protocol := int(packet[6])
+	for protocol != ip6Fragment {
+		length, err := ip6ExtensionLength(packet, protocol, cursor)
+		if err != nil {
+			// the transport or a header we cannot walk
+			return cursor, nextHeader, false
+		}
+		protocol = int(packet[cursor])
+		nextHeader = cursor
+		cursor += length
+	}
+	return cursor, nextHeader, cursor+8 <= len(packet)
+}
+
+// fragmentHeader returns a copy of the unfragmentable part of the first
+// fragment. For IPv6 the Fragment header is left out.
+func fragmentHeader(packet []byte) []byte {
+	if packet[0]>>4 == 4 {
+		return append([]byte(nil), packet[:int(packet[0]&0x0F)*4]...)
+	}
+	cursor, nextHeader, _ := ip6FragmentHeader(packet)
+	header := append([]byte(nil), packet[:cursor]...)
+	header[nextHeader] = packet[cursor]
+	return header
+}
@@ -3,7 +3,8 @@
 
 package parse
 
-// FuzzParse will fuzz the packet parser
+// FuzzParse will fuzz the packet parser with go-fuzz. The native fuzz
+// targets are in fuzz_test.go.
 func FuzzParse(data []byte) int {
 	if _, err := Parse(data); err != nil {
 		return 0
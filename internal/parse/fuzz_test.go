@@ -0,0 +1,94 @@
+package parse
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func FuzzParse(f *testing.F) {
+	for _, seed := range [][]byte{tcpHello4, tcpSegment, ip6QUICPacket} {
+		f.Add(seed, uint8(LinkRaw), []byte(nil))
+	}
+	// The corpus of the gofuzz target in fuzz.go
+	files, err := filepath.Glob("testdata/corpus/*")
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, file := range files {
+		seed, err := os.ReadFile(file)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(seed, uint8(LinkRaw), []byte(nil))
+	}
+
+	// Frames of every link type
+	f.Add(etherFrame(etherTypeIPv4, tcpHello4, 0x8100, 100), uint8(LinkEthernet), []byte(nil))
+	f.Add(sllFrame(etherTypeIPv4, tcpHello4), uint8(LinkSLL), []byte(nil))
+	f.Add(sll2Frame(etherTypeIPv6, ip6QUICPacket), uint8(LinkSLL2), []byte(nil))
+
+	// Flows whose parts are reassembled from several segments
+	pending := flowPacket(f, false, 80, []byte("GET / HTTP/1.1\r\n"))
+	f.Add(pending, uint8(LinkRaw), morePackets(
+		sequencePacket(flowPacket(f, false, 80, []byte("Accept: */*\r\n\r\n")), 39),
+		sequencePacket(flowPacket(f, false, 80, []byte("Host: blocked.example\r\n")), 16),
+	))
+	flight, err := os.ReadFile("testdata/tls12-server-flight")
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(flowPacket(f, false, 443, helloWithoutSNI), uint8(LinkRaw), morePackets(
+		flowPacket(f, true, 443, flight[:200]),
+		sequencePacket(flowPacket(f, true, 443, flight[300:]), 300),
+		sequencePacket(flowPacket(f, true, 443, flight[200:300]), 200),
+	))
+
+	f.Fuzz(func(t *testing.T, data []byte, link uint8, more []byte) {
+		opts := Options{
+			Link:               LinkType(link % uint8(linkTypes)),
+			MaxTunnels:         2,
+			StartTLS:           NewStartTLS(map[uint16]StartTLSProtocol{25: SMTP}, 8, time.Minute),
+			QUIC:               NewQUICConnections([]uint16{443}, 8, time.Minute),
+			QUICKeys:           8,
+			HTTP:               NewHTTPRequests(8, time.Minute),
+			ServerCertificates: NewServerCertificates(8, time.Minute),
+		}
+		parser := NewParser(opts)
+		// more holds the packets that follow data, each after its length
+		for packet := data; ; {
+			// QUIC packets are decrypted in place
+			_, _ = ParseWithOptions(bytes.Clone(packet), opts)
+			_, _ = parser.Parse(bytes.Clone(packet))
+
+			if len(more) < 2 {
+				return
+			}
+			end := 2 + int(binary.BigEndian.Uint16(more))
+			if end > len(more) {
+				return
+			}
+			packet, more = more[2:end], more[end:]
+		}
+	})
+}
+
+// morePackets joins packets into the more argument of FuzzParse.
+func morePackets(packets ...[]byte) []byte {
+	var more []byte
+	for _, packet := range packets {
+		more = binary.BigEndian.AppendUint16(more, uint16(len(packet)))
+		more = append(more, packet...)
+	}
+	return more
+}
+
+// sequencePacket sets the sequence number of the TCP segment in an IPv4
+// packet built by flowPacket.
+func sequencePacket(packet []byte, sequence uint32) []byte {
+	binary.BigEndian.PutUint32(packet[24:28], sequence)
+	return packet
+}
@@ -0,0 +1,9 @@
+package http
+
+import "errors"
+
+var UnmarshalNoHTTPError = errors.New("not an HTTP request")
+var UnmarshalRequestError = errors.New("malformed HTTP request")
+var UnmarshalHostError = errors.New("malformed HTTP Host header")
+var UnmarshalHeaderSizeError = errors.New("HTTP request header too large")
+var UnmarshalIncompleteError = errors.New("HTTP request header continues beyond the packet")
@@ -0,0 +1,28 @@
+package http
+
+import (
+	"bytes"
+	"testing"
+)
+
+func FuzzRequest_Unmarshal(f *testing.F) {
+	f.Add([]byte("GET /index.html HTTP/1.1\r\nUser-Agent: curl/8.5.0\r\nHost: example.com\r\nAccept: */*\r\n\r\n"))
+	f.Add([]byte("GET http://other.example/ HTTP/1.1\r\nHost: [2001:db8::1]:80\r\nHost: example.com\r\n\r\n"))
+	f.Add([]byte("GET / HTTP/1.1\r\nHost: exa"))
+	f.Add([]byte("GET / HTTP/1.0\r\n\r\n"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		orig := bytes.Clone(data)
+		var m Request
+		err := m.Unmarshal(data)
+		if !bytes.Equal(data, orig) {
+			t.Fatalf("payload was modified")
+		}
+		if m.Host != "" && (len(m.Hosts) == 0 || m.Hosts[0] != m.Host) {
+			t.Fatalf("Host %q is not the first of Hosts %q", m.Host, m.Hosts)
+		}
+		if err == nil && m.Host == "" && m.Version != "HTTP/1.0" {
+			t.Fatalf("%s request without Host decoded", m.Version)
+		}
+	})
+}
@@ -0,0 +1,190 @@
+package http
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+)
+
+// MaxHeaderSize is the number of bytes searched for the end of the request
+// header. Requests with a larger header are rejected.
+const MaxHeaderSize = 8192
+
+// methods are the request methods we recognize at the start of a segment.
+var methods = []string{"GET", "POST", "HEAD", "PUT", "DELETE", "OPTIONS", "PATCH", "CONNECT", "TRACE"}
+
+// Request holds the parts of an HTTP/1.x request header used for matching.
+type Request struct {
+	Method  string
+	Target  string
+	Version string
+	Host    string
+	// Hosts lists every host the request names, in the target and in each
+	// Host header, Host first. Requests with several Host headers are sent
+	// to confuse filters, so all of them have to be matched.
+	Hosts []string
+	// Partial is set when the header continues beyond the parsed bytes, the
+	// fields hold whatever was decoded up to that point.
+	Partial bool
+}
+
+// Unmarshal decodes the request line and the Host header of an HTTP/1.x
+// request. The payload must start at the request line. A header that is cut
+// short by the end of the segment is decoded as far as possible.
+func (m *Request) Unmarshal(payload []byte) error {
+	if !isRequest(payload) {
+		if isMethodPrefix(payload) {
+			// Not even the method fits in this segment
+			m.Partial = true
+			return UnmarshalIncompleteError
+		}
+		return UnmarshalNoHTTPError
+	}
+	if len(payload) > MaxHeaderSize {
+		payload = payload[:MaxHeaderSize]
+	}
+
+	line, rest, ok := cutLine(payload)
+	if !ok {
+		// Not even the request line fits in this segment
+		m.Partial = true
+		return m.incomplete(len(payload))
+	}
+	if err := m.unmarshalRequestLine(line); err != nil {
+		return err
+	}
+
+	for {
+		line, rest, ok = cutLine(rest)
+		if !ok {
+			m.Partial = true
+			return m.incomplete(len(payload))
+		}
+		if len(line) == 0 {
+			// End of the header
+			break
+		}
+		name, value, ok := bytes.Cut(line, []byte(":"))
+		if !ok {
+			return UnmarshalRequestError
+		}
+		if !bytes.EqualFold(name, []byte("host")) {
+			continue
+		}
+		if err := m.setHost(string(bytes.Trim(value, " \t"))); err != nil {
+			return err
+		}
+	}
+
+	if m.Host == "" && m.Version != "HTTP/1.0" {
+		// Host is mandatory in HTTP/1.1
+		return UnmarshalHostError
+	}
+	return nil
+}
+
+// Incomplete reports whether err only signals that the header continues
+// beyond the parsed bytes while the host was already found.
+func (m *Request) Incomplete(err error) bool {
+	return m.Partial && m.Host != "" && err == UnmarshalIncompleteError
+}
+
+// incomplete returns the error for a header cut short after n bytes.
+func (m *Request) incomplete(n int) error {
+	if n == MaxHeaderSize && m.Host == "" {
+		return UnmarshalHeaderSizeError
+	}
+	return UnmarshalIncompleteError
+}
+
+func (m *Request) unmarshalRequestLine(line []byte) error {
+	method, rest, ok := bytes.Cut(line, []byte(" "))
+	if !ok {
+		return UnmarshalRequestError
+	}
+	target, version, ok := bytes.Cut(rest, []byte(" "))
+	if !ok || len(target) == 0 {
+		return UnmarshalRequestError
+	}
+	if !bytes.Equal(version, []byte("HTTP/1.0")) && !bytes.Equal(version, []byte("HTTP/1.1")) {
+		return UnmarshalRequestError
+	}
+	m.Method = string(method)
+	m.Target = string(target)
+	m.Version = string(version)
+
+	// The host of an absolute-form or authority-form target takes precedence
+	// over the Host header.
+	switch {
+	case strings.HasPrefix(m.Target, "http://"):
+		authority, _, _ := strings.Cut(m.Target[len("http://"):], "/")
+		return m.setHost(authority)
+	case m.Method == "CONNECT":
+		return m.setHost(m.Target)
+	}
+	return nil
+}
+
+// setHost adds the host of an authority to Hosts, stripping the port and any
+// userinfo. Host is set to the first one.
+func (m *Request) setHost(authority string) error {
+	if i := strings.LastIndexByte(authority, '@'); i >= 0 {
+		authority = authority[i+1:]
+	}
+	host := authority
+	if strings.HasPrefix(authority, "[") {
+		// IPv6 literal
+		end := strings.IndexByte(authority, ']')
+		if end < 0 {
+			return UnmarshalHostError
+		}
+		host = authority[1:end]
+	} else if i := strings.LastIndexByte(authority, ':'); i >= 0 {
+		host = authority[:i]
+	}
+	if host == "" || strings.ContainsAny(host, " \t/") {
+		return UnmarshalHostError
+	}
+	if m.Host == "" {
+		m.Host = host
+	}
+	if !slices.Contains(m.Hosts, host) {
+		m.Hosts = append(m.Hosts, host)
+	}
+	return nil
+}
+
+// isRequest reports whether payload starts with a known method followed by
+// a space.
+func isRequest(payload []byte) bool {
+	for _, method := range methods {
+		if len(payload) > len(method) && string(payload[:len(method)]) == method && payload[len(method)] == ' ' {
+			return true
+		}
+	}
+	return false
+}
+
+// isMethodPrefix reports whether payload is too short to hold a known method
+// followed by a space, but starts like one.
+func isMethodPrefix(payload []byte) bool {
+	if len(payload) == 0 {
+		return false
+	}
+	for _, method := range methods {
+		if len(payload) <= len(method) && method[:len(payload)] == string(payload) {
+			return true
+		}
+	}
+	return false
+}
+
+// cutLine returns the line at the start of b without its line ending, and
+// the bytes after it. A bare LF is accepted as line ending.
+func cutLine(b []byte) ([]byte, []byte, bool) {
+	i := bytes.IndexByte(b, '\n')
+	if i < 0 {
+		return nil, b, false
+	}
+	return bytes.TrimSuffix(b[:i], []byte("\r")), b[i+1:], true
+}
@@ -0,0 +1,121 @@
+package http
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRequest_Unmarshal(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    Request
+		wantErr error
+	}{
+		{
+			name:    "GET",
+			payload: "GET /index.html HTTP/1.1\r\nUser-Agent: curl/8.5.0\r\nHost: example.com\r\nAccept: */*\r\n\r\n",
+			want:    Request{Method: "GET", Target: "/index.html", Version: "HTTP/1.1", Host: "example.com", Hosts: []string{"example.com"}},
+		},
+		{
+			name:    "Host with port and odd case",
+			payload: "POST /api HTTP/1.1\r\nhOsT:\tExample.com:8080 \r\nContent-Length: 2\r\n\r\n{}",
+			want:    Request{Method: "POST", Target: "/api", Version: "HTTP/1.1", Host: "Example.com", Hosts: []string{"Example.com"}},
+		},
+		{
+			name:    "IPv6 literal",
+			payload: "GET / HTTP/1.1\r\nHost: [2001:db8::1]:80\r\n\r\n",
+			want:    Request{Method: "GET", Target: "/", Version: "HTTP/1.1", Host: "2001:db8::1", Hosts: []string{"2001:db8::1"}},
+		},
+		{
+			name:    "Absolute form takes precedence",
+			payload: "GET http://user@proxy.example:3128/path HTTP/1.1\r\nHost: other.example\r\n\r\n",
+			want:    Request{Method: "GET", Target: "http://user@proxy.example:3128/path", Version: "HTTP/1.1", Host: "proxy.example", Hosts: []string{"proxy.example", "other.example"}},
+		},
+		{
+			name:    "CONNECT",
+			payload: "CONNECT tunnel.example:443 HTTP/1.1\r\n\r\n",
+			want:    Request{Method: "CONNECT", Target: "tunnel.example:443", Version: "HTTP/1.1", Host: "tunnel.example", Hosts: []string{"tunnel.example"}},
+		},
+		{
+			name:    "Bare LF",
+			payload: "HEAD / HTTP/1.0\nHost: example.org\n\n",
+			want:    Request{Method: "HEAD", Target: "/", Version: "HTTP/1.0", Host: "example.org", Hosts: []string{"example.org"}},
+		},
+		{
+			name:    "HTTP/1.0 without Host",
+			payload: "GET / HTTP/1.0\r\n\r\n",
+			want:    Request{Method: "GET", Target: "/", Version: "HTTP/1.0"},
+		},
+		{
+			name:    "Split after Host",
+			payload: "GET / HTTP/1.1\r\nHost: example.com\r\nCookie: a=",
+			want:    Request{Method: "GET", Target: "/", Version: "HTTP/1.1", Host: "example.com", Hosts: []string{"example.com"}, Partial: true},
+			wantErr: UnmarshalIncompleteError,
+		},
+		{
+			name:    "Split inside Host",
+			payload: "GET / HTTP/1.1\r\nHost: exam",
+			want:    Request{Method: "GET", Target: "/", Version: "HTTP/1.1", Partial: true},
+			wantErr: UnmarshalIncompleteError,
+		},
+		{
+			name:    "Split inside method",
+			payload: "PO",
+			want:    Request{Partial: true},
+			wantErr: UnmarshalIncompleteError,
+		},
+		{
+			name:    "Split inside request line",
+			payload: "GET /very/long/pa",
+			want:    Request{Partial: true},
+			wantErr: UnmarshalIncompleteError,
+		},
+		{
+			name:    "Header too large",
+			payload: "GET / HTTP/1.1\r\nX-Padding: " + strings.Repeat("a", MaxHeaderSize) + "\r\nHost: example.com\r\n\r\n",
+			want:    Request{Method: "GET", Target: "/", Version: "HTTP/1.1", Partial: true},
+			wantErr: UnmarshalHeaderSizeError,
+		},
+		{
+			name:    "Duplicate Host",
+			payload: "GET / HTTP/1.1\r\nHost: allowed.example\r\nHost: blocked.example\r\n\r\n",
+			want:    Request{Method: "GET", Target: "/", Version: "HTTP/1.1", Host: "allowed.example", Hosts: []string{"allowed.example", "blocked.example"}},
+		},
+		{
+			name:    "Missing Host",
+			payload: "GET / HTTP/1.1\r\nAccept: */*\r\n\r\n",
+			want:    Request{Method: "GET", Target: "/", Version: "HTTP/1.1"},
+			wantErr: UnmarshalHostError,
+		},
+		{
+			name:    "HTTP/2 preface",
+			payload: "PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n",
+			wantErr: UnmarshalNoHTTPError,
+		},
+		{
+			name:    "Unknown version",
+			payload: "GET / HTTP/3\r\nHost: example.com\r\n\r\n",
+			wantErr: UnmarshalRequestError,
+		},
+		{
+			name:    "Not HTTP",
+			payload: "\x16\x03\x01\x00\x05",
+			wantErr: UnmarshalNoHTTPError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Request
+			err := got.Unmarshal([]byte(tt.payload))
+			if err != tt.wantErr {
+				t.Fatalf("Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Fatalf("unexpected request (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
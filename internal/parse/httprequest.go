@@ -0,0 +1,127 @@
+package parse
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jsimonetti/sniqueue/internal/parse/http"
+)
+
+// HTTPPendingError is returned for the segments of a followed HTTP request
+// before its Host header is complete.
+var HTTPPendingError = errors.New("waiting for HTTP Host header")
+
+type httpFlow struct {
+	added  time.Time
+	stream stream
+	// request is set once its Host was found
+	request *http.Request
+}
+
+// HTTPRequests follows the cleartext HTTP requests whose header is split
+// over several segments before the Host header, so the host can be extracted
+// from the reassembled header. Once found, the request is set on all the
+// segments of the client until the flow expires, so the flow can be judged
+// by any of them. It is safe for concurrent use.
+type HTTPRequests struct {
+	mu      sync.Mutex
+	size    int
+	timeout time.Duration
+	flows   map[flowKey]*httpFlow
+
+	// now is replaced in tests
+	now func() time.Time
+}
+
+// NewHTTPRequests returns an HTTPRequests that follows at most size flows,
+// each for at most timeout.
+func NewHTTPRequests(size int, timeout time.Duration) *HTTPRequests {
+	return &HTTPRequests{
+		size:    size,
+		timeout: timeout,
+		flows:   make(map[flowKey]*httpFlow),
+		now:     time.Now,
+	}
+}
+
+// Expire removes the flows that were followed for the timeout.
+func (s *HTTPRequests) Expire() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for key, f := range s.flows {
+		if now.Sub(f.added) >= s.timeout {
+			delete(s.flows, key)
+		}
+	}
+}
+
+// Len returns the number of followed flows.
+func (s *HTTPRequests) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.flows)
+}
+
+// track starts following the flow of a request that was cut short before its
+// Host header, and reassembles the header from the segments of the client of
+// a followed flow. err is the result of parsing the segment, which is
+// returned for the segments that are not part of a followed request. When the
+// header turns out to be malformed, the flow is no longer followed.
+func (s *HTTPRequests) track(p *Inet, tcp *TCP, sequence uint32, data []byte, err error) error {
+	if len(data) == 0 {
+		return err
+	}
+
+	var key flowKey
+	copy(key.client[:], p.Source.To16())
+	copy(key.server[:], p.Destination.To16())
+	key.clientPort, key.serverPort = tcp.SourcePort, tcp.DestinationPort
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The request line without the Host header starts a request
+	starts := tcp.HTTP != nil && tcp.HTTP.Host == "" && errors.Is(err, http.UnmarshalIncompleteError)
+	f := s.flows[key]
+	switch {
+	case f == nil && starts:
+		if len(s.flows) >= s.size {
+			return err
+		}
+		f = &httpFlow{added: s.now()}
+		s.flows[key] = f
+	case f == nil:
+		return err
+	case f.request != nil && starts:
+		// The next request of the flow is split as well
+		f.request = nil
+	case f.request != nil:
+		if tcp.HTTP != nil && tcp.HTTP.Host != "" {
+			// The next request of the flow names its own host
+			return err
+		}
+		tcp.HTTP = f.request
+		return nil
+	}
+
+	if !f.stream.add(sequence, data, http.MaxHeaderSize) {
+		tcp.HTTP = nil
+		return HTTPPendingError
+	}
+	request := &http.Request{}
+	err = request.Unmarshal(f.stream.data)
+	if err == http.UnmarshalIncompleteError && request.Host == "" {
+		tcp.HTTP = nil
+		return HTTPPendingError
+	}
+	tcp.HTTP = request
+	if err != nil && !request.Incomplete(err) {
+		delete(s.flows, key)
+		return err
+	}
+	f.request, f.stream = request, stream{}
+	return nil
+}
@@ -0,0 +1,145 @@
+package parse
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jsimonetti/sniqueue/internal/parse/http"
+)
+
+func TestHTTPRequests(t *testing.T) {
+	// The client stream starts at sequence number 1000
+	client := func(seq int, data string, wantErr error) seqSegment {
+		return seqSegment{seq: 1000 + uint32(seq), data: []byte(data), wantErr: wantErr}
+	}
+	pending := HTTPPendingError
+	requestLine := "GET / HTTP/1.1\r\n"
+	host := "Host: blocked.example\r\n"
+	want := &http.Request{Method: "GET", Target: "/", Version: "HTTP/1.1", Host: "blocked.example", Hosts: []string{"blocked.example"}}
+
+	tests := []struct {
+		name     string
+		segments []seqSegment
+		want     *http.Request
+		wantLen  int
+	}{
+		{
+			name: "Split before Host",
+			segments: []seqSegment{
+				client(0, requestLine, pending),
+				client(16, host+"\r\n", nil),
+			},
+			want:    want,
+			wantLen: 1,
+		},
+		{
+			name: "Split inside method",
+			segments: []seqSegment{
+				client(0, "G", pending),
+				client(1, requestLine[1:]+host+"\r\n", nil),
+			},
+			want:    want,
+			wantLen: 1,
+		},
+		{
+			name: "Reordered",
+			segments: []seqSegment{
+				client(0, requestLine, pending),
+				client(16+len(host), "Accept: */*\r\n\r\n", pending),
+				client(16, host, nil),
+			},
+			want:    want,
+			wantLen: 1,
+		},
+		{
+			name: "Retransmitted after the Host",
+			segments: []seqSegment{
+				client(0, requestLine, pending),
+				client(16, host+"\r\n", nil),
+				client(16, host+"\r\n", nil),
+			},
+			want:    want,
+			wantLen: 1,
+		},
+		{
+			name: "Next request split",
+			segments: []seqSegment{
+				client(0, requestLine, pending),
+				client(16, host+"\r\n", nil),
+				client(16+len(host)+2, "HEAD / HTTP/1.1\r\n", pending),
+				client(16+len(host)+2+17, "Host: other.example\r\n\r\n", nil),
+			},
+			want:    &http.Request{Method: "HEAD", Target: "/", Version: "HTTP/1.1", Host: "other.example", Hosts: []string{"other.example"}},
+			wantLen: 1,
+		},
+		{
+			name: "Whole request",
+			segments: []seqSegment{
+				client(0, requestLine+host+"\r\n", nil),
+			},
+			want: want,
+		},
+		{
+			name: "Malformed header",
+			segments: []seqSegment{
+				client(0, requestLine, pending),
+				client(16, "no colon\r\n\r\n", http.UnmarshalRequestError),
+			},
+			want: &http.Request{Method: "GET", Target: "/", Version: "HTTP/1.1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewHTTPRequests(4, time.Minute)
+			opts := Options{HTTP: s}
+
+			var got networkLayer
+			var err error
+			for i, seg := range tt.segments {
+				packet := flowPacket(t, false, 80, seg.data)
+				binary.BigEndian.PutUint32(packet[24:28], seg.seq)
+				got, err = ParseWithOptions(packet, opts)
+				if !errors.Is(err, seg.wantErr) {
+					t.Fatalf("segment %d: ParseWithOptions() error = %v, wantErr %v", i, err, seg.wantErr)
+				}
+			}
+			tcp := got.(*IPv4).Transport.(*TCP)
+			if diff := cmp.Diff(tt.want, tcp.HTTP); diff != "" {
+				t.Errorf("unexpected request (-want +got):\n%s", diff)
+			}
+			if got.DomainName() != tt.want.Host {
+				t.Errorf("DomainName() = %q, want %q", got.DomainName(), tt.want.Host)
+			}
+			if s.Len() != tt.wantLen {
+				t.Errorf("%d flows left after the last segment, want %d", s.Len(), tt.wantLen)
+			}
+		})
+	}
+}
+
+func TestHTTPRequests_Expire(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	s := NewHTTPRequests(1, time.Second)
+	s.now = func() time.Time { return now }
+	opts := Options{HTTP: s}
+
+	if _, err := ParseWithOptions(flowPacket(t, false, 80, []byte("GET / HTTP/1.1\r\n")), opts); !errors.Is(err, HTTPPendingError) {
+		t.Fatalf("ParseWithOptions() error = %v, want %v", err, HTTPPendingError)
+	}
+	// A second flow does not fit, its request is parsed on its own
+	if _, err := ParseWithOptions(flowPacket(t, false, 8080, []byte("GET / HTTP/1.1\r\n")), opts); !errors.Is(err, http.UnmarshalIncompleteError) {
+		t.Fatalf("ParseWithOptions() error = %v, want %v", err, http.UnmarshalIncompleteError)
+	}
+	if s.Len() != 1 {
+		t.Fatalf("Len() = %d, want 1", s.Len())
+	}
+
+	now = now.Add(time.Second)
+	s.Expire()
+	if s.Len() != 0 {
+		t.Fatalf("%d flows left after Expire()", s.Len())
+	}
+}
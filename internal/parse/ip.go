@@ -4,12 +4,18 @@ import (
 	"encoding/binary"
 	"errors"
 	"net"
+
+	"github.com/jsimonetti/sniqueue/internal/parse/dns"
+	"github.com/jsimonetti/sniqueue/internal/parse/tls"
 )
 
 var unmarshalIPError = errors.New("insufficient bytes to Unmarshal IP")
 var unmarshalIP4Error = errors.New("insufficient bytes to Unmarshal IP4")
 var unmarshalIP6Error = errors.New("insufficient bytes to Unmarshal IP6")
 var unmarshalNonIPError = errors.New("cannot parse non-IP")
+var unmarshalIP6ExtensionError = errors.New("insufficient bytes to Unmarshal IP6 extension header")
+var unmarshalFragmentError = errors.New("cannot parse non-first IP fragment")
+var unmarshalIP6HeaderError = errors.New("unknown IP6 extension header")
 
 type IPv4 struct {
 	Inet
@@ -17,8 +23,23 @@ type IPv4 struct {
 
 type IPv6 struct {
 	Inet
+	// ExtensionHeaders lists the next header values of the extension
+	// headers between the IPv6 header and the transport, in order.
+	ExtensionHeaders []int
 }
 
+// IPv6 next header values of the extension headers we can walk.
+const (
+	ip6HopByHop        = 0
+	ip6Routing         = 43
+	ip6Fragment        = 44
+	ip6AuthHeader      = 51
+	ip6DestinationOpts = 60
+	ip6Mobility        = 135
+	ip6HostIdentity    = 139
+	ip6Shim6           = 140
+)
+
 type Inet struct {
 	IPVersion      int
 	IPHeaderLength int
@@ -27,6 +48,12 @@ type Inet struct {
 	Source         net.IP
 	Destination    net.IP
 	Transport      transportLayer
+	// Tunnels lists the tunnel headers that were stripped to reach this
+	// packet, outermost first.
+	Tunnels []Tunnel
+	// Link is the link layer header in front of the packet, nil for
+	// LinkRaw.
+	Link *Link
 }
 
 func (p *Inet) DomainName() string {
@@ -36,6 +63,38 @@ func (p *Inet) DomainName() string {
 	return ""
 }
 
+// Hello returns the parsed ClientHello of the transport, if any.
+func (p *Inet) Hello() *tls.ClientHello {
+	if p.Transport != nil {
+		return p.Transport.clientHello()
+	}
+	return nil
+}
+
+// JA3 returns the JA3 fingerprint of the ClientHello, if any.
+func (p *Inet) JA3() string {
+	if p.Transport != nil {
+		return p.Transport.clientHello().JA3()
+	}
+	return ""
+}
+
+// JA4 returns the JA4 fingerprint of the ClientHello, if any.
+func (p *Inet) JA4() string {
+	if p.Transport != nil {
+		return p.Transport.ja4()
+	}
+	return ""
+}
+
+// DNS returns the DNS message of the transport, if any.
+func (p *Inet) DNS() *dns.Message {
+	if p.Transport != nil {
+		return p.Transport.dnsMessage()
+	}
+	return nil
+}
+
 func (p *Inet) Version() int {
 	return p.IPVersion
 }
@@ -48,12 +107,52 @@ func (p *Inet) Dst() net.IP {
 	return p.Destination
 }
 
+// unmarshalTCP decodes the TCP segment at payload, following the flow when
+// STARTTLS, HTTP request or server certificate tracking is enabled.
+func (p *Inet) unmarshalTCP(payload []byte, opts Options) error {
+	tcp := opts.tcp()
+	p.Transport = tcp
+	err := tcp.unmarshal(payload, opts)
+	if (opts.StartTLS == nil && opts.HTTP == nil && opts.ServerCertificates == nil) || len(payload) < 20 {
+		return err
+	}
+	var data []byte
+	if cursor := int(payload[12]>>4) * 4; cursor <= len(payload) {
+		data = payload[cursor:]
+	}
+	if opts.StartTLS != nil {
+		err = opts.wrapError(LayerStartTLS, 0, opts.StartTLS.track(p, tcp, data, err))
+	}
+	if opts.HTTP != nil {
+		err = opts.wrapError(LayerHTTP, 0, opts.HTTP.track(p, tcp, tcpSequence(payload), data, err))
+	}
+	if opts.ServerCertificates != nil {
+		err = opts.wrapError(LayerCertificate, 0, opts.ServerCertificates.track(p, tcp, tcpSequence(payload), tcpAcknowledgment(payload), data, err))
+	}
+	return err
+}
+
+// unmarshalUDP decodes the UDP datagram at payload, following the QUIC
+// connection when enabled.
+func (p *Inet) unmarshalUDP(payload []byte, opts Options) error {
+	udp := opts.udp()
+	p.Transport = udp
+	err := udp.unmarshal(payload, opts)
+	if opts.QUIC != nil {
+		opts.QUIC.track(p, udp)
+	}
+	return err
+}
+
 type transportLayer interface {
-	unmarshal([]byte) error
+	unmarshal([]byte, Options) error
 	domainName() string
+	clientHello() *tls.ClientHello
+	dnsMessage() *dns.Message
+	ja4() string
 }
 
-func (p *IPv4) unmarshal(payload []byte) error {
+func (p *IPv4) unmarshal(payload []byte, opts Options) error {
 	if len(payload) < 20 {
 		return unmarshalIP4Error
 	}
@@ -84,18 +183,21 @@ func (p *IPv4) unmarshal(payload []byte) error {
 		return unmarshalIP4Error
 	}
 
+	// Only the first fragment carries the transport header
+	if binary.BigEndian.Uint16(payload[6:8])&0x1fff != 0 {
+		return unmarshalFragmentError
+	}
+
 	switch p.Protocol {
 	case 6:
-		p.Transport = &TCP{}
-		return p.Transport.unmarshal(payload[p.IPHeaderLength*4:])
+		return opts.wrapError(LayerTCP, p.IPHeaderLength*4, p.unmarshalTCP(payload[p.IPHeaderLength*4:], opts))
 	case 17:
-		p.Transport = &UDP{}
-		return p.Transport.unmarshal(payload[p.IPHeaderLength*4:])
+		return opts.wrapError(LayerUDP, p.IPHeaderLength*4, p.unmarshalUDP(payload[p.IPHeaderLength*4:], opts))
 	}
 	return unmarshalNonIPError
 }
 
-func (p *IPv6) unmarshal(payload []byte) error {
+func (p *IPv6) unmarshal(payload []byte, opts Options) error {
 	if len(payload) < 40 {
 		return unmarshalIP6Error
 	}
@@ -104,52 +206,198 @@ func (p *IPv6) unmarshal(payload []byte) error {
 	p.Protocol = int(payload[6])
 	p.Length = binary.BigEndian.Uint16(payload[4:6])
 
+	cursor, err := p.walkExtensionHeaders(payload)
+	if err != nil {
+		return opts.wrapError(LayerIP, cursor, err)
+	}
+
 	switch p.Protocol {
 	case 6:
-		p.Transport = &TCP{}
-		return p.Transport.unmarshal(payload[40:])
+		return opts.wrapError(LayerTCP, cursor, p.unmarshalTCP(payload[cursor:], opts))
 	case 17:
-		p.Transport = &UDP{}
-		return p.Transport.unmarshal(payload[40:])
+		return opts.wrapError(LayerUDP, cursor, p.unmarshalUDP(payload[cursor:], opts))
 	}
 
-	return unmarshalIP6Error
+	return unmarshalIP6HeaderError
+}
+
+// walkExtensionHeaders follows the next header chain until it finds the
+// upper-layer header. It sets p.Protocol to the upper-layer protocol and
+// returns the offset of its header.
+func (p *IPv6) walkExtensionHeaders(payload []byte) (int, error) {
+	cursor := 40
+	for ip6ExtensionHeader(p.Protocol) {
+		length, err := ip6ExtensionLength(payload, p.Protocol, cursor)
+		if err != nil {
+			return cursor, err
+		}
+		p.ExtensionHeaders = append(p.ExtensionHeaders, p.Protocol)
+		// Only the first fragment carries the transport header
+		if p.Protocol == ip6Fragment && binary.BigEndian.Uint16(payload[cursor+2:cursor+4])>>3 != 0 {
+			return cursor, unmarshalFragmentError
+		}
+		p.Protocol = int(payload[cursor])
+		cursor += length
+	}
+	return cursor, nil
+}
+
+// ip6ExtensionHeader reports whether protocol is an extension header.
+func ip6ExtensionHeader(protocol int) bool {
+	switch protocol {
+	case ip6HopByHop, ip6Routing, ip6Fragment, ip6AuthHeader, ip6DestinationOpts, ip6Mobility, ip6HostIdentity, ip6Shim6:
+		return true
+	}
+	return false
+}
+
+// ip6ExtensionLength returns the length of the extension header of type
+// protocol at cursor.
+func ip6ExtensionLength(payload []byte, protocol int, cursor int) (int, error) {
+	var length int
+	switch protocol {
+	case ip6HopByHop, ip6Routing, ip6DestinationOpts, ip6Mobility, ip6HostIdentity, ip6Shim6:
+		if cursor+8 > len(payload) {
+			return 0, unmarshalIP6ExtensionError
+		}
+		// Hdr Ext Len is in 8-octet units, not including the first 8 octets
+		length = (int(payload[cursor+1]) + 1) * 8
+	case ip6AuthHeader:
+		if cursor+8 > len(payload) {
+			return 0, unmarshalIP6ExtensionError
+		}
+		// Payload Len is in 4-octet units, minus 2
+		length = (int(payload[cursor+1]) + 2) * 4
+	case ip6Fragment:
+		length = 8
+	default:
+		// ESP, No Next Header or a header we do not know how to skip
+		return 0, unmarshalIP6HeaderError
+	}
+	if cursor+length > len(payload) {
+		return 0, unmarshalIP6ExtensionError
+	}
+	return length, nil
 }
 
 type networkLayer interface {
-	unmarshal([]byte) error
+	unmarshal([]byte, Options) error
 	DomainName() string
+	Hello() *tls.ClientHello
+	JA3() string
+	JA4() string
+	DNS() *dns.Message
 	Version() int
 	Src() net.IP
 	Dst() net.IP
 }
 
+// Options control how much of a packet is parsed.
+type Options struct {
+	// Link is the link layer header in front of the IP header.
+	Link LinkType
+	// SNIOnly only extracts the server name (and ECH presence) from
+	// ClientHellos, skipping the other metadata.
+	SNIOnly bool
+	// MaxTunnels is the number of nested IPIP, IPv6 in IP, GRE and VXLAN
+	// headers that are stripped to reach the inner packet. Zero disables
+	// decapsulation.
+	MaxTunnels int
+	// StartTLS follows flows on its ports until they upgrade to TLS. While
+	// waiting, their segments return StartTLSPendingError.
+	StartTLS *StartTLS
+	// HTTP follows the cleartext HTTP requests whose Host header is not in
+	// their first segment. While waiting, their segments return
+	// HTTPPendingError.
+	HTTP *HTTPRequests
+	// QUIC follows QUIC connections to correlate the Initials of a client
+	// with the Retry and Version Negotiation packets of the server.
+	QUIC *QUICConnections
+	// ServerCertificates follows the TLS flows without server name until
+	// the server sends its certificate. While waiting, the segments of the
+	// server return ServerCertificatePendingError.
+	ServerCertificates *ServerCertificates
+	// QUICKeys is the number of QUIC Initial keys a Parser caches, so the
+	// keys are derived once per connection instead of for every Initial.
+	// Zero disables the cache. ParseWithOptions never caches keys.
+	QUICKeys int
+
+	// parser is set when parsing into the reusable state of a Parser
+	parser *Parser
+}
+
+func (o Options) tls() tls.Options {
+	return tls.Options{SNIOnly: o.SNIOnly, NoCopy: o.parser != nil}
+}
+
+// Parse parses payload as an IP packet using the default Options.
 func Parse(payload []byte) (networkLayer, error) {
+	return ParseWithOptions(payload, Options{})
+}
+
+// ParseWithOptions parses payload as an IP packet. When decapsulation is
+// enabled, the returned packet is the innermost one and lists the stripped
+// tunnels. Errors are returned as an *Error, with offsets relative to the
+// start of payload.
+func ParseWithOptions(payload []byte, opts Options) (networkLayer, error) {
+	var link *Link
+	if opts.Link != LinkRaw {
+		link = opts.link()
+		frame := payload
+		var err error
+		if payload, err = link.unmarshal(frame, opts.Link); err != nil {
+			return nil, opts.wrapError(LayerLink, 0, err)
+		}
+		p, err := parseTunnels(payload, opts, link)
+		return p, opts.wrapError(LayerIP, len(frame)-len(payload), err)
+	}
+	return parseTunnels(payload, opts, link)
+}
+
+// parseTunnels parses the IP packet at payload, after stripping the
+// tunnels around it when enabled.
+func parseTunnels(payload []byte, opts Options, link *Link) (networkLayer, error) {
+	if opts.MaxTunnels <= 0 {
+		return parseIP(payload, opts, link, nil)
+	}
+	inner, tunnels, err := decapsulate(payload, opts.MaxTunnels)
+	p, parseErr := parseIP(inner, opts, link, tunnels)
+	if err != nil {
+		return p, opts.wrapError(LayerTunnel, len(payload)-len(inner), err)
+	}
+	return p, opts.wrapError(LayerIP, len(payload)-len(inner), parseErr)
+}
+
+func parseIP(payload []byte, opts Options, link *Link, tunnels []Tunnel) (networkLayer, error) {
 	if len(payload) < 1 {
-		return nil, unmarshalIPError
+		return nil, opts.wrapError(LayerIP, 0, unmarshalIPError)
 	}
 
 	version := int(payload[0]) >> 4
 	headerLength := int(payload[0]) & 0x0F
 
-	var p networkLayer
 	switch version {
 	case 4: // IPv4
-		p = &IPv4{
-			Inet{
-				IPVersion:      4,
-				IPHeaderLength: headerLength,
-			},
+		p := opts.ipv4()
+		p.Inet = Inet{
+			IPVersion:      4,
+			IPHeaderLength: headerLength,
+			Tunnels:        tunnels,
+			Link:           link,
 		}
-		return p, p.unmarshal(payload)
+		return p, opts.wrapError(LayerIP, 0, p.unmarshal(payload, opts))
 	case 6: // IPv6
-		p = &IPv6{
-			Inet{
+		p := opts.ipv6()
+		*p = IPv6{
+			Inet: Inet{
 				IPVersion:      6,
 				IPHeaderLength: headerLength,
+				Tunnels:        tunnels,
+				Link:           link,
 			},
+			ExtensionHeaders: p.ExtensionHeaders[:0],
 		}
-		return p, p.unmarshal(payload)
+		return p, opts.wrapError(LayerIP, 0, p.unmarshal(payload, opts))
 	}
-	return nil, unmarshalNonIPError
+	return nil, opts.wrapError(LayerIP, 0, unmarshalNonIPError)
 }
@@ -0,0 +1,143 @@
+package parse
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+)
+
+var unmarshalSLLError = errors.New("insufficient bytes to Unmarshal Linux cooked capture")
+var unmarshalLinkTypeError = errors.New("unknown link type")
+
+// LinkType is the link layer header in front of the IP packet.
+type LinkType int
+
+const (
+	// LinkRaw is a packet that starts with the IP header, as delivered by
+	// NFQUEUE for the inet, ip and ip6 families and by TUN devices.
+	LinkRaw LinkType = iota
+	// LinkEthernet is an Ethernet II frame, optionally with 802.1Q and
+	// 802.1ad VLAN tags.
+	LinkEthernet
+	// LinkSLL is the Linux cooked capture header (DLT_LINUX_SLL) used when
+	// capturing on the any interface.
+	LinkSLL
+	// LinkSLL2 is the version 2 Linux cooked capture header
+	// (DLT_LINUX_SLL2), which adds the interface index.
+	LinkSLL2
+	// linkTypes is the number of link types
+	linkTypes
+)
+
+const (
+	ethernetHeaderLength = 14
+	sllHeaderLength      = 16
+	sll2HeaderLength     = 20
+	// arphrdEthernet is the ARPHRD type of Ethernet devices, the only type
+	// for which the SLL address is a MAC address
+	arphrdEthernet = 1
+)
+
+// Link describes the link layer header that was stripped to reach the IP
+// packet.
+type Link struct {
+	Type LinkType
+	// Source is the MAC address of the sender. Destination is only known
+	// for Ethernet frames.
+	Source      net.HardwareAddr
+	Destination net.HardwareAddr
+	// VLANs lists the VLAN IDs of the 802.1Q and 802.1ad tags, outermost
+	// first.
+	VLANs []uint16
+	// EtherType is the protocol of the payload after the VLAN tags.
+	EtherType uint16
+	// InterfaceIndex is the index of the capture interface, only set by
+	// SLL2.
+	InterfaceIndex uint32
+}
+
+// unmarshal strips the link layer header of the given type from frame and
+// returns the IP packet. It returns unmarshalNonIPError for frames that do
+// not carry IPv4 or IPv6.
+func (l *Link) unmarshal(frame []byte, linkType LinkType) ([]byte, error) {
+	*l = Link{Type: linkType, VLANs: l.VLANs[:0]}
+	var payload []byte
+	var err error
+	switch linkType {
+	case LinkEthernet:
+		payload, err = l.unmarshalEthernet(frame)
+	case LinkSLL:
+		payload, err = l.unmarshalSLL(frame)
+	case LinkSLL2:
+		payload, err = l.unmarshalSLL2(frame)
+	default:
+		return nil, unmarshalLinkTypeError
+	}
+	if err != nil {
+		return nil, err
+	}
+	if l.EtherType != etherTypeIPv4 && l.EtherType != etherTypeIPv6 {
+		return nil, unmarshalNonIPError
+	}
+	return payload, nil
+}
+
+// unmarshalEthernet reads the addresses and the VLAN tags of an Ethernet II
+// frame and returns its payload.
+func (l *Link) unmarshalEthernet(frame []byte) ([]byte, error) {
+	if len(frame) < ethernetHeaderLength {
+		return nil, unmarshalEthernetError
+	}
+	l.Destination = frame[0:6]
+	l.Source = frame[6:12]
+	return l.unmarshalVLANs(frame, binary.BigEndian.Uint16(frame[12:14]), ethernetHeaderLength)
+}
+
+// unmarshalSLL reads a Linux cooked capture header and returns its payload.
+func (l *Link) unmarshalSLL(frame []byte) ([]byte, error) {
+	if len(frame) < sllHeaderLength {
+		return nil, unmarshalSLLError
+	}
+	arphrd := binary.BigEndian.Uint16(frame[2:4])
+	l.Source = sllAddress(arphrd, frame[6:14], int(binary.BigEndian.Uint16(frame[4:6])))
+	return l.unmarshalVLANs(frame, binary.BigEndian.Uint16(frame[14:16]), sllHeaderLength)
+}
+
+// unmarshalSLL2 reads a version 2 Linux cooked capture header and returns
+// its payload.
+func (l *Link) unmarshalSLL2(frame []byte) ([]byte, error) {
+	if len(frame) < sll2HeaderLength {
+		return nil, unmarshalSLLError
+	}
+	l.InterfaceIndex = binary.BigEndian.Uint32(frame[4:8])
+	arphrd := binary.BigEndian.Uint16(frame[8:10])
+	l.Source = sllAddress(arphrd, frame[12:20], int(frame[11]))
+	return l.unmarshalVLANs(frame, binary.BigEndian.Uint16(frame[0:2]), sll2HeaderLength)
+}
+
+// unmarshalVLANs records the VLAN tags that follow an EtherType of 802.1Q
+// or 802.1ad and returns the payload after them. cursor is the offset of
+// the first byte after the EtherType.
+func (l *Link) unmarshalVLANs(frame []byte, etherType uint16, cursor int) ([]byte, error) {
+	for etherType == etherTypeVLAN || etherType == etherTypeQinQ {
+		// The tag control information and the next EtherType
+		if cursor+4 > len(frame) {
+			return nil, unmarshalEthernetError
+		}
+		// The VLAN ID is the low 12 bits of the tag control information
+		l.VLANs = append(l.VLANs, binary.BigEndian.Uint16(frame[cursor:cursor+2])&0x0fff)
+		etherType = binary.BigEndian.Uint16(frame[cursor+2 : cursor+4])
+		cursor += 4
+	}
+	l.EtherType = etherType
+	return frame[cursor:], nil
+}
+
+// sllAddress returns the link layer address of a cooked capture header when
+// it is a MAC address.
+func sllAddress(arphrd uint16, address []byte, length int) net.HardwareAddr {
+	if arphrd != arphrdEthernet || length != 6 {
+		return nil
+	}
+	return address[:6]
+}
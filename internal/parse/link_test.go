@@ -0,0 +1,135 @@
+package parse
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParse_link(t *testing.T) {
+	src4, dst4 := net.IP{10, 0, 0, 1}, net.IP{10, 0, 0, 2}
+	ip4TCP := func() []byte { return ip4Packet(t, src4, dst4, 6, tcpSegment) }
+	ip6TCP := func() []byte { return ip6Packet(t, nil, nil, 6, tcpSegment) }
+	srcMAC := net.HardwareAddr{0x02, 0x00, 0x00, 0x00, 0x00, 0x01}
+	dstMAC := net.HardwareAddr{0x02, 0x00, 0x00, 0x00, 0x00, 0x02}
+
+	tests := []struct {
+		name     string
+		payload  []byte
+		linkType LinkType
+		wantErr  error
+		wantSrc  net.IP
+		want     *Link
+	}{
+		{
+			name:     "Ethernet",
+			payload:  etherFrame(etherTypeIPv4, ip4TCP()),
+			linkType: LinkEthernet,
+			wantSrc:  src4,
+			want:     &Link{Type: LinkEthernet, Source: srcMAC, Destination: dstMAC, EtherType: etherTypeIPv4},
+		},
+		{
+			name:     "Ethernet QinQ",
+			payload:  etherFrame(etherTypeIPv6, ip6TCP(), 0x2064, 200),
+			linkType: LinkEthernet,
+			wantSrc:  net.ParseIP("2001:db8::1"),
+			want:     &Link{Type: LinkEthernet, Source: srcMAC, Destination: dstMAC, VLANs: []uint16{100, 200}, EtherType: etherTypeIPv6},
+		},
+		{
+			name:     "SLL",
+			payload:  sllFrame(etherTypeIPv4, ip4TCP()),
+			linkType: LinkSLL,
+			wantSrc:  src4,
+			want:     &Link{Type: LinkSLL, Source: srcMAC, EtherType: etherTypeIPv4},
+		},
+		{
+			name:     "SLL2 with VLAN tag",
+			payload:  sll2Frame(etherTypeVLAN, append([]byte{0x00, 0x2a, 0x08, 0x00}, ip4TCP()...)),
+			linkType: LinkSLL2,
+			wantSrc:  src4,
+			want:     &Link{Type: LinkSLL2, Source: srcMAC, VLANs: []uint16{42}, EtherType: etherTypeIPv4, InterfaceIndex: 3},
+		},
+		{
+			name:     "Ethernet ARP",
+			payload:  etherFrame(0x0806, make([]byte, 28)),
+			linkType: LinkEthernet,
+			wantErr:  unmarshalNonIPError,
+		},
+		{
+			name:     "Ethernet truncated VLAN tag",
+			payload:  etherFrame(etherTypeVLAN, []byte{0x00}),
+			linkType: LinkEthernet,
+			wantErr:  unmarshalEthernetError,
+		},
+		{
+			name:     "SLL truncated",
+			payload:  sllFrame(etherTypeIPv4, nil)[:15],
+			linkType: LinkSLL,
+			wantErr:  unmarshalSLLError,
+		},
+		{
+			name:     "Unknown link type",
+			payload:  ip4TCP(),
+			linkType: LinkSLL2 + 1,
+			wantErr:  unmarshalLinkTypeError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, parser := range []func([]byte) (networkLayer, error){
+				func(payload []byte) (networkLayer, error) {
+					return ParseWithOptions(payload, Options{Link: tt.linkType})
+				},
+				NewParser(Options{Link: tt.linkType}).Parse,
+			} {
+				p, err := parser(tt.payload)
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("unexpected error: want %v, got %v", tt.wantErr, err)
+				}
+				if tt.wantErr != nil {
+					return
+				}
+				if !p.Src().Equal(tt.wantSrc) {
+					t.Fatalf("unexpected source: want %s, got %s", tt.wantSrc, p.Src())
+				}
+				var link *Link
+				switch ip := p.(type) {
+				case *IPv4:
+					link = ip.Link
+				case *IPv6:
+					link = ip.Link
+				}
+				if diff := cmp.Diff(tt.want, link); diff != "" {
+					t.Fatalf("unexpected link (-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
+
+// sllFrame builds a Linux cooked capture header of a received Ethernet
+// frame, followed by payload.
+func sllFrame(protocol uint16, payload []byte) []byte {
+	frame := []byte{
+		0x00, 0x00, 0x00, 0x01, 0x00, 0x06, // incoming, Ethernet, address length
+		0x02, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00,
+	}
+	frame = binary.BigEndian.AppendUint16(frame, protocol)
+	return append(frame, payload...)
+}
+
+// sll2Frame builds a version 2 Linux cooked capture header of a frame
+// received on interface 3, followed by payload.
+func sll2Frame(protocol uint16, payload []byte) []byte {
+	frame := binary.BigEndian.AppendUint16(nil, protocol)
+	frame = append(frame,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x03, // reserved, interface index
+		0x00, 0x01, 0x00, 0x06, // Ethernet, incoming, address length
+		0x02, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00,
+	)
+	return append(frame, payload...)
+}
@@ -0,0 +1,110 @@
+package parse
+
+import (
+	"github.com/jsimonetti/sniqueue/internal/parse/quic"
+	"github.com/jsimonetti/sniqueue/internal/parse/tls"
+)
+
+// Parser parses packets into state that is reused from one packet to the
+// next, so that extracting the SNI from a TCP ClientHello, or from a QUIC
+// Initial whose keys are cached, does not allocate.
+// The packet returned by Parse, including the strings of its ClientHello,
+// and the *Error it returns refer to the Parser and to the payload. They are
+// only valid until the next call to Parse and as long as the payload is not
+// modified or reused.
+// A Parser is not safe for concurrent use, use one per goroutine.
+type Parser struct {
+	opts Options
+
+	ip4  IPv4
+	ip6  IPv6
+	tcp  TCP
+	udp  UDP
+	link Link
+	quic quic.Quic
+	err  Error
+
+	openers *quic.Openers
+}
+
+// NewParser returns a Parser that parses packets as specified by opts.
+func NewParser(opts Options) *Parser {
+	p := &Parser{opts: opts}
+	p.opts.parser = p
+	if opts.QUICKeys > 0 {
+		p.openers = quic.NewOpeners(opts.QUICKeys)
+	}
+	return p
+}
+
+// Parse parses payload as an IP packet, like ParseWithOptions.
+func (p *Parser) Parse(payload []byte) (networkLayer, error) {
+	return ParseWithOptions(payload, p.opts)
+}
+
+// ipv4 returns the IPv4 state to parse into.
+func (o Options) ipv4() *IPv4 {
+	if o.parser == nil {
+		return &IPv4{}
+	}
+	return &o.parser.ip4
+}
+
+// ipv6 returns the IPv6 state to parse into.
+func (o Options) ipv6() *IPv6 {
+	if o.parser == nil {
+		return &IPv6{}
+	}
+	return &o.parser.ip6
+}
+
+// link returns the link layer state to parse into.
+func (o Options) link() *Link {
+	if o.parser == nil {
+		return &Link{}
+	}
+	return &o.parser.link
+}
+
+// tcp returns the TCP state to parse into, cleared of the previous packet.
+func (o Options) tcp() *TCP {
+	if o.parser == nil {
+		return &TCP{}
+	}
+	p := &o.parser.tcp
+	*p = TCP{Hello: resetHello(p.Hello)}
+	return p
+}
+
+// udp returns the UDP state to parse into, cleared of the previous packet.
+func (o Options) udp() *UDP {
+	if o.parser == nil {
+		return &UDP{}
+	}
+	p := &o.parser.udp
+	*p = UDP{Hello: resetHello(p.Hello)}
+	return p
+}
+
+// quic returns the QUIC state to parse into, with the hello of p.
+func (o Options) quic(hello tls.ClientHello) *quic.Quic {
+	if o.parser == nil {
+		return &quic.Quic{Hello: hello}
+	}
+	q := &o.parser.quic
+	*q = quic.Quic{Hello: hello}
+	return q
+}
+
+// openers returns the cache of QUIC Initial openers, if any.
+func (o Options) openers() *quic.Openers {
+	if o.parser == nil {
+		return nil
+	}
+	return o.parser.openers
+}
+
+func resetHello(hello tls.ClientHello) tls.ClientHello {
+	hello.Reset()
+	return hello
+}
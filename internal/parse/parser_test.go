@@ -0,0 +1,95 @@
+package parse
+
+import (
+	"testing"
+)
+
+func TestParser_allocs(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   Options
+		packet []byte
+		want   string
+	}{
+		{name: "SNI only", opts: Options{SNIOnly: true}, packet: tcpHello4, want: "dns.google"},
+		{name: "Full hello", opts: Options{}, packet: tcpHello4, want: "dns.google"},
+		{name: "QUIC", opts: Options{QUICKeys: 8}, packet: quicInitial6, want: "r2---sn-fxc25nn-nwje.googlevideo.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := NewParser(tt.opts)
+			packet := make([]byte, len(tt.packet))
+			allocs := testing.AllocsPerRun(100, func() {
+				// The header protection of QUIC is removed in place
+				copy(packet, tt.packet)
+				p, err := parser.Parse(packet)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if p.DomainName() != tt.want {
+					t.Fatalf("unexpected domain name: %q", p.DomainName())
+				}
+			})
+			if allocs != 0 {
+				t.Fatalf("expected no allocations, got %v per packet", allocs)
+			}
+		})
+	}
+}
+
+func TestParser_reuse(t *testing.T) {
+	parser := NewParser(Options{})
+	p, err := parser.Parse(tcpHello4)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Hello().SNI != "dns.google" || len(p.Hello().ALPN) != 2 {
+		t.Fatalf("unexpected hello: %+v", p.Hello())
+	}
+
+	// A packet without a hello must not keep the previous one
+	p, _ = parser.Parse(ip6Packet(t, nil, nil, 6, tcpSegment[:20]))
+	if p.DomainName() != "" || len(p.Hello().ALPN) != 0 || p.Version() != 6 {
+		t.Fatalf("state of the previous packet was kept: %+v", p.Hello())
+	}
+}
+
+func BenchmarkParser_sni(b *testing.B) {
+	packet := make([]byte, 1500)
+	copy(packet, tcpHello4)
+	parser := NewParser(Options{SNIOnly: true})
+
+	b.ReportAllocs()
+	var got networkLayer
+	for n := 0; n < b.N; n++ {
+		got, _ = parser.Parse(packet)
+	}
+	result = got
+}
+
+func BenchmarkParser_hello(b *testing.B) {
+	packet := make([]byte, 1500)
+	copy(packet, tcpHello4)
+	parser := NewParser(Options{})
+
+	b.ReportAllocs()
+	var got networkLayer
+	for n := 0; n < b.N; n++ {
+		got, _ = parser.Parse(packet)
+	}
+	result = got
+}
+
+func BenchmarkParser_quic(b *testing.B) {
+	packet := make([]byte, len(quicInitial6))
+	parser := NewParser(Options{QUICKeys: 8})
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		// The header protection is removed in place
+		copy(packet, quicInitial6)
+		if _, err := parser.Parse(packet); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
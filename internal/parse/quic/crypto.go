@@ -96,17 +96,19 @@ func (o *LongHeaderOpener) DecryptHeader(sample []byte, firstByte *byte, pnBytes
 	o.headerProtector.DecryptHeader(sample, firstByte, pnBytes)
 }
 
-func UnpackHeader(hd *LongHeaderOpener, hdr *Header, data []byte, version uint32) (*ExtendedHeader, error) {
+// UnpackHeader removes the header protection of the packet in data, whose
+// long header was parsed into hdr, and reads its packet number into hdr.
+func UnpackHeader(hd *LongHeaderOpener, hdr *ExtendedHeader, data []byte) error {
 	r := bytes.NewReader(data)
 
 	hdrLen := hdr.ParsedLen
 	if int64(len(data)) < hdrLen+4+16 {
-		return nil, UnmarshalQUICError
+		return UnmarshalQUICError
 	}
 	// The packet number can be up to 4 bytes long, but we won't know the length until we decrypt it.
 	// 1. save a copy of the 4 bytes
-	origPNBytes := make([]byte, 4)
-	copy(origPNBytes, data[hdrLen:hdrLen+4])
+	var origPNBytes [4]byte
+	copy(origPNBytes[:], data[hdrLen:hdrLen+4])
 	// 2. decrypt the header, assuming a 4 byte packet number
 	hd.DecryptHeader(
 		data[hdrLen+4:hdrLen+4+16],
@@ -114,13 +116,16 @@ func UnpackHeader(hd *LongHeaderOpener, hdr *Header, data []byte, version uint32
 		data[hdrLen:hdrLen+4],
 	)
 	// 3. parse the header (and learn the actual length of the packet number)
-	extHdr, parseErr := hdr.ParseExtended(r, version)
-	if parseErr != nil && parseErr != UnmarshalQUICBitsError {
-		return nil, parseErr
+	reservedBitsValid, err := hdr.Parse(r, hdr.Version)
+	if err != nil {
+		return err
 	}
 	// 4. if the packet number is shorter than 4 bytes, replace the remaining bytes with the copy we saved earlier
-	if extHdr.PacketNumberLen != 4 {
-		copy(data[extHdr.ParsedLen:hdrLen+4], origPNBytes[int(extHdr.PacketNumberLen):])
+	if hdr.PacketNumberLen != 4 {
+		copy(data[hdr.ParsedLen:hdrLen+4], origPNBytes[int(hdr.PacketNumberLen):])
+	}
+	if !reservedBitsValid {
+		return UnmarshalQUICBitsError
 	}
-	return extHdr, parseErr
+	return nil
 }
@@ -0,0 +1,126 @@
+package quic
+
+import (
+	"bytes"
+	"cmp"
+	"io"
+	"slices"
+)
+
+// Frame types that may appear in an Initial packet (RFC 9000, section 12.4).
+const (
+	framePadding          = 0x00
+	framePing             = 0x01
+	frameAck              = 0x02
+	frameAckECN           = 0x03
+	frameCrypto           = 0x06
+	frameConnectionClose  = 0x1c
+	frameApplicationClose = 0x1d
+)
+
+type cryptoFrame struct {
+	offset uint64
+	data   []byte
+}
+
+// ReadCryptoData walks the frames in the decrypted payload of an Initial
+// packet and returns the CRYPTO stream data that is contiguous from offset 0.
+// Clients may split the ClientHello over several CRYPTO frames in any order,
+// and over several Initials when it is large. The Initials that only carry
+// the rest of such a hello return UnmarshalQUICContinuationError.
+func ReadCryptoData(payload []byte) ([]byte, error) {
+	b := bytes.NewReader(payload)
+	// Chromium splits the hello in a few frames, more are rare
+	var buf [8]cryptoFrame
+	frames := buf[:0]
+	for b.Len() > 0 {
+		frameType, err := ReadQuickVarInt(b)
+		if err != nil {
+			return nil, err
+		}
+		switch frameType {
+		case framePadding, framePing:
+		case frameAck, frameAckECN:
+			if err := skipAckFrame(b, frameType == frameAckECN); err != nil {
+				return nil, err
+			}
+		case frameCrypto:
+			offset, err := ReadQuickVarInt(b)
+			if err != nil {
+				return nil, err
+			}
+			length, err := ReadQuickVarInt(b)
+			if err != nil {
+				return nil, err
+			}
+			if length > uint64(b.Len()) {
+				return nil, io.EOF
+			}
+			start := len(payload) - b.Len()
+			frames = append(frames, cryptoFrame{offset: offset, data: payload[start : start+int(length)]})
+			if _, err := b.Seek(int64(length), io.SeekCurrent); err != nil {
+				return nil, err
+			}
+		case frameConnectionClose, frameApplicationClose:
+			// Nothing useful follows a close
+			b.Reset(nil)
+		default:
+			return nil, UnmarshalQUICFrameError
+		}
+	}
+	if len(frames) == 0 {
+		return nil, UnmarshalNoQUICCryptoError
+	}
+	if len(frames) == 1 && frames[0].offset == 0 {
+		return frames[0].data, nil
+	}
+
+	slices.SortFunc(frames, func(a, b cryptoFrame) int { return cmp.Compare(a.offset, b.offset) })
+	if frames[0].offset > 0 {
+		// The start of the hello is in an earlier Initial
+		return nil, UnmarshalQUICContinuationError
+	}
+	var data []byte
+	for _, f := range frames {
+		next := uint64(len(data))
+		if f.offset > next {
+			// There is a gap, the missing data is in another packet
+			break
+		}
+		if end := f.offset + uint64(len(f.data)); end > next {
+			data = append(data, f.data[next-f.offset:]...)
+		}
+	}
+	return data, nil
+}
+
+func skipAckFrame(b *bytes.Reader, ecn bool) error {
+	// Largest Acknowledged, ACK Delay, ACK Range Count, First ACK Range
+	var fields [4]uint64
+	for i := range fields {
+		v, err := ReadQuickVarInt(b)
+		if err != nil {
+			return err
+		}
+		fields[i] = v
+	}
+	ranges := fields[2]
+	if ranges > uint64(b.Len()) {
+		return io.EOF
+	}
+	// Gap and ACK Range Length for every range
+	for i := uint64(0); i < ranges*2; i++ {
+		if _, err := ReadQuickVarInt(b); err != nil {
+			return err
+		}
+	}
+	if ecn {
+		// ECT0, ECT1 and ECN-CE counts
+		for i := 0; i < 3; i++ {
+			if _, err := ReadQuickVarInt(b); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
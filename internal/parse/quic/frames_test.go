@@ -0,0 +1,91 @@
+package quic
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReadCryptoData(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload []byte
+		want    []byte
+		wantErr bool
+	}{
+		{
+			name:    "Empty",
+			payload: []byte{},
+			wantErr: true,
+		},
+		{
+			name: "Single frame",
+			payload: []byte{
+				0x06, 0x00, 0x05, 'h', 'e', 'l', 'l', 'o',
+				0x00, 0x00, 0x00,
+			},
+			want: []byte("hello"),
+		},
+		{
+			name: "Out of order with padding, ping and ack",
+			payload: []byte{
+				0x00, 0x00,
+				0x06, 0x05, 0x05, 'w', 'o', 'r', 'l', 'd',
+				0x01,
+				0x02, 0x01, 0x00, 0x01, 0x00, 0x00, 0x00,
+				0x06, 0x00, 0x05, 'h', 'e', 'l', 'l', 'o',
+				0x00,
+			},
+			want: []byte("helloworld"),
+		},
+		{
+			name: "Overlapping frames",
+			payload: []byte{
+				0x06, 0x03, 0x04, 'l', 'o', 'w', 'o',
+				0x06, 0x00, 0x04, 'h', 'e', 'l', 'l',
+			},
+			want: []byte("hellowo"),
+		},
+		{
+			name: "Gap",
+			payload: []byte{
+				0x06, 0x00, 0x05, 'h', 'e', 'l', 'l', 'o',
+				0x06, 0x06, 0x04, 'o', 'r', 'l', 'd',
+			},
+			want: []byte("hello"),
+		},
+		{
+			name: "Continuation",
+			payload: []byte{
+				0x06, 0x05, 0x05, 'w', 'o', 'r', 'l', 'd',
+				0x00, 0x00,
+			},
+			wantErr: true,
+		},
+		{
+			name: "Truncated frame",
+			payload: []byte{
+				0x06, 0x00, 0x05, 'h', 'e', 'l',
+			},
+			wantErr: true,
+		},
+		{
+			name: "Unknown frame",
+			payload: []byte{
+				0x08, 0x00, 0x05, 'h', 'e', 'l', 'l', 'o',
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadCryptoData(tt.payload)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadCryptoData() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Fatalf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package quic
+
+import (
+	"bytes"
+	"testing"
+)
+
+func FuzzParseHeader(f *testing.F) {
+	f.Add(initialV1)
+	f.Add(gquicQ046)
+	f.Add(gquicQ050)
+	// Retry and Version Negotiation
+	f.Add([]byte{0xf0, 0x00, 0x00, 0x00, 0x01, 0x01, 0x01, 0x01, 0x02, 0x74, 0x6f, 0x6b, 0x65, 0x6e,
+		0xee, 0xee, 0xee, 0xee, 0xee, 0xee, 0xee, 0xee, 0xee, 0xee, 0xee, 0xee, 0xee, 0xee, 0xee, 0xee})
+	f.Add([]byte{0x80, 0x00, 0x00, 0x00, 0x00, 0x01, 0x01, 0x01, 0x02, 0x00, 0x00, 0x00, 0x01})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// The header is decrypted in place
+		data = bytes.Clone(data)
+		hdr, err := ParseHeader(data)
+		if err != nil {
+			return
+		}
+		if hdr.ParsedLen > int64(len(data)) {
+			t.Fatalf("parsed %d bytes of %d", hdr.ParsedLen, len(data))
+		}
+		if hdr.Type != PacketInitial {
+			return
+		}
+		opener := NewInitialAEAD(hdr.DestConnectionID, hdr.Version)
+		extHdr := &ExtendedHeader{Header: *hdr}
+		if err := UnpackHeader(opener, extHdr, data); err != nil && err != UnmarshalQUICBitsError {
+			return
+		}
+		if extHdr.ParsedLen > int64(len(data)) {
+			t.Fatalf("unpacked %d bytes of %d", extHdr.ParsedLen, len(data))
+		}
+	})
+}
+
+func FuzzReadQuickVarInt(f *testing.F) {
+	for _, seed := range [][]byte{
+		{0x25},
+		{0x7b, 0xbd},
+		{0x9d, 0x7f, 0x3e, 0x7d},
+		{0xc2, 0x19, 0x7c, 0x5e, 0xff, 0x14, 0xe8, 0x8c},
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		b := bytes.NewReader(data)
+		v, err := ReadQuickVarInt(b)
+		if err != nil {
+			return
+		}
+		if length := 1 << (data[0] >> 6); len(data)-b.Len() != length {
+			t.Fatalf("read %d bytes of a %d byte integer", len(data)-b.Len(), length)
+		}
+		if v >= 1<<62 {
+			t.Fatalf("%d does not fit in 62 bits", v)
+		}
+	})
+}
+
+func FuzzGQuic_Unmarshal(f *testing.F) {
+	f.Add(gquicQ043)
+	f.Add(gquicQ046)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var p GQuic
+		_ = p.Unmarshal(data)
+	})
+}
+
+func FuzzCHLO_Unmarshal(f *testing.F) {
+	for _, packet := range [][]byte{gquicQ043, gquicQ046} {
+		if i := bytes.Index(packet, tagCHLO[:]); i >= 0 {
+			f.Add(packet[i:])
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, message []byte) {
+		var c CHLO
+		_ = c.Unmarshal(message)
+	})
+}
@@ -0,0 +1,321 @@
+package quic
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+// The Google QUIC versions that send the CHLO unencrypted
+const (
+	VersionQ043 uint32 = 0x51303433
+	VersionQ046 uint32 = 0x51303436
+)
+
+// Google QUIC frame types (pre-IETF framing)
+const (
+	gquicFramePadding = 0x00
+	gquicFramePing    = 0x07
+	gquicFrameCrypto  = 0x08 // Q050 only
+	gquicFrameStream  = 0x80
+)
+
+const (
+	// gquicCryptoStream is the stream that carries the handshake messages
+	gquicCryptoStream = 1
+	// gquicHashLength is the length of the message authentication hash
+	// of unencrypted packets
+	gquicHashLength = 12
+	// gquicMaxTags is the maximum number of tags in a handshake message
+	gquicMaxTags = 128
+)
+
+// Tags of a CHLO handshake message, as they appear on the wire
+var (
+	tagCHLO = [4]byte{'C', 'H', 'L', 'O'}
+	tagSNI  = [4]byte{'S', 'N', 'I', 0}
+	tagUAID = [4]byte{'U', 'A', 'I', 'D'}
+	tagVER  = [4]byte{'V', 'E', 'R', 0}
+)
+
+// CHLO holds the tags of a Google QUIC client hello used for matching.
+type CHLO struct {
+	// Version is the version the client asks for, such as "Q043"
+	Version string
+	SNI     string
+	// UAID is the user agent of the client
+	UAID string
+}
+
+// GQuic is a Google QUIC packet that carries an unencrypted CHLO (Q043 and
+// Q046). Q050 encrypts the CHLO and is handled by Quic.
+type GQuic struct {
+	Version      uint32
+	ConnectionID []byte
+	PacketNumber uint64
+	CHLO         CHLO
+}
+
+// IsGQUIC reports whether payload starts with a Q043 public header or a Q046
+// long header.
+func IsGQUIC(payload []byte) bool {
+	if len(payload) < 5 {
+		return false
+	}
+	if payload[0]&0x80 != 0 {
+		// Q046 long header, the version follows the type byte
+		return binary.BigEndian.Uint32(payload[1:5]) == VersionQ046
+	}
+	// Q043 public header, the version follows the connection id
+	offset := 1
+	if payload[0]&0x08 != 0 {
+		offset += 8
+	}
+	return payload[0]&0x01 != 0 && len(payload) >= offset+4 &&
+		binary.BigEndian.Uint32(payload[offset:offset+4]) == VersionQ043
+}
+
+// Unmarshal decodes the header of a Q043 or Q046 packet and the CHLO in its
+// crypto stream frame.
+func (p *GQuic) Unmarshal(payload []byte) error {
+	b := bytes.NewReader(payload)
+	typeByte, err := b.ReadByte()
+	if err != nil {
+		return UnmarshalQUICError
+	}
+	var packetNumberLen int
+	if typeByte&0x80 != 0 {
+		packetNumberLen, err = p.readLongHeader(b, typeByte)
+	} else {
+		packetNumberLen, err = p.readPublicHeader(b, typeByte)
+	}
+	if err != nil {
+		return err
+	}
+
+	var pn [8]byte
+	if _, err := io.ReadFull(b, pn[8-packetNumberLen:]); err != nil {
+		return UnmarshalQUICError
+	}
+	p.PacketNumber = binary.BigEndian.Uint64(pn[:])
+	if _, err := b.Seek(gquicHashLength, io.SeekCurrent); err != nil || b.Len() == 0 {
+		return UnmarshalQUICError
+	}
+
+	message, err := readStreamFrames(b, payload)
+	if err != nil {
+		return err
+	}
+	return p.CHLO.Unmarshal(message)
+}
+
+// readPublicHeader reads the Q043 public header up to the packet number and
+// returns the packet number length.
+func (p *GQuic) readPublicHeader(b *bytes.Reader, flags byte) (int, error) {
+	if flags&0x02 != 0 {
+		// Public reset
+		return 0, UnmarshalNoQUICInitialError
+	}
+	if flags&0x01 == 0 {
+		// Only the first packets of a client carry the version
+		return 0, UnmarshalNoQUICInitialError
+	}
+	var err error
+	if flags&0x08 != 0 {
+		if p.ConnectionID, err = ReadConnectionID(b, 8); err != nil {
+			return 0, UnmarshalQUICError
+		}
+	}
+	if p.Version, err = ReadUint32(b); err != nil {
+		return 0, UnmarshalQUICError
+	}
+	if p.Version != VersionQ043 {
+		return 0, UnmarshalQUICUnsupportedVersion
+	}
+	if flags&0x04 != 0 {
+		// Diversification nonce, only sent by servers
+		return 0, UnmarshalNoQUICInitialError
+	}
+	switch flags & 0x30 {
+	case 0x00:
+		return 1, nil
+	case 0x10:
+		return 2, nil
+	case 0x20:
+		return 4, nil
+	}
+	return 6, nil
+}
+
+// readLongHeader reads the Q046 long header up to the packet number and
+// returns the packet number length.
+func (p *GQuic) readLongHeader(b *bytes.Reader, typeByte byte) (int, error) {
+	var err error
+	if p.Version, err = ReadUint32(b); err != nil {
+		return 0, UnmarshalQUICError
+	}
+	if p.Version != VersionQ046 {
+		return 0, UnmarshalQUICUnsupportedVersion
+	}
+	if typeByte&0x40 == 0 {
+		return 0, UnmarshalNoQUICError
+	}
+	if typeByte&0x30 != 0 {
+		return 0, UnmarshalNoQUICInitialError
+	}
+	// Both connection id lengths are in one byte, a non-zero length is
+	// encoded as the length minus 3
+	lengths, err := b.ReadByte()
+	if err != nil {
+		return 0, UnmarshalQUICError
+	}
+	destLen, srcLen := int(lengths>>4), int(lengths&0x0f)
+	if destLen > 0 {
+		destLen += 3
+	}
+	if srcLen > 0 {
+		srcLen += 3
+	}
+	if p.ConnectionID, err = ReadConnectionID(b, destLen); err != nil {
+		return 0, UnmarshalQUICError
+	}
+	if _, err := ReadConnectionID(b, srcLen); err != nil {
+		return 0, UnmarshalQUICError
+	}
+	return int(typeByte&0x03) + 1, nil
+}
+
+// readStreamFrames walks the gQUIC frames of an unencrypted packet and
+// returns the data of the crypto stream, which must start at offset 0.
+func readStreamFrames(b *bytes.Reader, payload []byte) ([]byte, error) {
+	for b.Len() > 0 {
+		frameType, err := b.ReadByte()
+		if err != nil {
+			return nil, UnmarshalQUICError
+		}
+		switch {
+		case frameType == gquicFramePadding:
+			// Padding fills the rest of the packet
+			return nil, UnmarshalNoQUICCryptoError
+		case frameType == gquicFramePing:
+		case frameType&gquicFrameStream != 0:
+			// 1fdooossB: FIN, data length present, offset and stream id lengths
+			streamLen := int(frameType&0x03) + 1
+			offsetLen := int(frameType>>2) & 0x07
+			if offsetLen > 0 {
+				offsetLen++
+			}
+			var buf [8]byte
+			if _, err := io.ReadFull(b, buf[8-streamLen:]); err != nil {
+				return nil, UnmarshalQUICError
+			}
+			streamID := binary.BigEndian.Uint64(buf[:])
+			buf = [8]byte{}
+			if _, err := io.ReadFull(b, buf[8-offsetLen:]); err != nil {
+				return nil, UnmarshalQUICError
+			}
+			offset := binary.BigEndian.Uint64(buf[:])
+			length := b.Len()
+			if frameType&0x20 != 0 {
+				n, err := ReadUint16(b)
+				if err != nil {
+					return nil, UnmarshalQUICError
+				}
+				length = int(n)
+			}
+			if length > b.Len() {
+				return nil, UnmarshalQUICError
+			}
+			start := len(payload) - b.Len()
+			if streamID == gquicCryptoStream && offset == 0 {
+				return payload[start : start+length], nil
+			}
+			if _, err := b.Seek(int64(length), io.SeekCurrent); err != nil {
+				return nil, UnmarshalQUICError
+			}
+		default:
+			return nil, UnmarshalQUICFrameError
+		}
+	}
+	return nil, UnmarshalNoQUICCryptoError
+}
+
+// readGQUICCryptoData walks the frames in the decrypted payload of a Q050
+// Initial packet and returns the crypto data at offset 0.
+func readGQUICCryptoData(payload []byte) ([]byte, error) {
+	b := bytes.NewReader(payload)
+	for b.Len() > 0 {
+		frameType, err := b.ReadByte()
+		if err != nil {
+			return nil, UnmarshalQUICError
+		}
+		switch frameType {
+		case gquicFramePadding, gquicFramePing:
+		case gquicFrameCrypto:
+			offset, err := ReadQuickVarInt(b)
+			if err != nil {
+				return nil, UnmarshalQUICError
+			}
+			length, err := ReadQuickVarInt(b)
+			if err != nil {
+				return nil, UnmarshalQUICError
+			}
+			if length > uint64(b.Len()) {
+				return nil, UnmarshalQUICError
+			}
+			start := len(payload) - b.Len()
+			if offset == 0 {
+				return payload[start : start+int(length)], nil
+			}
+			if _, err := b.Seek(int64(length), io.SeekCurrent); err != nil {
+				return nil, UnmarshalQUICError
+			}
+		default:
+			return nil, UnmarshalQUICFrameError
+		}
+	}
+	return nil, UnmarshalNoQUICCryptoError
+}
+
+// Unmarshal decodes a CHLO handshake message. The message is a tag/value
+// map: the message tag, the number of entries, padding, the entries (tag and
+// end offset of the value, little endian) and finally the values.
+func (c *CHLO) Unmarshal(message []byte) error {
+	if len(message) < 8 {
+		return UnmarshalCHLOError
+	}
+	if [4]byte(message[0:4]) != tagCHLO {
+		return UnmarshalNoCHLOError
+	}
+	entries := int(binary.LittleEndian.Uint16(message[4:6]))
+	if entries > gquicMaxTags {
+		return UnmarshalCHLOError
+	}
+	valuesStart := 8 + entries*8
+	if valuesStart > len(message) {
+		return UnmarshalCHLOError
+	}
+	values := message[valuesStart:]
+
+	start := 0
+	for i := 0; i < entries; i++ {
+		entry := message[8+i*8 : 16+i*8]
+		end := int(binary.LittleEndian.Uint32(entry[4:8]))
+		if end < start || end > len(values) {
+			// Offsets must increase and stay within the message
+			return UnmarshalCHLOError
+		}
+		value := values[start:end]
+		start = end
+
+		switch [4]byte(entry[0:4]) {
+		case tagSNI:
+			c.SNI = string(value)
+		case tagUAID:
+			c.UAID = string(value)
+		case tagVER:
+			c.Version = string(value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,353 @@
+package quic
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const userAgent = "Chrome/86.0.4240.75 Linux x86_64"
+
+func TestGQuic_Unmarshal(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload []byte
+		want    *GQuic
+		wantErr error
+	}{
+		{
+			name:    "Q043",
+			payload: gquicQ043,
+			want: &GQuic{
+				Version:      VersionQ043,
+				ConnectionID: []byte{0xd1, 0xa2, 0xb3, 0xc4, 0xe5, 0xf6, 0x07, 0x18},
+				PacketNumber: 1,
+				CHLO:         CHLO{Version: "Q043", SNI: "www.youtube.com", UAID: userAgent},
+			},
+		},
+		{
+			name:    "Q046",
+			payload: gquicQ046,
+			want: &GQuic{
+				Version:      VersionQ046,
+				ConnectionID: []byte{0x5a, 0x2a, 0xe2, 0xc6, 0xf9, 0x5c, 0x2a, 0x02},
+				PacketNumber: 1,
+				CHLO:         CHLO{Version: "Q046", SNI: "www.google.com", UAID: userAgent},
+			},
+		},
+		{
+			name:    "Q046 handshake packet",
+			payload: append([]byte{0xe3}, gquicQ046[1:]...),
+			wantErr: UnmarshalNoQUICInitialError,
+		},
+		{
+			name:    "Q043 truncated stream frame",
+			payload: gquicQ043[:100],
+			wantErr: UnmarshalQUICError,
+		},
+		{
+			name:    "Q043 stream frame at non-zero offset",
+			payload: append(append(append([]byte(nil), gquicQ043[:26]...), 0xa4, 0x01, 0x05, 0xa0), gquicQ043[28:]...),
+			wantErr: UnmarshalNoQUICCryptoError,
+		},
+		{
+			name:    "Q043 unknown frame",
+			payload: append(append([]byte(nil), gquicQ043[:26]...), 0x04, 0x00),
+			wantErr: UnmarshalQUICFrameError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !IsGQUIC(tt.payload) {
+				t.Fatalf("IsGQUIC() = false")
+			}
+			got := &GQuic{}
+			err := got.Unmarshal(tt.payload)
+			if err != tt.wantErr {
+				t.Fatalf("Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Fatalf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestQuic_unmarshalGQUIC(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload []byte
+		want    *CHLO
+	}{
+		{
+			name:    "Q043",
+			payload: gquicQ043,
+			want:    &CHLO{Version: "Q043", SNI: "www.youtube.com", UAID: userAgent},
+		},
+		{
+			name:    "Q050",
+			payload: gquicQ050,
+			want:    &CHLO{Version: "Q050", SNI: "www.googleapis.com", UAID: "Chrome/87.0.4280.88 Windows NT 10.0; Win64; x64"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := &Quic{}
+			if err := got.Unmarshal(tt.payload); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got.CHLO); diff != "" {
+				t.Fatalf("unexpected CHLO (-want +got):\n%s", diff)
+			}
+			if got.Hello.SNI != tt.want.SNI {
+				t.Fatalf("SNI = %q, want %q", got.Hello.SNI, tt.want.SNI)
+			}
+		})
+	}
+}
+
+func TestIsGQUIC(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload []byte
+		want    bool
+	}{
+		{name: "Q043", payload: gquicQ043, want: true},
+		{name: "Q046", payload: gquicQ046, want: true},
+		{name: "Q043 without version", payload: append([]byte{0x08}, gquicQ043[1:]...)},
+		{name: "IETF QUIC", payload: []byte{0xc3, 0x00, 0x00, 0x00, 0x01, 0x08}},
+		{name: "Short", payload: []byte{0x09, 0x01}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsGQUIC(tt.payload); got != tt.want {
+				t.Errorf("IsGQUIC() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCHLO_Unmarshal(t *testing.T) {
+	tests := []struct {
+		name    string
+		message []byte
+		want    CHLO
+		wantErr error
+	}{
+		{
+			name: "SNI and VER",
+			message: []byte{
+				'C', 'H', 'L', 'O', 0x02, 0x00, 0x00, 0x00,
+				'S', 'N', 'I', 0x00, 0x0b, 0x00, 0x00, 0x00,
+				'V', 'E', 'R', 0x00, 0x0f, 0x00, 0x00, 0x00,
+				'e', 'x', 'a', 'm', 'p', 'l', 'e', '.', 'c', 'o', 'm', 'Q', '0', '4', '3',
+			},
+			want: CHLO{Version: "Q043", SNI: "example.com"},
+		},
+		{
+			name: "Not a CHLO",
+			message: []byte{
+				'R', 'E', 'J', 0x00, 0x00, 0x00, 0x00, 0x00,
+			},
+			wantErr: UnmarshalNoCHLOError,
+		},
+		{
+			name: "Offsets decrease",
+			message: []byte{
+				'C', 'H', 'L', 'O', 0x02, 0x00, 0x00, 0x00,
+				'S', 'N', 'I', 0x00, 0x04, 0x00, 0x00, 0x00,
+				'V', 'E', 'R', 0x00, 0x02, 0x00, 0x00, 0x00,
+				'a', 'b', 'c', 'd',
+			},
+			want:    CHLO{SNI: "abcd"},
+			wantErr: UnmarshalCHLOError,
+		},
+		{
+			name: "Value beyond message",
+			message: []byte{
+				'C', 'H', 'L', 'O', 0x01, 0x00, 0x00, 0x00,
+				'S', 'N', 'I', 0x00, 0xff, 0x00, 0x00, 0x00,
+				'a', 'b', 'c', 'd',
+			},
+			wantErr: UnmarshalCHLOError,
+		},
+		{
+			name: "Entries beyond message",
+			message: []byte{
+				'C', 'H', 'L', 'O', 0x10, 0x00, 0x00, 0x00,
+				'S', 'N', 'I', 0x00, 0x00, 0x00, 0x00, 0x00,
+			},
+			wantErr: UnmarshalCHLOError,
+		},
+		{
+			name: "Too many entries",
+			message: []byte{
+				'C', 'H', 'L', 'O', 0x00, 0x01, 0x00, 0x00,
+			},
+			wantErr: UnmarshalCHLOError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got CHLO
+			if err := got.Unmarshal(tt.message); err != tt.wantErr {
+				t.Fatalf("Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Fatalf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// The gQUIC fixtures below are synthesized, not captured: no client still
+// speaks these versions to record from, and the connection ids, padding and
+// CHLO tags are made up. They follow the wire format of Chromium as read from
+// its source, so they only show the parser agrees with that reading. A CHLO
+// captured from Chrome, especially for Q046 and Q050, is still missing and
+// should replace them when one turns up.
+
+// gquicQ043 is a Q043 client Initial for www.youtube.com: public header with
+// version and 8 byte connection id, the 12 byte FNV-1a hash of the NULL
+// encryption and a stream frame on stream 1 carrying the CHLO, followed by
+// padding.
+var gquicQ043 = []byte{
+	0x09, 0xd1, 0xa2, 0xb3, 0xc4, 0xe5, 0xf6, 0x07, 0x18, 0x51, 0x30, 0x34, 0x33, 0x01, 0x5a, 0x29,
+	0x5a, 0x24, 0x00, 0x39, 0xb0, 0xbe, 0xc4, 0xaa, 0xd0, 0x5e, 0xa0, 0x01, 0x01, 0x33, 0x43, 0x48,
+	0x4c, 0x4f, 0x11, 0x00, 0x00, 0x00, 0x50, 0x41, 0x44, 0x00, 0x18, 0x00, 0x00, 0x00, 0x53, 0x4e,
+	0x49, 0x00, 0x27, 0x00, 0x00, 0x00, 0x56, 0x45, 0x52, 0x00, 0x2b, 0x00, 0x00, 0x00, 0x43, 0x43,
+	0x53, 0x00, 0x3b, 0x00, 0x00, 0x00, 0x55, 0x41, 0x49, 0x44, 0x5b, 0x00, 0x00, 0x00, 0x54, 0x43,
+	0x49, 0x44, 0x5f, 0x00, 0x00, 0x00, 0x50, 0x44, 0x4d, 0x44, 0x63, 0x00, 0x00, 0x00, 0x53, 0x4d,
+	0x48, 0x4c, 0x67, 0x00, 0x00, 0x00, 0x49, 0x43, 0x53, 0x4c, 0x6b, 0x00, 0x00, 0x00, 0x4e, 0x4f,
+	0x4e, 0x50, 0x8b, 0x00, 0x00, 0x00, 0x4d, 0x49, 0x44, 0x53, 0x8f, 0x00, 0x00, 0x00, 0x53, 0x43,
+	0x4c, 0x53, 0x93, 0x00, 0x00, 0x00, 0x43, 0x53, 0x43, 0x54, 0x93, 0x00, 0x00, 0x00, 0x43, 0x4f,
+	0x50, 0x54, 0x97, 0x00, 0x00, 0x00, 0x49, 0x52, 0x54, 0x54, 0x9b, 0x00, 0x00, 0x00, 0x43, 0x46,
+	0x43, 0x57, 0x9f, 0x00, 0x00, 0x00, 0x53, 0x46, 0x43, 0x57, 0xa3, 0x00, 0x00, 0x00, 0x2d, 0x2d,
+	0x2d, 0x2d, 0x2d, 0x2d, 0x2d, 0x2d, 0x2d, 0x2d, 0x2d, 0x2d, 0x2d, 0x2d, 0x2d, 0x2d, 0x2d, 0x2d,
+	0x2d, 0x2d, 0x2d, 0x2d, 0x2d, 0x2d, 0x77, 0x77, 0x77, 0x2e, 0x79, 0x6f, 0x75, 0x74, 0x75, 0x62,
+	0x65, 0x2e, 0x63, 0x6f, 0x6d, 0x51, 0x30, 0x34, 0x33, 0x01, 0xe8, 0x81, 0x60, 0x92, 0x92, 0x1a,
+	0xe8, 0x7e, 0xed, 0x80, 0x86, 0xa2, 0x15, 0x82, 0x91, 0x43, 0x68, 0x72, 0x6f, 0x6d, 0x65, 0x2f,
+	0x38, 0x36, 0x2e, 0x30, 0x2e, 0x34, 0x32, 0x34, 0x30, 0x2e, 0x37, 0x35, 0x20, 0x4c, 0x69, 0x6e,
+	0x75, 0x78, 0x20, 0x78, 0x38, 0x36, 0x5f, 0x36, 0x34, 0x00, 0x00, 0x00, 0x00, 0x58, 0x35, 0x30,
+	0x39, 0x01, 0x00, 0x00, 0x00, 0x1e, 0x00, 0x00, 0x00, 0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06,
+	0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16,
+	0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f, 0x64, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00,
+	0x00, 0x4e, 0x53, 0x54, 0x50, 0xc5, 0x57, 0x00, 0x00, 0x00, 0x00, 0xf0, 0x00, 0x00, 0x00, 0x60,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+}
+
+// gquicQ046 is a Q046 client Initial for www.google.com, using the long
+// header with both connection id lengths in one byte.
+var gquicQ046 = []byte{
+	0xc3, 0x51, 0x30, 0x34, 0x36, 0x50, 0x5a, 0x2a, 0xe2, 0xc6, 0xf9, 0x5c, 0x2a, 0x02, 0x00, 0x00,
+	0x00, 0x01, 0x34, 0x1a, 0x72, 0x7a, 0x82, 0x92, 0x1a, 0x46, 0xe6, 0x45, 0x74, 0x91, 0xa0, 0x01,
+	0x01, 0x32, 0x43, 0x48, 0x4c, 0x4f, 0x11, 0x00, 0x00, 0x00, 0x50, 0x41, 0x44, 0x00, 0x18, 0x00,
+	0x00, 0x00, 0x53, 0x4e, 0x49, 0x00, 0x26, 0x00, 0x00, 0x00, 0x56, 0x45, 0x52, 0x00, 0x2a, 0x00,
+	0x00, 0x00, 0x43, 0x43, 0x53, 0x00, 0x3a, 0x00, 0x00, 0x00, 0x55, 0x41, 0x49, 0x44, 0x5a, 0x00,
+	0x00, 0x00, 0x54, 0x43, 0x49, 0x44, 0x5e, 0x00, 0x00, 0x00, 0x50, 0x44, 0x4d, 0x44, 0x62, 0x00,
+	0x00, 0x00, 0x53, 0x4d, 0x48, 0x4c, 0x66, 0x00, 0x00, 0x00, 0x49, 0x43, 0x53, 0x4c, 0x6a, 0x00,
+	0x00, 0x00, 0x4e, 0x4f, 0x4e, 0x50, 0x8a, 0x00, 0x00, 0x00, 0x4d, 0x49, 0x44, 0x53, 0x8e, 0x00,
+	0x00, 0x00, 0x53, 0x43, 0x4c, 0x53, 0x92, 0x00, 0x00, 0x00, 0x43, 0x53, 0x43, 0x54, 0x92, 0x00,
+	0x00, 0x00, 0x43, 0x4f, 0x50, 0x54, 0x96, 0x00, 0x00, 0x00, 0x49, 0x52, 0x54, 0x54, 0x9a, 0x00,
+	0x00, 0x00, 0x43, 0x46, 0x43, 0x57, 0x9e, 0x00, 0x00, 0x00, 0x53, 0x46, 0x43, 0x57, 0xa2, 0x00,
+	0x00, 0x00, 0x2d, 0x2d, 0x2d, 0x2d, 0x2d, 0x2d, 0x2d, 0x2d, 0x2d, 0x2d, 0x2d, 0x2d, 0x2d, 0x2d,
+	0x2d, 0x2d, 0x2d, 0x2d, 0x2d, 0x2d, 0x2d, 0x2d, 0x2d, 0x2d, 0x77, 0x77, 0x77, 0x2e, 0x67, 0x6f,
+	0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x63, 0x6f, 0x6d, 0x51, 0x30, 0x34, 0x36, 0x01, 0xe8, 0x81, 0x60,
+	0x92, 0x92, 0x1a, 0xe8, 0x7e, 0xed, 0x80, 0x86, 0xa2, 0x15, 0x82, 0x91, 0x43, 0x68, 0x72, 0x6f,
+	0x6d, 0x65, 0x2f, 0x38, 0x36, 0x2e, 0x30, 0x2e, 0x34, 0x32, 0x34, 0x30, 0x2e, 0x37, 0x35, 0x20,
+	0x4c, 0x69, 0x6e, 0x75, 0x78, 0x20, 0x78, 0x38, 0x36, 0x5f, 0x36, 0x34, 0x00, 0x00, 0x00, 0x00,
+	0x58, 0x35, 0x30, 0x39, 0x01, 0x00, 0x00, 0x00, 0x1e, 0x00, 0x00, 0x00, 0x00, 0x01, 0x02, 0x03,
+	0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10, 0x11, 0x12, 0x13,
+	0x14, 0x15, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f, 0x64, 0x00, 0x00, 0x00,
+	0x01, 0x00, 0x00, 0x00, 0x4e, 0x53, 0x54, 0x50, 0xc5, 0x57, 0x00, 0x00, 0x00, 0x00, 0xf0, 0x00,
+	0x00, 0x00, 0x60, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+}
+
+// gquicQ050 is a Q050 client Initial for www.googleapis.com: an IETF long
+// header, protected with the Initial keys derived from the Q050 salt, and a
+// gQUIC CRYPTO frame carrying the CHLO, followed by padding. It was encrypted
+// with a separate implementation of the key schedule.
+var gquicQ050 = []byte{
+	0xc4, 0x51, 0x30, 0x35, 0x30, 0x08, 0x7b, 0x2e, 0x91, 0x4c, 0xd8, 0x05, 0x63, 0xfa, 0x00, 0x00,
+	0x44, 0x9e, 0xc5, 0xdb, 0xd4, 0xf9, 0xf9, 0x15, 0x94, 0xd6, 0x12, 0x8c, 0x7c, 0xfa, 0x35, 0x9b,
+	0xd8, 0xe1, 0xf7, 0xf3, 0xbd, 0xbb, 0xf8, 0x7e, 0xec, 0x01, 0xf8, 0x18, 0x09, 0x0d, 0xd3, 0xe4,
+	0x7c, 0xc9, 0x4e, 0x12, 0xae, 0x78, 0x66, 0x8c, 0x6a, 0x68, 0xce, 0xf1, 0x39, 0xdb, 0x97, 0xe8,
+	0x70, 0x3a, 0x4b, 0xa6, 0x5f, 0x0c, 0x8e, 0x07, 0xb9, 0x59, 0x3a, 0x9d, 0xd4, 0x3d, 0x34, 0xea,
+	0x89, 0x51, 0xec, 0xc5, 0x5c, 0xda, 0xc1, 0x5f, 0x76, 0x5e, 0x81, 0x69, 0x7d, 0xe5, 0x24, 0xc3,
+	0x8f, 0xa2, 0x0a, 0x6d, 0xb9, 0x59, 0x6f, 0x10, 0x33, 0xff, 0x4c, 0x4c, 0xf7, 0x9c, 0xab, 0x21,
+	0x97, 0x6b, 0xc3, 0x6e, 0x64, 0x7f, 0x9b, 0x28, 0x7f, 0xf8, 0xa0, 0x67, 0x4d, 0x9e, 0xa8, 0x39,
+	0xe0, 0x2c, 0xb1, 0xd4, 0xe3, 0x59, 0x20, 0xd3, 0x68, 0x10, 0x3e, 0xcf, 0xfb, 0xf7, 0x65, 0x0c,
+	0xfb, 0x80, 0x53, 0xdb, 0xb3, 0xce, 0x87, 0x33, 0x23, 0x9c, 0xef, 0x7c, 0x58, 0xad, 0xa7, 0x04,
+	0xc1, 0xe5, 0xc7, 0x57, 0x11, 0x89, 0xcc, 0x2d, 0xe2, 0xf4, 0xa2, 0xa6, 0x2b, 0x48, 0x45, 0xe8,
+	0x40, 0x9d, 0x35, 0xac, 0x9c, 0xe4, 0x2b, 0xcd, 0x95, 0x2b, 0x24, 0x25, 0x1c, 0x3f, 0x23, 0x6f,
+	0x01, 0x80, 0x29, 0xbf, 0xdd, 0xac, 0x20, 0x13, 0x04, 0x03, 0xce, 0x5d, 0x1b, 0x7f, 0x42, 0x62,
+	0x89, 0x2e, 0x12, 0x0f, 0xd7, 0xe5, 0xe0, 0xa4, 0xb5, 0x42, 0xf3, 0xf3, 0xf3, 0x79, 0xaa, 0xae,
+	0x65, 0x62, 0xda, 0xbe, 0xe7, 0x5a, 0xbe, 0xac, 0x46, 0x1b, 0x4f, 0x92, 0x68, 0x42, 0xe5, 0x5c,
+	0xef, 0x35, 0x53, 0x19, 0x70, 0x90, 0x5b, 0xe2, 0xcd, 0x72, 0x9f, 0x9a, 0x70, 0x8a, 0xf0, 0x64,
+	0x33, 0xde, 0x08, 0x9c, 0xb0, 0xbd, 0xe0, 0xfe, 0xcb, 0x12, 0x42, 0x26, 0x74, 0x75, 0x60, 0x6c,
+	0x59, 0x2c, 0x32, 0xcd, 0x39, 0x2f, 0x08, 0x94, 0x61, 0x67, 0x25, 0x3d, 0xea, 0x27, 0x7e, 0x7a,
+	0x77, 0x84, 0x71, 0xd1, 0x62, 0xd9, 0xa5, 0xf3, 0x49, 0x58, 0xf4, 0xf8, 0x10, 0x67, 0x64, 0x5c,
+	0x82, 0xfb, 0xb7, 0xd8, 0xa6, 0xce, 0x84, 0x90, 0xe3, 0x61, 0x04, 0xad, 0x56, 0x41, 0x3a, 0x30,
+	0xa3, 0x9f, 0xa5, 0x58, 0xe5, 0x98, 0x71, 0x8b, 0xda, 0x85, 0xd3, 0xfd, 0x0b, 0x8d, 0xca, 0x47,
+	0xa1, 0x05, 0x05, 0x94, 0xb2, 0x71, 0xef, 0x35, 0xbe, 0x5d, 0x4b, 0xfa, 0xce, 0x30, 0x8c, 0x6a,
+	0xdc, 0xb0, 0x94, 0x96, 0x25, 0x5a, 0xf6, 0xcb, 0x03, 0xc2, 0x16, 0x30, 0x34, 0xaa, 0xef, 0x26,
+	0x52, 0x81, 0x43, 0x2b, 0x94, 0x7e, 0x83, 0xe5, 0xc3, 0x73, 0x27, 0x27, 0xe5, 0xed, 0x79, 0xd3,
+	0xb1, 0x43, 0x9a, 0x3c, 0x22, 0xc8, 0x58, 0x26, 0x3b, 0xe1, 0xe4, 0x5d, 0x1f, 0x8d, 0xe0, 0x66,
+	0x00, 0x4c, 0x50, 0xf4, 0x04, 0x91, 0x49, 0x68, 0xe6, 0x97, 0x34, 0x5d, 0x23, 0x1e, 0x04, 0xe8,
+	0x82, 0xa9, 0xd9, 0x35, 0x7c, 0xf9, 0xf0, 0x17, 0x75, 0x8f, 0xae, 0x3b, 0x3d, 0x4d, 0xfe, 0x23,
+	0x58, 0xfa, 0x59, 0x57, 0x8e, 0x8d, 0x98, 0x84, 0xcd, 0x6b, 0x5e, 0x28, 0xe5, 0x14, 0x49, 0xce,
+	0x03, 0xe2, 0xfe, 0xe0, 0x4b, 0x97, 0xfc, 0x23, 0xc1, 0x77, 0x65, 0x72, 0x7d, 0xda, 0x17, 0xfc,
+	0x18, 0x28, 0x93, 0xbc, 0x2c, 0x12, 0x17, 0x63, 0xf3, 0xfa, 0xb4, 0x7e, 0xb1, 0x84, 0x41, 0x95,
+	0xce, 0xcc, 0x9b, 0xb0, 0x98, 0x7c, 0x81, 0xe2, 0xe8, 0xe9, 0x08, 0xaf, 0x4d, 0x00, 0x0b, 0x8a,
+	0xf6, 0xbf, 0x45, 0xe1, 0x51, 0x81, 0xa7, 0xf1, 0xfa, 0x12, 0x69, 0x1e, 0xd6, 0x3f, 0xd0, 0x9c,
+	0x3f, 0x14, 0x1f, 0xa5, 0x05, 0xba, 0x04, 0x44, 0x70, 0xbb, 0x21, 0x0a, 0x13, 0x1e, 0xbe, 0xc5,
+	0xcb, 0xed, 0x34, 0x51, 0xa9, 0xb9, 0x2c, 0xca, 0x39, 0x83, 0x6a, 0xc0, 0x9c, 0x69, 0x92, 0xc3,
+	0x33, 0xf2, 0xe6, 0xe9, 0x9b, 0x58, 0x58, 0x3f, 0x41, 0xf8, 0xca, 0x0c, 0xb8, 0x57, 0x9a, 0x4a,
+	0xd5, 0x68, 0x5a, 0x8e, 0x8c, 0x77, 0x69, 0xa4, 0xbc, 0x6e, 0x26, 0x4b, 0xba, 0xda, 0xe1, 0x77,
+	0xda, 0xa1, 0x86, 0xfd, 0x99, 0xce, 0x54, 0x1d, 0x09, 0x7a, 0xa7, 0xca, 0x17, 0x8c, 0xa5, 0xd4,
+	0x58, 0xa4, 0x4b, 0x74, 0x1d, 0xc7, 0x26, 0x1b, 0xea, 0x6d, 0xf0, 0x25, 0xc2, 0xbe, 0x97, 0xff,
+	0xf2, 0xe0, 0xa1, 0xfa, 0xb9, 0xc8, 0x0a, 0x2f, 0xc8, 0x23, 0x67, 0xf7, 0xed, 0x1f, 0xdb, 0xc0,
+	0xf4, 0x0c, 0xe6, 0x42, 0x3f, 0x35, 0xc8, 0xa5, 0x71, 0x5f, 0x5b, 0x0e, 0xcd, 0xbd, 0xf6, 0x9d,
+	0x51, 0x45, 0x14, 0xa1, 0xc5, 0x64, 0xf7, 0xa2, 0x7c, 0x79, 0xd9, 0x94, 0x93, 0x8c, 0x81, 0x38,
+	0x8b, 0xcb, 0xc5, 0x12, 0x3e, 0x39, 0x5e, 0x4f, 0x4d, 0xe5, 0xe9, 0xae, 0x88, 0xb2, 0x25, 0x21,
+	0xcd, 0xcb, 0x68, 0xa5, 0xa7, 0x8b, 0xb5, 0x8e, 0x9f, 0xfa, 0x64, 0x23, 0x76, 0xd2, 0xe0, 0x1f,
+	0xe8, 0x5a, 0x45, 0x25, 0x3f, 0x2d, 0x89, 0x39, 0x01, 0x69, 0xdc, 0xbd, 0x75, 0x66, 0x62, 0x2b,
+	0x05, 0xab, 0xb1, 0x74, 0x75, 0x1f, 0x2d, 0x1e, 0x43, 0xd8, 0x40, 0x1e, 0x5c, 0x7b, 0x02, 0xf8,
+	0x28, 0x11, 0x36, 0x56, 0x18, 0x8b, 0x40, 0x88, 0x23, 0xc2, 0x78, 0xd4, 0x14, 0xa5, 0xf4, 0x1c,
+	0xaa, 0xdc, 0xe9, 0xd2, 0x29, 0x2d, 0x96, 0x77, 0xe8, 0xb9, 0x51, 0x2a, 0xf1, 0x4c, 0x4f, 0x16,
+	0x21, 0xe3, 0x1f, 0xc5, 0xfd, 0xe8, 0x8f, 0x77, 0xc6, 0xc8, 0x81, 0xd5, 0xd3, 0x12, 0x9d, 0x06,
+	0xbc, 0x24, 0x76, 0x52, 0x78, 0x47, 0x6f, 0xa4, 0x29, 0x3b, 0x6f, 0xd7, 0xf4, 0x6d, 0x32, 0x2c,
+	0x5c, 0x86, 0x9b, 0x00, 0x9a, 0x36, 0xda, 0xc9, 0x06, 0x48, 0x39, 0x8d, 0x30, 0xe2, 0xd9, 0xab,
+	0x62, 0xef, 0x8e, 0x13, 0x46, 0xa5, 0x1a, 0x57, 0x43, 0x6f, 0x08, 0xee, 0x87, 0xba, 0xec, 0xe3,
+	0x27, 0xec, 0x90, 0x6a, 0xd0, 0x9a, 0x6c, 0x5f, 0x8c, 0x61, 0xfb, 0x22, 0x37, 0xc5, 0x6b, 0xe6,
+	0xbf, 0x67, 0x20, 0xe8, 0x19, 0xd6, 0xf6, 0xf7, 0x12, 0x59, 0x49, 0xa7, 0xbf, 0x98, 0x64, 0xdc,
+	0x82, 0x79, 0xb9, 0x11, 0xce, 0x0c, 0xe5, 0x4c, 0x37, 0x70, 0x69, 0x7e, 0xd1, 0x44, 0x5b, 0xc9,
+	0x4f, 0xa4, 0x7c, 0x23, 0x0f, 0xce, 0x76, 0x32, 0x55, 0xd7, 0xcf, 0x77, 0x5f, 0xba, 0x73, 0x1e,
+	0xde, 0xac, 0x8e, 0x0c, 0xa6, 0x18, 0x7c, 0x73, 0xba, 0xa2, 0x10, 0x97, 0xb9, 0x6a, 0x9e, 0x21,
+	0x5b, 0x7f, 0x79, 0xb7, 0x92, 0x36, 0xf4, 0x91, 0xe9, 0xaf, 0x49, 0x3a, 0x65, 0x84, 0xb7, 0x6c,
+	0x0e, 0xa0, 0x81, 0x9d, 0xfb, 0xd7, 0xcc, 0x07, 0xe8, 0x85, 0x1f, 0xb4, 0xda, 0x5d, 0x50, 0x7a,
+	0xf8, 0x1e, 0x90, 0x2b, 0xcc, 0x8a, 0x02, 0x97, 0x2e, 0x2f, 0x0b, 0x4c, 0x6a, 0x94, 0x72, 0x4a,
+	0xef, 0xd4, 0x82, 0xdf, 0xb6, 0x11, 0x66, 0xff, 0xdb, 0x8f, 0x3c, 0x32, 0x75, 0xd8, 0xdf, 0x35,
+	0x55, 0x9a, 0x86, 0x62, 0x46, 0x8d, 0x1c, 0x4e, 0x67, 0xe3, 0x97, 0x2d, 0x06, 0xb8, 0xac, 0x4e,
+	0xa9, 0xd8, 0xd7, 0x92, 0xe3, 0x5b, 0xba, 0x74, 0x13, 0xdc, 0x76, 0xf3, 0x7a, 0xe8, 0xef, 0x80,
+	0xf7, 0x7a, 0x03, 0x84, 0xe2, 0xde, 0x25, 0x40, 0xbd, 0x09, 0x2e, 0xfa, 0xcb, 0x3d, 0x20, 0xcf,
+	0x67, 0xcf, 0xfb, 0x5a, 0x22, 0xa7, 0x3e, 0x72, 0x84, 0xe9, 0xde, 0x44, 0x9b, 0x3d, 0xdf, 0x93,
+	0xc5, 0x5a, 0x04, 0x7f, 0xfa, 0xc2, 0x5b, 0x87, 0x17, 0x2a, 0x10, 0x43, 0x6a, 0x59, 0x51, 0x0a,
+	0x3c, 0xb6, 0x47, 0xd5, 0x40, 0xe8, 0xca, 0x67, 0xd2, 0x00, 0x01, 0xca, 0x42, 0x68, 0xe9, 0xf2,
+	0xbe, 0x41, 0xbc, 0x7b, 0x71, 0x6f, 0x0d, 0x38, 0x4c, 0xee, 0xd0, 0xf8, 0xc8, 0xb0, 0x2c, 0x37,
+	0x63, 0x1c, 0x31, 0x12, 0x8e, 0x72, 0x4c, 0xd1, 0xe6, 0x78, 0x1d, 0xc8, 0x86, 0xb6, 0xce, 0x35,
+	0x91, 0xb3, 0x80, 0xe4, 0x69, 0xfb, 0x83, 0xfd, 0xf5, 0xc7, 0x38, 0x6d, 0x7d, 0x48, 0xdb, 0x20,
+	0xc2, 0xd5, 0x4f, 0x2e, 0xca, 0xb6, 0x7f, 0xb8, 0x59, 0xd7, 0x12, 0x96, 0x2c, 0xc4, 0xb2, 0x1b,
+	0xfc, 0xae, 0xb6, 0x5e, 0xcb, 0xa1, 0xc7, 0xcc, 0xf6, 0x23, 0x47, 0xb3, 0x45, 0xbc, 0xef, 0x4b,
+	0x5c, 0x86, 0x6c, 0x80, 0xf0, 0xfb, 0xdb, 0x5d, 0x39, 0x9a, 0x97, 0xdb, 0xb3, 0x0a, 0xdf, 0x16,
+	0xf0, 0xd8, 0x10, 0x29, 0x0a, 0x51, 0xd6, 0x19, 0x82, 0x00, 0x86, 0x16, 0xa1, 0xe3, 0xaa, 0x04,
+	0x51, 0xf1, 0x60, 0x8c, 0x55, 0x99, 0xd1, 0x17, 0x75, 0x80, 0x85, 0x22, 0xb1, 0xc7, 0xbe, 0x48,
+	0x9d, 0x55, 0xbb, 0x06, 0x12, 0xd4, 0xfd, 0xa8, 0xf5, 0xc7, 0x81, 0x4f, 0x37, 0xbe, 0xc3, 0x84,
+}
@@ -2,32 +2,85 @@ package quic
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 )
 
-func ParseHeader(b *bytes.Reader) (*Header, error) {
-	startLen := b.Len()
+// PacketType is the type of a long header packet.
+type PacketType uint8
+
+const (
+	PacketInitial PacketType = iota
+	Packet0RTT
+	PacketHandshake
+	PacketRetry
+	// PacketVersionNegotiation is sent by a server that does not support
+	// the version of the client. It has version 0 instead of a type.
+	PacketVersionNegotiation
+)
+
+func (t PacketType) String() string {
+	switch t {
+	case PacketInitial:
+		return "Initial"
+	case Packet0RTT:
+		return "0-RTT"
+	case PacketHandshake:
+		return "Handshake"
+	case PacketRetry:
+		return "Retry"
+	case PacketVersionNegotiation:
+		return "Version Negotiation"
+	}
+	return fmt.Sprintf("PacketType(%d)", uint8(t))
+}
+
+// retryIntegrityTagLength is the length of the tag that ends a Retry packet
+const retryIntegrityTagLength = 16
+
+// ParseHeader parses the long header at the start of data. It returns
+// UnmarshalNoQUICInitialError for short header packets and
+// UnmarshalQUICUnsupportedVersion, together with the header up to the
+// connection ids, for versions that are not supported. The connection ids,
+// the token and the integrity tag refer to data.
+func ParseHeader(data []byte) (*Header, error) {
+	h := &Header{}
+	if err := h.parse(data); err != nil {
+		if !h.IsLongHeader {
+			return nil, err
+		}
+		return h, err
+	}
+	return h, nil
+}
+
+// parse is ParseHeader, parsing into h. IsLongHeader is false when there is
+// no long header.
+func (h *Header) parse(data []byte) error {
+	b := bytes.NewReader(data)
 	typeByte, err := b.ReadByte()
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	h := &Header{
+	*h = Header{
 		TypeByte:     typeByte,
 		IsLongHeader: typeByte&0x80 > 0,
 	}
 	if !h.IsLongHeader {
-		return nil, UnmarshalQUICError
+		// Short header packets are only sent after the handshake
+		return UnmarshalNoQUICInitialError
 	}
 
-	err = h.parseLongHeader(b)
-	h.ParsedLen = int64(startLen - b.Len())
-	return h, err
+	err = h.parseLongHeader(b, data)
+	h.ParsedLen = int64(len(data) - b.Len())
+	return err
 }
 
 type Header struct {
 	TypeByte     byte
 	IsLongHeader bool
+	Type         PacketType
 
 	ParsedLen int64
 
@@ -36,28 +89,17 @@ type Header struct {
 	SrcConnectionID  []byte
 
 	Length int64
-	Token  []byte
+	// Token is the token of an Initial packet, or the token a Retry packet
+	// asks the client to send in its next Initial.
+	Token []byte
+	// Versions lists the versions supported by the server in a Version
+	// Negotiation packet.
+	Versions []uint32
+	// RetryIntegrityTag is the tag at the end of a Retry packet.
+	RetryIntegrityTag []byte
 }
 
-// ParseExtended parses the version dependent part of the header.
-// The Reader has to be set such that it points to the first byte of the header.
-func (h *Header) ParseExtended(b *bytes.Reader, ver uint32) (*ExtendedHeader, error) {
-	extHdr := h.toExtendedHeader()
-	reservedBitsValid, err := extHdr.Parse(b, ver)
-	if err != nil {
-		return nil, err
-	}
-	if !reservedBitsValid {
-		return extHdr, UnmarshalQUICBitsError
-	}
-	return extHdr, nil
-}
-
-func (h *Header) toExtendedHeader() *ExtendedHeader {
-	return &ExtendedHeader{Header: *h}
-}
-
-func (h *Header) parseLongHeader(b *bytes.Reader) (err error) {
+func (h *Header) parseLongHeader(b *bytes.Reader, data []byte) (err error) {
 	h.Version, err = ReadUint32(b)
 	if err != nil {
 		return err
@@ -65,50 +107,92 @@ func (h *Header) parseLongHeader(b *bytes.Reader) (err error) {
 	if h.Version != 0 && h.TypeByte&0x40 == 0 {
 		return UnmarshalNoQUICError
 	}
-	if h.Version == 0 { // version negotiation packet
-		return UnmarshalNoQUICInitialError
-	}
 
 	destConnIDLen, err := b.ReadByte()
 	if err != nil {
 		return err
 	}
-	if h.DestConnectionID, err = ReadConnectionID(b, int(destConnIDLen)); err != nil {
+	if h.DestConnectionID, err = readConnectionID(b, data, int(destConnIDLen)); err != nil {
 		return err
 	}
 	srcConnIDLen, err := b.ReadByte()
 	if err != nil {
 		return err
 	}
-	if h.SrcConnectionID, err = ReadConnectionID(b, int(srcConnIDLen)); err != nil {
+	if h.SrcConnectionID, err = readConnectionID(b, data, int(srcConnIDLen)); err != nil {
 		return err
 	}
 
+	if h.Version == 0 {
+		return h.parseVersionNegotiation(b)
+	}
 	// If we don't understand the version, we have no idea how to interpret the rest of the bytes
 	if !IsSupportedVersion(SupportedVersions, h.Version) {
 		return UnmarshalQUICUnsupportedVersion
 	}
-	if (h.TypeByte&0x30)>>4 != 0x0 {
-		// not an initial package
-		return UnmarshalNoQUICInitialError
+
+	h.Type = PacketType((h.TypeByte & 0x30) >> 4)
+	switch h.Type {
+	case PacketRetry:
+		return h.parseRetry(b, data)
+	case PacketInitial:
+		tokenLen, err := ReadQuickVarInt(b)
+		if err != nil {
+			return err
+		}
+		if tokenLen > uint64(b.Len()) {
+			return io.EOF
+		}
+		if h.Token, err = readSlice(b, data, int(tokenLen)); err != nil {
+			return err
+		}
 	}
 
-	tokenLen, err := ReadQuickVarInt(b)
+	pl, err := ReadQuickVarInt(b)
 	if err != nil {
 		return err
 	}
-	if tokenLen > uint64(b.Len()) {
-		return io.EOF
+	h.Length = int64(pl)
+	return nil
+}
+
+// parseVersionNegotiation reads the versions that follow the connection ids
+// of a Version Negotiation packet.
+func (h *Header) parseVersionNegotiation(b *bytes.Reader) error {
+	h.Type = PacketVersionNegotiation
+	if b.Len() == 0 || b.Len()%4 != 0 {
+		return UnmarshalQUICError
+	}
+	h.Versions = make([]uint32, 0, b.Len()/4)
+	for b.Len() > 0 {
+		v, err := ReadUint32(b)
+		if err != nil {
+			return err
+		}
+		h.Versions = append(h.Versions, v)
 	}
-	h.Token = make([]byte, tokenLen)
-	if _, err := io.ReadFull(b, h.Token); err != nil {
+	return nil
+}
+
+// parseRetry reads the token and the integrity tag of a Retry packet, which
+// fill the rest of the datagram.
+func (h *Header) parseRetry(b *bytes.Reader, data []byte) error {
+	if b.Len() < retryIntegrityTagLength {
+		return UnmarshalQUICError
+	}
+	var err error
+	if h.Token, err = readSlice(b, data, b.Len()-retryIntegrityTagLength); err != nil {
 		return err
 	}
+	h.RetryIntegrityTag, err = readSlice(b, data, retryIntegrityTagLength)
+	return err
+}
 
-	pl, err := ReadQuickVarInt(b)
-	if err != nil {
-		return err
+// readConnectionID is like ReadConnectionID, but returns the connection id
+// in data instead of a copy.
+func readConnectionID(b *bytes.Reader, data []byte, length int) ([]byte, error) {
+	if length == 0 {
+		return nil, nil
 	}
-	h.Length = int64(pl)
-	return nil
+	return readSlice(b, data, length)
 }
@@ -10,13 +10,13 @@ import (
 func TestParseHeader(t *testing.T) {
 	tests := []struct {
 		name    string
-		b       *bytes.Reader
+		payload []byte
 		want    *Header
 		wantErr bool
 	}{
 		{
 			name: "Draft27 Facebook",
-			b: bytes.NewReader([]byte{
+			payload: []byte{
 				0xc6, 0xfa, 0xce, 0xb0, 0x02, 0x08, 0x4a, 0xaf, 0x2d, 0x9e, 0xdd, 0xac, 0x80, 0x59, 0x00, 0x00,
 				0x44, 0xbe, 0x9d, 0x71, 0x8b, 0xa7, 0x87, 0xb4, 0x29, 0x41, 0x49, 0x5b, 0x61, 0x9d, 0xef, 0xec,
 				0x2f, 0x8b, 0x23, 0x8e, 0x2b, 0xba, 0x22, 0x1c, 0x43, 0x24, 0x61, 0xaf, 0x1f, 0x3a, 0x0f, 0x47,
@@ -94,7 +94,7 @@ func TestParseHeader(t *testing.T) {
 				0x95, 0x24, 0x2a, 0x8c, 0x13, 0xfb, 0x0f, 0xd3, 0x4c, 0xf7, 0xad, 0x0a, 0x40, 0xee, 0x0c, 0x85,
 				0xb2, 0x0f, 0x92, 0x20, 0xdf, 0xd8, 0xa9, 0x89, 0xff, 0xb8, 0x52, 0x05, 0x09, 0x2b, 0x45, 0xf5,
 				0x31, 0x98, 0xfc, 0x7e, 0x91, 0xab, 0xeb, 0x29, 0xf6, 0xac, 0xcd, 0x6b, 0x79, 0x06, 0x07, 0xd3,
-			}),
+			},
 			want: &Header{
 				TypeByte:         198,
 				IsLongHeader:     true,
@@ -108,7 +108,7 @@ func TestParseHeader(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := ParseHeader(tt.b)
+			got, err := ParseHeader(tt.payload)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ParseHeader() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -122,3 +122,134 @@ func TestParseHeader(t *testing.T) {
 		})
 	}
 }
+
+func TestParseHeader_types(t *testing.T) {
+	connIDs := []byte{0x04, 0x01, 0x02, 0x03, 0x04, 0x02, 0x05, 0x06}
+	long := func(typeByte byte, version uint32, rest ...byte) []byte {
+		b := []byte{typeByte, byte(version >> 24), byte(version >> 16), byte(version >> 8), byte(version)}
+		b = append(b, connIDs...)
+		return append(b, rest...)
+	}
+	tag := bytes.Repeat([]byte{0xee}, retryIntegrityTagLength)
+
+	tests := []struct {
+		name    string
+		payload []byte
+		want    *Header
+		wantErr error
+	}{
+		{
+			name:    "Version Negotiation",
+			payload: long(0x80, 0, 0x00, 0x00, 0x00, 0x01, 0x1a, 0x2a, 0x3a, 0x4a),
+			want: &Header{
+				TypeByte:         0x80,
+				IsLongHeader:     true,
+				Type:             PacketVersionNegotiation,
+				ParsedLen:        21,
+				DestConnectionID: []byte{0x01, 0x02, 0x03, 0x04},
+				SrcConnectionID:  []byte{0x05, 0x06},
+				Versions:         []uint32{Version1, 0x1a2a3a4a},
+			},
+		},
+		{
+			name:    "Version Negotiation without versions",
+			payload: long(0x80, 0, 0x00, 0x00),
+			wantErr: UnmarshalQUICError,
+		},
+		{
+			name:    "Retry",
+			payload: long(0xf0, Version1, append([]byte("token"), tag...)...),
+			want: &Header{
+				TypeByte:          0xf0,
+				IsLongHeader:      true,
+				Type:              PacketRetry,
+				ParsedLen:         34,
+				Version:           Version1,
+				DestConnectionID:  []byte{0x01, 0x02, 0x03, 0x04},
+				SrcConnectionID:   []byte{0x05, 0x06},
+				Token:             []byte("token"),
+				RetryIntegrityTag: tag,
+			},
+		},
+		{
+			name:    "Retry without tag",
+			payload: long(0xf0, Version1, 0x01, 0x02),
+			wantErr: UnmarshalQUICError,
+		},
+		{
+			name:    "0-RTT",
+			payload: long(0xd1, Version1, 0x40, 0x20),
+			want: &Header{
+				TypeByte:         0xd1,
+				IsLongHeader:     true,
+				Type:             Packet0RTT,
+				ParsedLen:        15,
+				Version:          Version1,
+				DestConnectionID: []byte{0x01, 0x02, 0x03, 0x04},
+				SrcConnectionID:  []byte{0x05, 0x06},
+				Length:           32,
+			},
+		},
+		{
+			name:    "Handshake",
+			payload: long(0xe2, VersionDraft29, 0x20),
+			want: &Header{
+				TypeByte:         0xe2,
+				IsLongHeader:     true,
+				Type:             PacketHandshake,
+				ParsedLen:        14,
+				Version:          VersionDraft29,
+				DestConnectionID: []byte{0x01, 0x02, 0x03, 0x04},
+				SrcConnectionID:  []byte{0x05, 0x06},
+				Length:           32,
+			},
+		},
+		{
+			name:    "Greased version",
+			payload: long(0xc0, 0x3a4a5a6a, 0x00, 0x20),
+			want: &Header{
+				TypeByte:         0xc0,
+				IsLongHeader:     true,
+				ParsedLen:        13,
+				Version:          0x3a4a5a6a,
+				DestConnectionID: []byte{0x01, 0x02, 0x03, 0x04},
+				SrcConnectionID:  []byte{0x05, 0x06},
+			},
+			wantErr: UnmarshalQUICUnsupportedVersion,
+		},
+		{
+			name:    "Short header",
+			payload: []byte{0x40, 0x01, 0x02, 0x03},
+			wantErr: UnmarshalNoQUICInitialError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseHeader(tt.payload)
+			if err != tt.wantErr {
+				t.Fatalf("ParseHeader() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.want == nil {
+				return
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Fatalf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestIsGreasedVersion(t *testing.T) {
+	for v, want := range map[uint32]bool{
+		0x0a0a0a0a:     true,
+		0x1a2a3a4a:     true,
+		0xfafafafa:     true,
+		Version1:       false,
+		VersionDraft29: false,
+		0x0a0a0a0b:     false,
+	} {
+		if got := IsGreasedVersion(v); got != want {
+			t.Errorf("IsGreasedVersion(%#x) = %v, want %v", v, got, want)
+		}
+	}
+}
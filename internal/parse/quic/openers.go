@@ -0,0 +1,70 @@
+package quic
+
+import (
+	"container/list"
+)
+
+// maxConnectionIDLength is the longest connection id of QUIC version 1 and 2.
+// Initials with a longer destination connection id are not cached.
+const maxConnectionIDLength = 20
+
+type openerKey struct {
+	version uint32
+	length  int
+	connID  [maxConnectionIDLength]byte
+}
+
+type openerEntry struct {
+	key    openerKey
+	opener *LongHeaderOpener
+}
+
+// Openers is a least recently used cache of Initial openers, keyed by version
+// and destination connection id. A client sends all its Initials with the
+// same destination connection id, retransmissions included, so the keys only
+// have to be derived once per connection. Openers is not safe for concurrent
+// use, and neither are the openers it returns.
+type Openers struct {
+	size    int
+	order   *list.List
+	entries map[openerKey]*list.Element
+}
+
+// NewOpeners returns an Openers that holds at most size openers.
+func NewOpeners(size int) *Openers {
+	return &Openers{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[openerKey]*list.Element, size),
+	}
+}
+
+// Get returns the Initial opener for the destination connection id and
+// version, deriving it when it is not cached. A nil Openers derives a new
+// opener every time.
+func (o *Openers) Get(connID []byte, v uint32) *LongHeaderOpener {
+	if o == nil || o.size <= 0 || len(connID) > maxConnectionIDLength {
+		return NewInitialAEAD(connID, v)
+	}
+
+	key := openerKey{version: v, length: len(connID)}
+	copy(key.connID[:], connID)
+	if e, ok := o.entries[key]; ok {
+		o.order.MoveToFront(e)
+		return e.Value.(*openerEntry).opener
+	}
+
+	opener := NewInitialAEAD(connID, v)
+	if o.order.Len() >= o.size {
+		oldest := o.order.Back()
+		o.order.Remove(oldest)
+		delete(o.entries, oldest.Value.(*openerEntry).key)
+	}
+	o.entries[key] = o.order.PushFront(&openerEntry{key: key, opener: opener})
+	return opener
+}
+
+// Len returns the number of cached openers.
+func (o *Openers) Len() int {
+	return o.order.Len()
+}
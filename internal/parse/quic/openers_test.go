@@ -0,0 +1,126 @@
+package quic
+
+import (
+	"os"
+	"testing"
+
+	"github.com/jsimonetti/sniqueue/internal/parse/tls"
+)
+
+func TestOpeners_get(t *testing.T) {
+	openers := NewOpeners(2)
+	a := openers.Get([]byte{1, 2, 3, 4, 5, 6, 7, 8}, Version1)
+	if got := openers.Get([]byte{1, 2, 3, 4, 5, 6, 7, 8}, Version1); got != a {
+		t.Fatalf("expected the cached opener for the same connection id")
+	}
+	if got := openers.Get([]byte{1, 2, 3, 4, 5, 6, 7, 8}, VersionDraft29); got == a {
+		t.Fatalf("expected a new opener for another version")
+	}
+	if got := openers.Get([]byte{1, 2, 3, 4, 5, 6, 7}, Version1); got == a {
+		t.Fatalf("expected a new opener for a shorter connection id")
+	}
+	if openers.Len() != 2 {
+		t.Fatalf("expected 2 cached openers, got %d", openers.Len())
+	}
+	// The version 1 opener was the least recently used and was evicted
+	if got := openers.Get([]byte{1, 2, 3, 4, 5, 6, 7, 8}, Version1); got == a {
+		t.Fatalf("expected the least recently used opener to be evicted")
+	}
+
+	long := make([]byte, maxConnectionIDLength+1)
+	if openers.Get(long, Version1) == openers.Get(long, Version1) {
+		t.Fatalf("expected connection ids longer than %d bytes not to be cached", maxConnectionIDLength)
+	}
+
+	var none *Openers
+	if none.Get(long, Version1) == nil {
+		t.Fatalf("expected a nil Openers to derive an opener")
+	}
+}
+
+func TestQuic_unmarshalWithOpeners(t *testing.T) {
+	openers := NewOpeners(8)
+	// A retransmitted Initial is opened with the cached keys
+	for i := 0; i < 3; i++ {
+		got := &Quic{}
+		if err := got.UnmarshalWithOpeners(append([]byte(nil), initialV1...), tls.Options{}, openers); err != nil {
+			t.Fatalf("packet %d: unexpected error: %v", i, err)
+		}
+		if got.Hello.SNI != "r2---sn-fxc25nn-nwje.googlevideo.com" {
+			t.Fatalf("packet %d: unexpected SNI %q", i, got.Hello.SNI)
+		}
+	}
+	if openers.Len() != 1 {
+		t.Fatalf("expected 1 cached opener, got %d", openers.Len())
+	}
+}
+
+func TestQuic_unmarshalSplitHello(t *testing.T) {
+	openers := NewOpeners(8)
+	initials := splitInitials(t)
+
+	first := &Quic{}
+	if err := first.UnmarshalWithOpeners(initials[0], tls.Options{}, openers); err != nil {
+		t.Fatalf("first Initial: unexpected error: %v", err)
+	}
+	if first.Hello.SNI != "www.example.com" || !first.Hello.Partial {
+		t.Fatalf("first Initial: unexpected SNI %q, partial %v", first.Hello.SNI, first.Hello.Partial)
+	}
+	// The second Initial only continues the hello and is opened with the
+	// keys of the first
+	second := &Quic{}
+	if err := second.UnmarshalWithOpeners(initials[1], tls.Options{}, openers); err != UnmarshalQUICContinuationError {
+		t.Fatalf("second Initial: error = %v, want %v", err, UnmarshalQUICContinuationError)
+	}
+	if openers.Len() != 1 {
+		t.Fatalf("expected 1 cached opener, got %d", openers.Len())
+	}
+}
+
+func BenchmarkQuic_unmarshal(b *testing.B) {
+	benchmarkInitials(b, nil)
+}
+
+func BenchmarkQuic_unmarshalWithOpeners(b *testing.B) {
+	benchmarkInitials(b, NewOpeners(64))
+}
+
+// splitInitials reads the two Initials of a hello that does not fit one
+// packet: the ClientHello of the crypto/tls QUIC client offering
+// X25519MLKEM768, sent the way browsers do with the same destination
+// connection id. The packets were synthesized, the hello is cut after 1100
+// bytes and the second Initial only carries the rest of it.
+func splitInitials(tb testing.TB) [][]byte {
+	var initials [][]byte
+	for _, name := range []string{"testdata/quic-initial-1", "testdata/quic-initial-2"} {
+		initial, err := os.ReadFile(name)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		initials = append(initials, initial)
+	}
+	return initials
+}
+
+// benchmarkInitials opens the Initials of a connection that sends its hello
+// in two packets, both with the same destination connection id.
+func benchmarkInitials(b *testing.B, openers *Openers) {
+	initials := splitInitials(b)
+	packet := make([]byte, len(initials[0]))
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		copy(packet, initials[0])
+		got := &Quic{}
+		if err := got.UnmarshalWithOpeners(packet, tls.Options{}, openers); err != nil {
+			b.Fatal(err)
+		}
+		if got.Hello.SNI != "www.example.com" {
+			b.Fatalf("unexpected SNI %q", got.Hello.SNI)
+		}
+		copy(packet, initials[1])
+		got = &Quic{}
+		if err := got.UnmarshalWithOpeners(packet, tls.Options{}, openers); err != UnmarshalQUICContinuationError {
+			b.Fatal(err)
+		}
+	}
+}
@@ -1,7 +1,7 @@
 package quic
 
 import (
-	"bytes"
+	"encoding/binary"
 
 	"github.com/jsimonetti/sniqueue/internal/parse/tls"
 )
@@ -9,21 +9,63 @@ import (
 type Quic struct {
 	Header *ExtendedHeader
 	Hello  tls.ClientHello
+	// CHLO is set for Google QUIC packets, Hello then only holds the SNI.
+	// Header is nil for Q043 and Q046, which have their own header format.
+	CHLO *CHLO
+	// ZeroRTT is set when the datagram carries a 0-RTT packet, on its own
+	// or coalesced after the Initial.
+	ZeroRTT bool
+
+	// header is where Header points, so parsing it does not allocate
+	header ExtendedHeader
 }
 
+// Unmarshal decrypts an Initial packet and decodes the full ClientHello.
 func (p *Quic) Unmarshal(payload []byte) error {
-	hdr, err := ParseHeader(bytes.NewReader(payload))
+	return p.UnmarshalWithOptions(payload, tls.Options{})
+}
+
+// UnmarshalWithOptions decrypts an Initial packet and decodes the ClientHello
+// as specified by opts.
+func (p *Quic) UnmarshalWithOptions(payload []byte, opts tls.Options) error {
+	return p.UnmarshalWithOpeners(payload, opts, nil)
+}
+
+// UnmarshalWithOpeners is like UnmarshalWithOptions, but takes the Initial
+// opener from openers so its keys are derived once per connection.
+func (p *Quic) UnmarshalWithOpeners(payload []byte, opts tls.Options, openers *Openers) error {
+	if IsGQUIC(payload) {
+		gquic := &GQuic{}
+		if err := gquic.Unmarshal(payload); err != nil {
+			return err
+		}
+		p.setCHLO(gquic.CHLO)
+		return nil
+	}
+
+	hdr := &p.header
+	*hdr = ExtendedHeader{}
+	err := hdr.Header.parse(payload)
+	if hdr.IsLongHeader {
+		// Keep what was parsed, so the version and the type of packets
+		// that are not decrypted are known
+		p.Header = hdr
+	}
 	if err != nil {
 		return err
 	}
+	if hdr.Type != PacketInitial {
+		p.ZeroRTT = hdr.Type == Packet0RTT
+		return UnmarshalNoQUICInitialError
+	}
 	if int64(len(payload)) < hdr.ParsedLen+hdr.Length {
 		return UnmarshalQUICError
 	}
+	p.ZeroRTT = isZeroRTT(payload[hdr.ParsedLen+hdr.Length:], hdr.Version)
 
-	opener := NewInitialAEAD(hdr.DestConnectionID, hdr.Version)
+	opener := openers.Get(hdr.DestConnectionID, hdr.Version)
 	encryptedData := payload[:hdr.ParsedLen+hdr.Length]
-	p.Header, err = UnpackHeader(opener, hdr, encryptedData, hdr.Version)
-	if err != nil {
+	if err := UnpackHeader(opener, hdr, encryptedData); err != nil {
 		return err
 	}
 
@@ -33,5 +75,40 @@ func (p *Quic) Unmarshal(payload []byte) error {
 		return err
 	}
 
-	return p.Hello.Unmarshal(decryptedData)
+	if p.Header.Version == VersionQ50 {
+		// Q050 carries a gQUIC CHLO in a crypto frame instead of a TLS hello
+		message, err := readGQUICCryptoData(decryptedData)
+		if err != nil {
+			return err
+		}
+		var chlo CHLO
+		if err := chlo.Unmarshal(message); err != nil {
+			return err
+		}
+		p.setCHLO(chlo)
+		return nil
+	}
+
+	cryptoData, err := ReadCryptoData(decryptedData)
+	if err != nil {
+		return err
+	}
+	err = p.Hello.UnmarshalHandshake(cryptoData, opts)
+	if p.Hello.Incomplete(err) {
+		// The hello continues in the next Initial packet, but we have the SNI
+		return nil
+	}
+	return err
+}
+
+// isZeroRTT reports whether the coalesced packet at payload is a 0-RTT
+// packet of version v.
+func isZeroRTT(payload []byte, v uint32) bool {
+	// The long header and fixed bits, and the 0-RTT type
+	return len(payload) >= 5 && payload[0]&0xf0 == 0xd0 && binary.BigEndian.Uint32(payload[1:5]) == v
+}
+
+func (p *Quic) setCHLO(chlo CHLO) {
+	p.CHLO = &chlo
+	p.Hello.SNI = chlo.SNI
 }
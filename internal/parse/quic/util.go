@@ -1,6 +1,7 @@
 package quic
 
 import (
+	"bytes"
 	"crypto"
 	"crypto/tls"
 	"encoding/binary"
@@ -17,6 +18,11 @@ var UnmarshalNoQUICError = errors.New("not a QUIC packet")
 var UnmarshalNoQUICInitialError = errors.New("not an initial QUIC packet")
 var UnmarshalQUICBitsError = errors.New("unknown bits in QUIC header")
 var UnmarshalQUICUnsupportedVersion = errors.New("unsupported QUIC version")
+var UnmarshalQUICFrameError = errors.New("unknown frame in QUIC Initial packet")
+var UnmarshalNoQUICCryptoError = errors.New("no CRYPTO frame in QUIC Initial packet")
+var UnmarshalQUICContinuationError = errors.New("QUIC Initial packet continues the CRYPTO data of an earlier one")
+var UnmarshalNoCHLOError = errors.New("gQUIC CHLO not found")
+var UnmarshalCHLOError = errors.New("malformed gQUIC CHLO")
 
 var initialSuite = &qtls.CipherSuiteTLS13{
 	ID:     tls.TLS_AES_128_GCM_SHA256,
@@ -83,7 +89,7 @@ const (
 	VersionDraft29 uint32 = 0xff00001d
 	VersionDraft32 uint32 = 0xff000020
 	VersionDraft34 uint32 = 0xff000022
-	VersionQ50     uint32 = 0x51303530
+	VersionQ50     uint32 = 0x51303530 // Q050
 	Version1       uint32 = 0x1
 )
 
@@ -101,7 +107,13 @@ func IsSupportedVersion(supported []uint32, v uint32) bool {
 	return false
 }
 
-func ReadQuickVarInt(b io.ByteReader) (uint64, error) {
+// IsGreasedVersion reports whether v is one of the versions reserved to
+// exercise version negotiation, which have the form 0x?a?a?a?a.
+func IsGreasedVersion(v uint32) bool {
+	return v&0x0f0f0f0f == 0x0a0a0a0a
+}
+
+func ReadQuickVarInt(b *bytes.Reader) (uint64, error) {
 	firstByte, err := b.ReadByte()
 	if err != nil {
 		return 0, err
@@ -149,21 +161,33 @@ func ReadQuickVarInt(b io.ByteReader) (uint64, error) {
 	return uint64(b8) + uint64(b7)<<8 + uint64(b6)<<16 + uint64(b5)<<24 + uint64(b4)<<32 + uint64(b3)<<40 + uint64(b2)<<48 + uint64(b1)<<56, nil
 }
 
-// ReadConnectionID reads a connection ID of length len from the given io.Reader.
+// ReadConnectionID reads a connection ID of length len from the given reader.
 // It returns io.EOF if there are not enough bytes to read.
-func ReadConnectionID(r io.Reader, len int) ([]byte, error) {
+func ReadConnectionID(b *bytes.Reader, len int) ([]byte, error) {
 	if len == 0 {
 		return nil, nil
 	}
 	c := make([]byte, len)
-	_, err := io.ReadFull(r, c)
-	if err == io.ErrUnexpectedEOF {
+	if n, _ := b.Read(c); n < len {
 		return nil, io.EOF
 	}
-	return c, err
+	return c, nil
+}
+
+// readSlice returns the next n bytes of b, which reads data, without copying
+// them. It returns io.EOF if there are not enough bytes to read.
+func readSlice(b *bytes.Reader, data []byte, n int) ([]byte, error) {
+	if n > b.Len() {
+		return nil, io.EOF
+	}
+	start := len(data) - b.Len()
+	if _, err := b.Seek(int64(n), io.SeekCurrent); err != nil {
+		return nil, err
+	}
+	return data[start : start+n : start+n], nil
 }
 
-func ReadUint32(b io.ByteReader) (uint32, error) {
+func ReadUint32(b *bytes.Reader) (uint32, error) {
 	var b1, b2, b3, b4 uint8
 	var err error
 	if b4, err = b.ReadByte(); err != nil {
@@ -182,7 +206,7 @@ func ReadUint32(b io.ByteReader) (uint32, error) {
 }
 
 // ReadUint16 reads a uint16
-func ReadUint16(b io.ByteReader) (uint16, error) {
+func ReadUint16(b *bytes.Reader) (uint16, error) {
 	var b1, b2 uint8
 	var err error
 	if b2, err = b.ReadByte(); err != nil {
@@ -195,7 +219,7 @@ func ReadUint16(b io.ByteReader) (uint16, error) {
 }
 
 // ReadUint24 reads a uint24
-func ReadUint24(b io.ByteReader) (uint32, error) {
+func ReadUint24(b *bytes.Reader) (uint32, error) {
 	var b1, b2, b3 uint8
 	var err error
 	if b3, err = b.ReadByte(); err != nil {
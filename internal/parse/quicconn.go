@@ -0,0 +1,167 @@
+package parse
+
+import (
+	"bytes"
+	"sync"
+	"time"
+
+	"github.com/jsimonetti/sniqueue/internal/parse/quic"
+)
+
+// QUICPacket describes the QUIC long header packet of a datagram. It is zero
+// for datagrams that do not carry one, such as DNS, DTLS and Google QUIC
+// before Q050.
+type QUICPacket struct {
+	Version uint32
+	Type    quic.PacketType
+
+	DestinationConnectionID []byte
+	SourceConnectionID      []byte
+	// Token is the token of an Initial, or the token a Retry asks the
+	// client to send in its next Initial.
+	Token []byte
+	// Versions lists the versions offered by the server in a Version
+	// Negotiation packet. When the connection is followed, it is also set
+	// on the packets of the client that follow it.
+	Versions []uint32
+	// ZeroRTT is set when the datagram carries a 0-RTT packet.
+	ZeroRTT bool
+
+	// OriginalDestinationConnectionID is the destination connection id of
+	// the first Initial of the client, and Retried is set for an Initial
+	// that returns the token of a Retry of the server. Both are only set
+	// when the connection is followed.
+	OriginalDestinationConnectionID []byte
+	Retried                         bool
+}
+
+type quicConnection struct {
+	seen         time.Time
+	originalDCID []byte
+	retrySCID    []byte
+	retryToken   []byte
+	retried      bool
+	versions     []uint32
+}
+
+// QUICConnections follows QUIC connections from their first Initial, so the
+// Initial a client sends after a Retry or a Version Negotiation packet of the
+// server can be correlated with the first one. The packets of both directions
+// have to be parsed for this. Connections are tracked on the configured
+// server ports only. It is safe for concurrent use.
+type QUICConnections struct {
+	mu      sync.Mutex
+	ports   map[uint16]bool
+	size    int
+	timeout time.Duration
+	conns   map[flowKey]*quicConnection
+
+	// now is replaced in tests
+	now func() time.Time
+}
+
+// NewQUICConnections returns a QUICConnections that follows at most size
+// connections to the given server ports, until they have been idle for
+// timeout.
+func NewQUICConnections(ports []uint16, size int, timeout time.Duration) *QUICConnections {
+	c := &QUICConnections{
+		ports:   make(map[uint16]bool, len(ports)),
+		size:    size,
+		timeout: timeout,
+		conns:   make(map[flowKey]*quicConnection),
+		now:     time.Now,
+	}
+	for _, port := range ports {
+		c.ports[port] = true
+	}
+	return c
+}
+
+// Expire removes the connections that were idle for the timeout.
+func (c *QUICConnections) Expire() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	for key, conn := range c.conns {
+		if now.Sub(conn.seen) >= c.timeout {
+			delete(c.conns, key)
+		}
+	}
+}
+
+// Len returns the number of followed connections.
+func (c *QUICConnections) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.conns)
+}
+
+// track updates the connection of a datagram with its QUIC packet and adds
+// what is known about the connection to the packet.
+func (c *QUICConnections) track(p *Inet, udp *UDP) {
+	q := &udp.QUIC
+	if q.Version == 0 && q.Type != quic.PacketVersionNegotiation {
+		// Not a long header packet
+		return
+	}
+	key, fromClient, ok := c.flowKey(p, udp)
+	if !ok {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	conn := c.conns[key]
+	switch {
+	case fromClient && q.Type == quic.PacketInitial:
+		if conn == nil {
+			if len(c.conns) >= c.size {
+				return
+			}
+			conn = &quicConnection{originalDCID: bytes.Clone(q.DestinationConnectionID)}
+			c.conns[key] = conn
+		}
+		if conn.retryToken != nil && bytes.Equal(q.DestinationConnectionID, conn.retrySCID) && bytes.Equal(q.Token, conn.retryToken) {
+			conn.retried = true
+		}
+	case !fromClient && q.Type == quic.PacketRetry && conn != nil:
+		// The client sends its next Initial to the connection id chosen
+		// by the server, with the token
+		conn.retrySCID = bytes.Clone(q.SourceConnectionID)
+		conn.retryToken = bytes.Clone(q.Token)
+	case !fromClient && q.Type == quic.PacketVersionNegotiation && conn != nil:
+		conn.versions = append([]uint32(nil), q.Versions...)
+	}
+	if conn == nil {
+		return
+	}
+
+	conn.seen = c.now()
+	q.OriginalDestinationConnectionID = conn.originalDCID
+	q.Retried = conn.retried
+	if q.Versions == nil {
+		q.Versions = conn.versions
+	}
+}
+
+// flowKey returns the key of the connection of a datagram and whether it was
+// sent by the client. ok is false when neither port is tracked.
+func (c *QUICConnections) flowKey(p *Inet, udp *UDP) (key flowKey, fromClient bool, ok bool) {
+	client, server := p.Source, p.Destination
+	clientPort, serverPort := udp.SourcePort, udp.DestinationPort
+	switch {
+	case c.ports[serverPort]:
+		fromClient = true
+	case c.ports[clientPort]:
+		client, server = server, client
+		clientPort, serverPort = serverPort, clientPort
+	default:
+		return key, false, false
+	}
+	copy(key.client[:], client.To16())
+	copy(key.server[:], server.To16())
+	key.clientPort, key.serverPort = clientPort, serverPort
+	return key, fromClient, true
+}
@@ -0,0 +1,233 @@
+package parse
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jsimonetti/sniqueue/internal/parse/quic"
+)
+
+func TestQUICConnections(t *testing.T) {
+	client, server := net.IP{192, 0, 2, 1}, net.IP{198, 51, 100, 1}
+	clientID, serverID, retryID := []byte{0x01, 0x02, 0x03, 0x04}, []byte{0x05, 0x06, 0x07, 0x08}, []byte{0x09, 0x0a, 0x0b, 0x0c}
+	token := []byte("retry token")
+	tag := bytes.Repeat([]byte{0xee}, 16)
+
+	fromClient := func(port uint16, packet []byte) []byte {
+		return ip4Packet(t, client, server, 17, udpHeader(port, 443, packet))
+	}
+	fromServer := func(port uint16, packet []byte) []byte {
+		return ip4Packet(t, server, client, 17, udpHeader(443, port, packet))
+	}
+
+	type step struct {
+		payload []byte
+		want    QUICPacket
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "Retry",
+			steps: []step{
+				{
+					payload: fromClient(50000, initialPacket(quic.Version1, serverID, clientID, nil)),
+					want: QUICPacket{
+						Version:                         quic.Version1,
+						DestinationConnectionID:         serverID,
+						SourceConnectionID:              clientID,
+						Token:                           []byte{},
+						OriginalDestinationConnectionID: serverID,
+					},
+				},
+				{
+					payload: fromServer(50000, longHeader(0xf0, quic.Version1, clientID, retryID, append(token, tag...))),
+					want: QUICPacket{
+						Version:                         quic.Version1,
+						Type:                            quic.PacketRetry,
+						DestinationConnectionID:         clientID,
+						SourceConnectionID:              retryID,
+						Token:                           token,
+						OriginalDestinationConnectionID: serverID,
+					},
+				},
+				{
+					payload: fromClient(50000, initialPacket(quic.Version1, retryID, clientID, token)),
+					want: QUICPacket{
+						Version:                         quic.Version1,
+						DestinationConnectionID:         retryID,
+						SourceConnectionID:              clientID,
+						Token:                           token,
+						OriginalDestinationConnectionID: serverID,
+						Retried:                         true,
+					},
+				},
+			},
+		},
+		{
+			name: "Initial with another token",
+			steps: []step{
+				{
+					payload: fromClient(50001, initialPacket(quic.Version1, serverID, clientID, nil)),
+					want: QUICPacket{
+						Version:                         quic.Version1,
+						DestinationConnectionID:         serverID,
+						SourceConnectionID:              clientID,
+						Token:                           []byte{},
+						OriginalDestinationConnectionID: serverID,
+					},
+				},
+				{
+					payload: fromServer(50001, longHeader(0xf0, quic.Version1, clientID, retryID, append(token, tag...))),
+					want: QUICPacket{
+						Version:                         quic.Version1,
+						Type:                            quic.PacketRetry,
+						DestinationConnectionID:         clientID,
+						SourceConnectionID:              retryID,
+						Token:                           token,
+						OriginalDestinationConnectionID: serverID,
+					},
+				},
+				{
+					payload: fromClient(50001, initialPacket(quic.Version1, retryID, clientID, []byte("other"))),
+					want: QUICPacket{
+						Version:                         quic.Version1,
+						DestinationConnectionID:         retryID,
+						SourceConnectionID:              clientID,
+						Token:                           []byte("other"),
+						OriginalDestinationConnectionID: serverID,
+					},
+				},
+			},
+		},
+		{
+			name: "Version Negotiation",
+			steps: []step{
+				{
+					payload: fromClient(50002, initialPacket(0x1a2a3a4a, serverID, clientID, nil)),
+					want: QUICPacket{
+						Version:                         0x1a2a3a4a,
+						DestinationConnectionID:         serverID,
+						SourceConnectionID:              clientID,
+						OriginalDestinationConnectionID: serverID,
+					},
+				},
+				{
+					payload: fromServer(50002, longHeader(0x80, 0, clientID, serverID, []byte{0x00, 0x00, 0x00, 0x01})),
+					want: QUICPacket{
+						Type:                            quic.PacketVersionNegotiation,
+						DestinationConnectionID:         clientID,
+						SourceConnectionID:              serverID,
+						Versions:                        []uint32{quic.Version1},
+						OriginalDestinationConnectionID: serverID,
+					},
+				},
+				{
+					payload: fromClient(50002, initialPacket(quic.Version1, serverID, clientID, nil)),
+					want: QUICPacket{
+						Version:                         quic.Version1,
+						DestinationConnectionID:         serverID,
+						SourceConnectionID:              clientID,
+						Token:                           []byte{},
+						Versions:                        []uint32{quic.Version1},
+						OriginalDestinationConnectionID: serverID,
+					},
+				},
+			},
+		},
+		{
+			name: "Retry of an unknown connection",
+			steps: []step{
+				{
+					payload: fromServer(50003, longHeader(0xf0, quic.Version1, clientID, retryID, append(token, tag...))),
+					want: QUICPacket{
+						Version:                 quic.Version1,
+						Type:                    quic.PacketRetry,
+						DestinationConnectionID: clientID,
+						SourceConnectionID:      retryID,
+						Token:                   token,
+					},
+				},
+			},
+		},
+		{
+			name: "0-RTT",
+			steps: []step{
+				{
+					payload: fromClient(50004, longHeader(0xd0, quic.Version1, serverID, clientID, []byte{0x01, 0x00})),
+					want: QUICPacket{
+						Version:                 quic.Version1,
+						Type:                    quic.Packet0RTT,
+						DestinationConnectionID: serverID,
+						SourceConnectionID:      clientID,
+						ZeroRTT:                 true,
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := Options{QUIC: NewQUICConnections([]uint16{443}, 8, time.Minute)}
+			for i, s := range tt.steps {
+				p, _ := ParseWithOptions(s.payload, opts)
+				got := p.(*IPv4).Transport.(*UDP).QUIC
+				if diff := cmp.Diff(s.want, got); diff != "" {
+					t.Fatalf("step %d: unexpected packet (-want +got):\n%s", i, diff)
+				}
+			}
+		})
+	}
+}
+
+func TestQUICConnections_Expire(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	c := NewQUICConnections([]uint16{443}, 1, time.Second)
+	c.now = func() time.Time { return now }
+	opts := Options{QUIC: c}
+
+	initial := func(port uint16) []byte {
+		return ip4Packet(t, net.IP{192, 0, 2, 1}, net.IP{198, 51, 100, 1}, 17,
+			udpHeader(port, 443, initialPacket(quic.Version1, []byte{0x01}, nil, nil)))
+	}
+	_, _ = ParseWithOptions(initial(50000), opts)
+	// A second connection does not fit
+	_, _ = ParseWithOptions(initial(50001), opts)
+	if c.Len() != 1 {
+		t.Fatalf("expected 1 connection, got %d", c.Len())
+	}
+
+	now = now.Add(time.Second)
+	c.Expire()
+	if c.Len() != 0 {
+		t.Fatalf("%d connections left after Expire()", c.Len())
+	}
+	_, _ = ParseWithOptions(initial(50001), opts)
+	if c.Len() != 1 {
+		t.Fatalf("expected 1 connection, got %d", c.Len())
+	}
+}
+
+// longHeader builds a QUIC long header packet with the given first byte,
+// followed by rest.
+func longHeader(typeByte byte, version uint32, dcid, scid []byte, rest []byte) []byte {
+	packet := []byte{typeByte, byte(version >> 24), byte(version >> 16), byte(version >> 8), byte(version)}
+	packet = append(packet, byte(len(dcid)))
+	packet = append(packet, dcid...)
+	packet = append(packet, byte(len(scid)))
+	packet = append(packet, scid...)
+	return append(packet, rest...)
+}
+
+// initialPacket builds an Initial packet with the given token and a payload
+// that cannot be decrypted.
+func initialPacket(version uint32, dcid, scid, token []byte) []byte {
+	rest := append([]byte{byte(len(token))}, token...)
+	rest = append(rest, 0x40, 0x40)
+	return longHeader(0xc0, version, dcid, scid, append(rest, make([]byte, 64)...))
+}
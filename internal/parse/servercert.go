@@ -0,0 +1,163 @@
+package parse
+
+import (
+	"encoding/binary"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jsimonetti/sniqueue/internal/parse/tls"
+)
+
+// ServerCertificatePendingError is returned for the segments of the server
+// of a tracked flow before its certificate is complete.
+var ServerCertificatePendingError = errors.New("waiting for server certificate")
+
+var serverFlightSizeError = errors.New("server certificate exceeds the inspected bytes")
+
+// maxServerFlight is the number of bytes of the stream of a server that are
+// buffered to reach the end of its certificate. Chains are rarely larger
+// than a few kilobytes, and the leaf certificate comes first.
+const maxServerFlight = 32 << 10
+
+type serverFlow struct {
+	added  time.Time
+	stream stream
+	// server is set once the certificate was found
+	server *tls.ServerHello
+}
+
+// ServerCertificates follows the TLS flows whose ClientHello has no server
+// name, so the names of the certificate the server sends in cleartext in TLS
+// 1.2 can be extracted instead. The segments of both directions have to be
+// parsed for this. Once found, the certificate is set on all the segments of
+// the server until the flow expires, so the flow can be judged by any of
+// them. It is safe for concurrent use.
+type ServerCertificates struct {
+	mu      sync.Mutex
+	size    int
+	timeout time.Duration
+	flows   map[flowKey]*serverFlow
+
+	// now is replaced in tests
+	now func() time.Time
+}
+
+// NewServerCertificates returns a ServerCertificates that follows at most
+// size flows, each for at most timeout.
+func NewServerCertificates(size int, timeout time.Duration) *ServerCertificates {
+	return &ServerCertificates{
+		size:    size,
+		timeout: timeout,
+		flows:   make(map[flowKey]*serverFlow),
+		now:     time.Now,
+	}
+}
+
+// Expire removes the flows that were followed for the timeout.
+func (s *ServerCertificates) Expire() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for key, f := range s.flows {
+		if now.Sub(f.added) >= s.timeout {
+			delete(s.flows, key)
+		}
+	}
+}
+
+// Len returns the number of followed flows.
+func (s *ServerCertificates) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.flows)
+}
+
+// track starts following the flow of a ClientHello without server name, and
+// reassembles the stream of the server of a followed flow. err is the result
+// of parsing the segment, which is returned for the segments that are not
+// part of a followed server stream. The server stream starts at the
+// acknowledgment number of the ClientHello. When the server stream cannot
+// hold a certificate, the flow is no longer followed.
+func (s *ServerCertificates) track(p *Inet, tcp *TCP, sequence, acknowledgment uint32, data []byte, err error) error {
+	if len(data) == 0 {
+		return err
+	}
+	if err == nil && tcp.Detected.Protocol == DetectedTLS && tcp.Hello.SNI == "" {
+		s.add(p, tcp, acknowledgment)
+		return err
+	}
+
+	// The server sends to the client of the flow
+	var key flowKey
+	copy(key.client[:], p.Destination.To16())
+	copy(key.server[:], p.Source.To16())
+	key.clientPort, key.serverPort = tcp.DestinationPort, tcp.SourcePort
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f := s.flows[key]
+	if f == nil {
+		return err
+	}
+	if f.server != nil {
+		tcp.Server = f.server
+		return nil
+	}
+	if !f.stream.add(sequence, data, maxServerFlight) {
+		// A retransmission, or a segment after one that was lost or
+		// reordered, which is held until the gap is filled
+		return ServerCertificatePendingError
+	}
+
+	server := &tls.ServerHello{}
+	err = server.Unmarshal(f.stream.data)
+	if errors.Is(err, tls.UnmarshalServerHelloError) {
+		if len(f.stream.data) < maxServerFlight {
+			return ServerCertificatePendingError
+		}
+		err = serverFlightSizeError
+	}
+	if err != nil {
+		delete(s.flows, key)
+		return err
+	}
+	f.server, f.stream = server, stream{}
+	tcp.Server = server
+	return nil
+}
+
+// add starts following the flow of a ClientHello, unless too many flows are
+// followed already. The hello acknowledges the first byte the server will
+// send, which starts its stream. Without the ACK flag, as in a TCP Fast Open
+// SYN, the first data of the server starts it.
+func (s *ServerCertificates) add(p *Inet, tcp *TCP, acknowledgment uint32) {
+	var key flowKey
+	copy(key.client[:], p.Source.To16())
+	copy(key.server[:], p.Destination.To16())
+	key.clientPort, key.serverPort = tcp.SourcePort, tcp.DestinationPort
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.flows[key]; ok || len(s.flows) >= s.size {
+		return
+	}
+	f := &serverFlow{added: s.now()}
+	if tcp.Flags&TCPFlagACK != 0 {
+		f.stream.start(acknowledgment)
+	}
+	s.flows[key] = f
+}
+
+// tcpSequence returns the sequence number of a TCP segment.
+func tcpSequence(segment []byte) uint32 {
+	return binary.BigEndian.Uint32(segment[4:8])
+}
+
+// tcpAcknowledgment returns the acknowledgment number of a TCP segment.
+func tcpAcknowledgment(segment []byte) uint32 {
+	return binary.BigEndian.Uint32(segment[8:12])
+}
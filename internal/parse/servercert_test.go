@@ -0,0 +1,179 @@
+package parse
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jsimonetti/sniqueue/internal/parse/tls"
+)
+
+// helloWithoutSNI is a ClientHello without extensions.
+var helloWithoutSNI = []byte{
+	0x16, 0x03, 0x01, 0x00, 0x2d, 0x01, 0x00, 0x00,
+	0x29, 0x03, 0x03, 0x5e, 0x1b, 0x7c, 0x3a, 0x11,
+	0x94, 0x0d, 0x6f, 0x28, 0xa7, 0x52, 0xe0, 0x83,
+	0x4c, 0x19, 0xbd, 0x60, 0x07, 0xf2, 0x3e, 0xc5,
+	0x8a, 0x71, 0x2b, 0xd4, 0x96, 0x0e, 0x53, 0xfa,
+	0x38, 0x6c, 0x21, 0x00, 0x00, 0x02, 0xc0, 0x2b,
+	0x01, 0x00,
+}
+
+type seqSegment struct {
+	fromServer bool
+	seq        uint32
+	ack        uint32
+	data       []byte
+	wantErr    error
+}
+
+func TestServerCertificates(t *testing.T) {
+	flight, err := os.ReadFile("testdata/tls12-server-flight")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The hello acknowledges sequence number 1000, where the server stream
+	// starts
+	hello := seqSegment{ack: 1000, data: helloWithoutSNI}
+	server := func(from, to int, wantErr error) seqSegment {
+		return seqSegment{fromServer: true, seq: 1000 + uint32(from), data: flight[from:to], wantErr: wantErr}
+	}
+	pending := ServerCertificatePendingError
+	want := &tls.ServerHello{
+		Version:     0x0303,
+		CipherSuite: 0xc02b,
+		CommonName:  "device.example.com",
+		DNSNames:    []string{"device.example.com", "api.example.com"},
+	}
+
+	tests := []struct {
+		name     string
+		segments []seqSegment
+		want     *tls.ServerHello
+	}{
+		{
+			name: "Without SNI",
+			segments: []seqSegment{
+				hello,
+				server(0, 200, pending),
+				server(200, 450, nil),
+				server(450, len(flight), nil),
+			},
+			want: want,
+		},
+		{
+			name: "Whole flight",
+			segments: []seqSegment{
+				hello,
+				server(0, len(flight), nil),
+			},
+			want: want,
+		},
+		{
+			name: "Reordered",
+			segments: []seqSegment{
+				hello,
+				// The end of the certificate arrives before its middle
+				server(0, 200, pending),
+				server(300, len(flight), pending),
+				server(200, 300, nil),
+			},
+			want: want,
+		},
+		{
+			name: "First segment reordered",
+			segments: []seqSegment{
+				hello,
+				// The segment after the first is held until the first
+				// arrives
+				server(200, len(flight), pending),
+				server(0, 200, nil),
+			},
+			want: want,
+		},
+		{
+			name: "Retransmitted",
+			segments: []seqSegment{
+				hello,
+				server(0, 200, pending),
+				server(0, 200, pending),
+				// Repacketized with the data that follows
+				server(100, 450, nil),
+				server(100, 450, nil),
+			},
+			want: want,
+		},
+		{
+			name: "With SNI",
+			segments: []seqSegment{
+				{data: tcpSegment[32:]},
+				server(0, len(flight), tls.UnmarshalNoTLSHandshakeError),
+			},
+		},
+		{
+			name: "Not TLS",
+			segments: []seqSegment{
+				hello,
+				{fromServer: true, seq: 1000, data: []byte("HTTP/1.1 400 Bad Request\r\n\r\n"), wantErr: tls.UnmarshalNoTLSError},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewServerCertificates(4, time.Minute)
+			opts := Options{ServerCertificates: s}
+
+			var got networkLayer
+			var err error
+			for i, seg := range tt.segments {
+				packet := flowPacket(t, seg.fromServer, 443, seg.data)
+				binary.BigEndian.PutUint32(packet[24:28], seg.seq)
+				binary.BigEndian.PutUint32(packet[28:32], seg.ack)
+				got, err = ParseWithOptions(packet, opts)
+				if !errors.Is(err, seg.wantErr) {
+					t.Fatalf("segment %d: ParseWithOptions() error = %v, wantErr %v", i, err, seg.wantErr)
+				}
+			}
+			tcp := got.(*IPv4).Transport.(*TCP)
+			if diff := cmp.Diff(tt.want, tcp.Server); diff != "" {
+				t.Errorf("unexpected ServerHello (-want +got):\n%s", diff)
+			}
+			// Flows are followed until they expire once the certificate
+			// is found
+			wantLen := 0
+			if tt.want != nil {
+				wantLen = 1
+			}
+			if s.Len() != wantLen {
+				t.Errorf("%d flows left after the last segment, want %d", s.Len(), wantLen)
+			}
+		})
+	}
+}
+
+func TestServerCertificates_Expire(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	s := NewServerCertificates(1, time.Second)
+	s.now = func() time.Time { return now }
+	opts := Options{ServerCertificates: s}
+
+	if _, err := ParseWithOptions(flowPacket(t, false, 443, helloWithoutSNI), opts); err != nil {
+		t.Fatalf("ParseWithOptions() error = %v", err)
+	}
+	// A second flow does not fit, but its hello is still parsed
+	if _, err := ParseWithOptions(flowPacket(t, false, 8443, helloWithoutSNI), opts); err != nil {
+		t.Fatalf("ParseWithOptions() error = %v", err)
+	}
+	if s.Len() != 1 {
+		t.Fatalf("Len() = %d, want 1", s.Len())
+	}
+
+	now = now.Add(time.Second)
+	s.Expire()
+	if s.Len() != 0 {
+		t.Fatalf("%d flows left after Expire()", s.Len())
+	}
+}
@@ -0,0 +1,270 @@
+package parse
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// StartTLSPendingError is returned for the plaintext segments of a tracked
+// flow before it has been upgraded to TLS.
+var StartTLSPendingError = errors.New("waiting for STARTTLS upgrade")
+
+// StartTLSDeclinedError is returned when a tracked flow will not be upgraded
+// to TLS, because the server refused or the client did not ask in time.
+var StartTLSDeclinedError = errors.New("flow not upgraded with STARTTLS")
+
+var startTLSFullError = errors.New("too many STARTTLS flows being tracked")
+
+// maxPlaintextSegments is the number of client segments carrying data after
+// which a flow that did not ask for STARTTLS is given up.
+const maxPlaintextSegments = 16
+
+// StartTLSProtocol is a plaintext protocol that can be upgraded to TLS.
+type StartTLSProtocol int
+
+const (
+	SMTP StartTLSProtocol = iota + 1
+	IMAP
+	POP3
+	XMPP
+)
+
+var startTLSProtocols = map[string]StartTLSProtocol{
+	"smtp": SMTP,
+	"imap": IMAP,
+	"pop3": POP3,
+	"xmpp": XMPP,
+}
+
+// ParseStartTLSProtocol returns the protocol with the given name: smtp, imap,
+// pop3 or xmpp.
+func ParseStartTLSProtocol(name string) (StartTLSProtocol, error) {
+	if p, ok := startTLSProtocols[name]; ok {
+		return p, nil
+	}
+	return 0, fmt.Errorf("unknown STARTTLS protocol '%s'", name)
+}
+
+func (p StartTLSProtocol) String() string {
+	for name, protocol := range startTLSProtocols {
+		if protocol == p {
+			return name
+		}
+	}
+	return fmt.Sprintf("StartTLSProtocol(%d)", int(p))
+}
+
+type flowState int
+
+const (
+	// flowPlaintext is a flow where the client did not ask for TLS yet
+	flowPlaintext flowState = iota
+	// flowRequested is a flow where the client sent the STARTTLS command
+	flowRequested
+	// flowUpgraded is a flow where the server agreed to the upgrade
+	flowUpgraded
+)
+
+type flowKey struct {
+	client     [16]byte
+	server     [16]byte
+	clientPort uint16
+	serverPort uint16
+}
+
+type flow struct {
+	added    time.Time
+	protocol StartTLSProtocol
+	state    flowState
+	segments int
+}
+
+// StartTLS follows plaintext mail and chat connections until they are
+// upgraded to TLS, so the SNI of the ClientHello that follows the upgrade can
+// be extracted. Flows are tracked on the configured server ports only. It is
+// safe for concurrent use.
+type StartTLS struct {
+	mu      sync.Mutex
+	ports   map[uint16]StartTLSProtocol
+	size    int
+	timeout time.Duration
+	flows   map[flowKey]*flow
+
+	// now is replaced in tests
+	now func() time.Time
+}
+
+// NewStartTLS returns a StartTLS that tracks at most size flows to the given
+// server ports, each for at most timeout.
+func NewStartTLS(ports map[uint16]StartTLSProtocol, size int, timeout time.Duration) *StartTLS {
+	return &StartTLS{
+		ports:   ports,
+		size:    size,
+		timeout: timeout,
+		flows:   make(map[flowKey]*flow),
+		now:     time.Now,
+	}
+}
+
+// Expire removes the flows that were not upgraded within the timeout.
+func (s *StartTLS) Expire() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for key, f := range s.flows {
+		if now.Sub(f.added) >= s.timeout {
+			delete(s.flows, key)
+		}
+	}
+}
+
+// track updates the flow of a TCP segment with the given data. err is the
+// result of parsing the segment as TLS, which is returned once the flow has
+// been upgraded.
+func (s *StartTLS) track(p *Inet, tcp *TCP, data []byte, err error) error {
+	key, fromClient, protocol, ok := s.flowKey(p, tcp)
+	if !ok {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if fromClient && err == nil {
+		// A ClientHello, with or without the upgrade
+		delete(s.flows, key)
+		return err
+	}
+
+	f := s.flows[key]
+	if f == nil {
+		if !fromClient || len(data) == 0 {
+			// Wait for the client to say something
+			return StartTLSPendingError
+		}
+		if len(s.flows) >= s.size {
+			return startTLSFullError
+		}
+		f = &flow{added: s.now(), protocol: protocol}
+		s.flows[key] = f
+	}
+
+	switch {
+	case f.state == flowUpgraded && fromClient && len(data) > 0:
+		// This should be the ClientHello, there is nothing left to follow
+		delete(s.flows, key)
+		return err
+	case f.state == flowPlaintext && fromClient && len(data) > 0:
+		if isStartTLSCommand(f.protocol, data) {
+			f.state = flowRequested
+			return StartTLSPendingError
+		}
+		f.segments++
+		if f.segments >= maxPlaintextSegments {
+			delete(s.flows, key)
+			return StartTLSDeclinedError
+		}
+	case f.state == flowRequested && !fromClient && len(data) > 0:
+		switch startTLSReply(f.protocol, data) {
+		case replyAccepted:
+			f.state = flowUpgraded
+		case replyRefused:
+			delete(s.flows, key)
+			return StartTLSDeclinedError
+		}
+	}
+	return StartTLSPendingError
+}
+
+// flowKey returns the key of the flow of a segment, whether it was sent by
+// the client and the protocol of the server port. ok is false when neither
+// port is tracked.
+func (s *StartTLS) flowKey(p *Inet, tcp *TCP) (key flowKey, fromClient bool, protocol StartTLSProtocol, ok bool) {
+	client, server := p.Source, p.Destination
+	clientPort, serverPort := tcp.SourcePort, tcp.DestinationPort
+	if protocol, ok = s.ports[serverPort]; ok {
+		fromClient = true
+	} else if protocol, ok = s.ports[clientPort]; ok {
+		client, server = server, client
+		clientPort, serverPort = serverPort, clientPort
+	} else {
+		return key, false, 0, false
+	}
+	copy(key.client[:], client.To16())
+	copy(key.server[:], server.To16())
+	key.clientPort, key.serverPort = clientPort, serverPort
+	return key, fromClient, protocol, true
+}
+
+// isStartTLSCommand reports whether the client data is the command that asks
+// for the upgrade.
+func isStartTLSCommand(protocol StartTLSProtocol, data []byte) bool {
+	line := bytes.TrimRight(data, "\r\n")
+	switch protocol {
+	case SMTP:
+		return bytes.EqualFold(line, []byte("STARTTLS"))
+	case POP3:
+		return bytes.EqualFold(line, []byte("STLS"))
+	case IMAP:
+		// Commands are prefixed with a tag chosen by the client
+		_, command, ok := bytes.Cut(line, []byte(" "))
+		return ok && bytes.EqualFold(command, []byte("STARTTLS"))
+	case XMPP:
+		return bytes.HasPrefix(bytes.TrimSpace(data), []byte("<starttls"))
+	}
+	return false
+}
+
+type startTLSReplyType int
+
+const (
+	replyOther startTLSReplyType = iota
+	replyAccepted
+	replyRefused
+)
+
+// startTLSReply classifies the server reply to the STARTTLS command.
+func startTLSReply(protocol StartTLSProtocol, data []byte) startTLSReplyType {
+	switch protocol {
+	case SMTP:
+		if len(data) < 3 {
+			return replyOther
+		}
+		if bytes.HasPrefix(data, []byte("220")) {
+			return replyAccepted
+		}
+		return replyRefused
+	case POP3:
+		if bytes.HasPrefix(data, []byte("+OK")) {
+			return replyAccepted
+		}
+		if bytes.HasPrefix(data, []byte("-ERR")) {
+			return replyRefused
+		}
+	case IMAP:
+		// Skip untagged responses, the tagged one completes the command
+		for _, line := range bytes.Split(data, []byte("\r\n")) {
+			tag, status, ok := bytes.Cut(line, []byte(" "))
+			if !ok || bytes.Equal(tag, []byte("*")) {
+				continue
+			}
+			if len(status) >= 2 && bytes.EqualFold(status[:2], []byte("OK")) {
+				return replyAccepted
+			}
+			return replyRefused
+		}
+	case XMPP:
+		data = bytes.TrimSpace(data)
+		if bytes.HasPrefix(data, []byte("<proceed")) {
+			return replyAccepted
+		}
+		if bytes.HasPrefix(data, []byte("<failure")) {
+			return replyRefused
+		}
+	}
+	return replyOther
+}
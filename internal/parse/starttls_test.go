@@ -0,0 +1,202 @@
+package parse
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/jsimonetti/sniqueue/internal/parse/tls"
+)
+
+type segment struct {
+	fromServer bool
+	data       []byte
+	wantErr    error
+}
+
+func TestStartTLS(t *testing.T) {
+	hello := tcpSegment[32:]
+	client := func(data string) segment { return segment{data: []byte(data), wantErr: StartTLSPendingError} }
+	server := func(data string) segment {
+		return segment{fromServer: true, data: []byte(data), wantErr: StartTLSPendingError}
+	}
+	clientHello := segment{data: hello}
+
+	tests := []struct {
+		name     string
+		port     uint16
+		segments []segment
+	}{
+		{
+			name: "SMTP",
+			port: 25,
+			segments: []segment{
+				server("220 mx.example.com ESMTP\r\n"),
+				client("EHLO client.example.org\r\n"),
+				server("250-mx.example.com\r\n250-PIPELINING\r\n250 STARTTLS\r\n"),
+				client(""),
+				client("STARTTLS\r\n"),
+				server("220 2.0.0 Ready to start TLS\r\n"),
+				clientHello,
+			},
+		},
+		{
+			name: "SMTP refused",
+			port: 587,
+			segments: []segment{
+				client("EHLO client.example.org\r\n"),
+				client("STARTTLS\r\n"),
+				{fromServer: true, data: []byte("454 4.7.0 TLS not available\r\n"), wantErr: StartTLSDeclinedError},
+			},
+		},
+		{
+			name: "IMAP",
+			port: 143,
+			segments: []segment{
+				server("* OK IMAP4rev1 ready\r\n"),
+				client("a001 CAPABILITY\r\n"),
+				server("* CAPABILITY IMAP4rev1 STARTTLS LOGINDISABLED\r\na001 OK CAPABILITY completed\r\n"),
+				client("a002 starttls\r\n"),
+				server("a002 OK Begin TLS negotiation now\r\n"),
+				clientHello,
+			},
+		},
+		{
+			name: "IMAP refused",
+			port: 143,
+			segments: []segment{
+				client("a002 STARTTLS\r\n"),
+				{fromServer: true, data: []byte("a002 BAD STARTTLS not supported\r\n"), wantErr: StartTLSDeclinedError},
+			},
+		},
+		{
+			name: "POP3",
+			port: 110,
+			segments: []segment{
+				server("+OK POP3 server ready\r\n"),
+				client("STLS\r\n"),
+				server("+OK Begin TLS negotiation\r\n"),
+				clientHello,
+			},
+		},
+		{
+			name: "XMPP",
+			port: 5222,
+			segments: []segment{
+				client("<?xml version='1.0'?><stream:stream to='example.com' xmlns='jabber:client' xmlns:stream='http://etherx.jabber.org/streams' version='1.0'>"),
+				server("<stream:features><starttls xmlns='urn:ietf:params:xml:ns:xmpp-tls'><required/></starttls></stream:features>"),
+				client("<starttls xmlns='urn:ietf:params:xml:ns:xmpp-tls'/>"),
+				server("<proceed xmlns='urn:ietf:params:xml:ns:xmpp-tls'/>"),
+				clientHello,
+			},
+		},
+		{
+			name: "Plaintext only",
+			port: 25,
+			segments: append(
+				repeat(client("NOOP\r\n"), maxPlaintextSegments-1),
+				segment{data: []byte("NOOP\r\n"), wantErr: StartTLSDeclinedError},
+			),
+		},
+		{
+			name: "Untracked port",
+			port: 443,
+			segments: []segment{
+				{data: []byte("EHLO client.example.org\r\n"), wantErr: tls.UnmarshalNoTLSError},
+				clientHello,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewStartTLS(map[uint16]StartTLSProtocol{25: SMTP, 587: SMTP, 143: IMAP, 110: POP3, 5222: XMPP}, 4, time.Minute)
+			opts := Options{StartTLS: s}
+
+			var got networkLayer
+			var err error
+			for i, seg := range tt.segments {
+				got, err = ParseWithOptions(flowPacket(t, seg.fromServer, tt.port, seg.data), opts)
+				if !errors.Is(err, seg.wantErr) {
+					t.Fatalf("segment %d: ParseWithOptions() error = %v, wantErr %v", i, err, seg.wantErr)
+				}
+			}
+			if err == nil && got.DomainName() != "dns.google" {
+				t.Errorf("DomainName() = %q, want %q", got.DomainName(), "dns.google")
+			}
+			if len(s.flows) != 0 {
+				t.Errorf("%d flows left after the last segment", len(s.flows))
+			}
+		})
+	}
+}
+
+func TestStartTLS_Expire(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	s := NewStartTLS(map[uint16]StartTLSProtocol{25: SMTP}, 1, time.Second)
+	s.now = func() time.Time { return now }
+	opts := Options{StartTLS: s}
+
+	if _, err := ParseWithOptions(flowPacket(t, false, 25, []byte("EHLO a\r\n")), opts); !errors.Is(err, StartTLSPendingError) {
+		t.Fatalf("ParseWithOptions() error = %v, want %v", err, StartTLSPendingError)
+	}
+	// A second flow does not fit
+	packet := ip4Packet(t, net.IP{192, 0, 2, 3}, net.IP{198, 51, 100, 25}, 6, tcpHeader(50001, 25, []byte("EHLO b\r\n")))
+	if _, err := ParseWithOptions(packet, opts); !errors.Is(err, startTLSFullError) {
+		t.Fatalf("ParseWithOptions() error = %v, want %v", err, startTLSFullError)
+	}
+
+	now = now.Add(time.Second)
+	s.Expire()
+	if len(s.flows) != 0 {
+		t.Fatalf("%d flows left after Expire()", len(s.flows))
+	}
+	if _, err := ParseWithOptions(packet, opts); !errors.Is(err, StartTLSPendingError) {
+		t.Fatalf("ParseWithOptions() error = %v, want %v", err, StartTLSPendingError)
+	}
+}
+
+func TestParseStartTLSProtocol(t *testing.T) {
+	for _, name := range []string{"smtp", "imap", "pop3", "xmpp"} {
+		p, err := ParseStartTLSProtocol(name)
+		if err != nil {
+			t.Fatalf("ParseStartTLSProtocol(%q) error = %v", name, err)
+		}
+		if p.String() != name {
+			t.Errorf("String() = %q, want %q", p.String(), name)
+		}
+	}
+	if _, err := ParseStartTLSProtocol("ftp"); err == nil {
+		t.Errorf("ParseStartTLSProtocol(%q) succeeded", "ftp")
+	}
+}
+
+// flowPacket builds an IPv4 packet of a flow between a client on port 50000
+// and a server on port.
+func flowPacket(t testing.TB, fromServer bool, port uint16, data []byte) []byte {
+	t.Helper()
+	client, server := net.IP{192, 0, 2, 1}, net.IP{198, 51, 100, 25}
+	if fromServer {
+		return ip4Packet(t, server, client, 6, tcpHeader(port, 50000, data))
+	}
+	return ip4Packet(t, client, server, 6, tcpHeader(50000, port, data))
+}
+
+// tcpHeader builds a TCP segment without options carrying data.
+func tcpHeader(src, dst uint16, data []byte) []byte {
+	segment := make([]byte, 20, 20+len(data))
+	binary.BigEndian.PutUint16(segment[0:2], src)
+	binary.BigEndian.PutUint16(segment[2:4], dst)
+	segment[12] = 5 << 4
+	segment[13] = 0x18 // PSH, ACK
+	return append(segment, data...)
+}
+
+func repeat(s segment, n int) []segment {
+	segments := make([]segment, n)
+	for i := range segments {
+		segments[i] = s
+	}
+	return segments
+}
@@ -0,0 +1,71 @@
+package parse
+
+// stream reassembles the bytes sent by one side of a TCP flow from its
+// segments, which may be lost, retransmitted or reordered.
+type stream struct {
+	// next is the sequence number of the byte that follows data, valid once
+	// started is set
+	next    uint32
+	started bool
+	data    []byte
+	// ahead holds copies of the segments that arrived before the ones
+	// preceding them, and aheadSize their total length
+	ahead     []streamSegment
+	aheadSize int
+}
+
+type streamSegment struct {
+	sequence uint32
+	data     []byte
+}
+
+// start makes the stream start at sequence, instead of at the first segment
+// added.
+func (s *stream) start(sequence uint32) {
+	s.next, s.started = sequence, true
+}
+
+// add adds a segment to the stream, which starts at the first segment added
+// unless it was started.
+// Segments beyond the end of data are held until the gap before them is
+// filled, as long as data and the held segments fit in limit bytes. add
+// reports whether data grew.
+func (s *stream) add(sequence uint32, data []byte, limit int) bool {
+	if !s.started {
+		s.next, s.started = sequence, true
+	}
+	if !s.append(sequence, data) {
+		if int32(sequence-s.next) > 0 && len(s.data)+s.aheadSize+len(data) <= limit {
+			s.ahead = append(s.ahead, streamSegment{sequence: sequence, data: append([]byte(nil), data...)})
+			s.aheadSize += len(data)
+		}
+		return false
+	}
+
+	// The segment may have filled the gap before held segments
+	for i := 0; i < len(s.ahead); {
+		segment := s.ahead[i]
+		if int32(segment.sequence-s.next) > 0 {
+			i++
+			continue
+		}
+		s.append(segment.sequence, segment.data)
+		s.aheadSize -= len(segment.data)
+		s.ahead = append(s.ahead[:i], s.ahead[i+1:]...)
+		i = 0
+	}
+	return true
+}
+
+// append appends the bytes of a segment that follow data. It reports whether
+// there were any.
+func (s *stream) append(sequence uint32, data []byte) bool {
+	offset := int32(s.next - sequence)
+	if offset < 0 || int(offset) >= len(data) {
+		// A gap before the segment, or a retransmission of bytes we have
+		return false
+	}
+	s.data = append(s.data, data[offset:]...)
+	s.next += uint32(len(data) - int(offset))
+	return true
+}
@@ -3,30 +3,174 @@ package parse
 import (
 	"encoding/binary"
 	"errors"
+	"strings"
 
+	"github.com/jsimonetti/sniqueue/internal/parse/dns"
+	"github.com/jsimonetti/sniqueue/internal/parse/http"
 	"github.com/jsimonetti/sniqueue/internal/parse/tls"
 )
 
 var unmarshalTCPError = errors.New("insufficient bytes to Unmarshal TCP")
+var unmarshalTCPControlError = errors.New("TCP RST or SYN/ACK segment cannot carry a ClientHello")
+
+// TCPFlags are the control bits of a TCP segment.
+type TCPFlags uint8
+
+const (
+	TCPFlagFIN TCPFlags = 1 << iota
+	TCPFlagSYN
+	TCPFlagRST
+	TCPFlagPSH
+	TCPFlagACK
+	TCPFlagURG
+	TCPFlagECE
+	TCPFlagCWR
+)
+
+var tcpFlagNames = [...]string{"FIN", "SYN", "RST", "PSH", "ACK", "URG", "ECE", "CWR"}
+
+func (f TCPFlags) String() string {
+	var b strings.Builder
+	for i, name := range tcpFlagNames {
+		if f&(1<<i) == 0 {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte('|')
+		}
+		b.WriteString(name)
+	}
+	return b.String()
+}
+
+// Kinds of the TCP options that are decoded
+const (
+	tcpOptionEnd           = 0
+	tcpOptionNOP           = 1
+	tcpOptionMSS           = 2
+	tcpOptionWindowScale   = 3
+	tcpOptionSACKPermitted = 4
+	tcpOptionTimestamp     = 8
+	tcpOptionFastOpen      = 34
+	// tcpOptionExperimental carries TCP Fast Open with the magic 0xf989
+	// in implementations that predate option 34
+	tcpOptionExperimental = 254
+	tcpFastOpenMagic      = 0xf989
+)
+
+// TCPOptions are the decoded options of a TCP segment. Most of them are only
+// sent in SYN segments.
+type TCPOptions struct {
+	MSS uint16
+	// WindowScale is the shift count of the window scale option, only
+	// valid when WindowScaling is set.
+	WindowScale   uint8
+	WindowScaling bool
+	SACKPermitted bool
+	Timestamp     bool
+	// FastOpen is set when the segment carries a TCP Fast Open option.
+	// FastOpenCookie is empty for a cookie request.
+	FastOpen       bool
+	FastOpenCookie []byte
+}
+
+// unmarshal decodes the options area of a TCP header. Decoding stops at the
+// first malformed option, the data offset of the header still tells where
+// the data starts.
+func (o *TCPOptions) unmarshal(options []byte) {
+	for cursor := 0; cursor < len(options); {
+		kind := options[cursor]
+		if kind == tcpOptionEnd {
+			return
+		}
+		if kind == tcpOptionNOP {
+			cursor++
+			continue
+		}
+		if cursor+2 > len(options) {
+			return
+		}
+		length := int(options[cursor+1])
+		if length < 2 || cursor+length > len(options) {
+			return
+		}
+		value := options[cursor+2 : cursor+length]
+		switch {
+		case kind == tcpOptionMSS && len(value) == 2:
+			o.MSS = binary.BigEndian.Uint16(value)
+		case kind == tcpOptionWindowScale && len(value) == 1:
+			o.WindowScale, o.WindowScaling = value[0], true
+		case kind == tcpOptionSACKPermitted:
+			o.SACKPermitted = true
+		case kind == tcpOptionTimestamp:
+			o.Timestamp = true
+		case kind == tcpOptionFastOpen:
+			o.FastOpen, o.FastOpenCookie = true, value
+		case kind == tcpOptionExperimental && len(value) >= 2 && binary.BigEndian.Uint16(value) == tcpFastOpenMagic:
+			o.FastOpen, o.FastOpenCookie = true, value[2:]
+		}
+		cursor += length
+	}
+}
 
 type TCP struct {
 	SourcePort      uint16
 	DestinationPort uint16
+	Flags           TCPFlags
+	Options         TCPOptions
 	Hello           tls.ClientHello
+	// HTTP is set for cleartext HTTP requests instead of Hello. For flows
+	// followed by HTTPRequests, it is set on the segments of the client
+	// from the one that completes the Host header.
+	HTTP *http.Request
+	// DNS is set for DNS messages instead of Hello.
+	DNS *dns.Message
+	// Detected is the protocol the data looks like by its content.
+	Detected Detection
+	// VPN is set when the segment starts an SSH, OpenVPN or Tor connection.
+	VPN Classification
+	// Server is set on the segments of the server from the one that
+	// completes its certificate, when the flow is followed by
+	// ServerCertificates.
+	Server *tls.ServerHello
 }
 
 func (p *TCP) domainName() string {
+	if p.HTTP != nil {
+		return p.HTTP.Host
+	}
+	if p.DNS != nil {
+		return p.DNS.Name()
+	}
 	return p.Hello.SNI
 }
 
-func (p *TCP) unmarshal(payload []byte) error {
-	// add code to skip SYN, SYN/ACK, RST, etc
+func (p *TCP) dnsMessage() *dns.Message {
+	return p.DNS
+}
+
+func (p *TCP) clientHello() *tls.ClientHello {
+	return &p.Hello
+}
+
+func (p *TCP) ja4() string {
+	return p.Hello.JA4(tls.JA4TCP)
+}
+
+func (p *TCP) unmarshal(payload []byte, opts Options) error {
 	if len(payload) < 20 { // truncated / fragmented packet
 		return unmarshalTCPError
 	}
 
 	p.SourcePort = binary.BigEndian.Uint16(payload[:2])
 	p.DestinationPort = binary.BigEndian.Uint16(payload[2:4])
+	p.Flags = TCPFlags(payload[13])
+
+	// A reset or the reply of the server to a SYN never carries a
+	// ClientHello, even with TCP Fast Open data
+	if p.Flags&TCPFlagRST != 0 || p.Flags&(TCPFlagSYN|TCPFlagACK) == TCPFlagSYN|TCPFlagACK {
+		return unmarshalTCPControlError
+	}
 
 	dataOffset := int(payload[12] >> 4)
 	if dataOffset < 5 {
@@ -35,17 +179,59 @@ func (p *TCP) unmarshal(payload []byte) error {
 	}
 
 	cursor := int(dataOffset) * 4
-	if cursor == len(payload) { // no data in packet
-		return tls.UnmarshalNoTLSError
-	}
 	if cursor > len(payload) {
 		// TCP data offset greater than packet length
 		return unmarshalTCPError
 	}
+	p.Options.unmarshal(payload[20:cursor])
+	if cursor == len(payload) { // no data in packet
+		return tls.UnmarshalNoTLSError
+	}
 
-	// Only handle TLS
-	if payload[cursor] == 0x16 {
-		return p.Hello.Unmarshal(payload[cursor+1:])
+	// The data of a SYN is the start of the stream with TCP Fast Open, so a
+	// ClientHello is parsed like in any other segment
+	if p.SourcePort == dnsPort || p.DestinationPort == dnsPort {
+		p.DNS = &dns.Message{}
+		return opts.wrapError(LayerDNS, cursor, p.DNS.UnmarshalTCP(payload[cursor:]))
+	}
+
+	// The hello is recognised by its content, so TLS on any port is parsed
+	p.Detected = DetectTCP(payload[cursor:])
+	if p.Detected.Protocol != DetectedTLS {
+		// SSH and OpenVPN carry no name, their classification is the result
+		if p.VPN = ClassifyTCP(payload[cursor:]); p.VPN.Protocol != VPNNone {
+			return nil
+		}
+		err := p.unmarshalHTTP(payload[cursor:])
+		if err != tls.UnmarshalNoTLSError {
+			return opts.wrapError(LayerHTTP, cursor, err)
+		}
+		return opts.wrapError(LayerTLS, cursor, err)
+	}
+
+	err := p.Hello.UnmarshalWithOptions(payload[cursor:], opts.tls())
+	if err == nil && !opts.SNIOnly {
+		// Tor is recognised by the names and the extensions of its hello
+		p.VPN = ClassifyHello(&p.Hello)
+	}
+	if p.Hello.Incomplete(err) {
+		// The hello continues in the next segment, but we have the SNI
+		return nil
+	}
+	return opts.wrapError(LayerTLS, cursor, err)
+}
+
+// unmarshalHTTP extracts the Host of a cleartext HTTP request.
+func (p *TCP) unmarshalHTTP(payload []byte) error {
+	request := &http.Request{}
+	err := request.Unmarshal(payload)
+	if err == http.UnmarshalNoHTTPError {
+		return tls.UnmarshalNoTLSError
+	}
+	p.HTTP = request
+	if request.Incomplete(err) {
+		// The header continues in the next segment, but we have the Host
+		return nil
 	}
-	return tls.UnmarshalNoTLSError
+	return err
 }
@@ -3,6 +3,7 @@ package parse
 import (
 	"testing"
 
+	"github.com/jsimonetti/sniqueue/internal/parse/http"
 	"github.com/jsimonetti/sniqueue/internal/parse/tls"
 
 	"github.com/google/go-cmp/cmp"
@@ -62,7 +63,28 @@ func TestTCP_unmarshal(t *testing.T) {
 			want: &TCP{
 				SourcePort:      64115,
 				DestinationPort: 443,
-				Hello:           tls.ClientHello{SNI: "dns.google"},
+				Flags:           TCPFlagPSH | TCPFlagACK,
+				Options:         TCPOptions{Timestamp: true},
+				Detected:        Detection{Protocol: DetectedTLS, Confidence: ConfidenceHigh},
+				Hello: tls.ClientHello{
+					SNI:     "dns.google",
+					Version: 0x0303,
+					CipherSuites: []uint16{
+						0xc030, 0xc02c, 0xc028, 0xc024, 0xc014, 0xc00a, 0x009f, 0x006b, 0x0039, 0xcca9,
+						0xcca8, 0xccaa, 0xff85, 0x00c4, 0x0088, 0x0081, 0x009d, 0x003d, 0x0035, 0x00c0,
+						0x0084, 0xc02f, 0xc02b, 0xc027, 0xc023, 0xc013, 0xc009, 0x009e, 0x0067, 0x0033,
+						0x00be, 0x0045, 0x009c, 0x003c, 0x002f, 0x00ba, 0x0041, 0xc011, 0xc007, 0x0005,
+						0x0004, 0xc012, 0xc008, 0x0016, 0x000a, 0x00ff,
+					},
+					Extensions:      []uint16{0x0000, 0x000b, 0x000a, 0x000d, 0x0010},
+					ALPN:            []string{"h2", "http/1.1"},
+					SupportedGroups: []uint16{0x001d, 0x0017, 0x0018},
+					PointFormats:    []uint8{0x00},
+					SignatureAlgorithms: []uint16{
+						0x0601, 0x0603, 0xefef, 0x0501, 0x0503, 0x0401, 0x0403, 0xeeee, 0xeded, 0x0301,
+						0x0303, 0x0201, 0x0203,
+					},
+				},
 			},
 		},
 		{
@@ -79,12 +101,33 @@ func TestTCP_unmarshal(t *testing.T) {
 				DestinationPort: 443,
 			},
 		},
+		{
+			name: "Plain HTTP",
+			payload: append([]byte{
+				0xc3, 0x50, 0x00, 0x50, 0x00, 0x00, 0x00, 0x01,
+				0x00, 0x00, 0x00, 0x01, 0x50, 0x18, 0x01, 0xf6,
+				0x00, 0x00, 0x00, 0x00,
+			}, "GET / HTTP/1.1\r\nHost: example.com\r\nAccept-Encoding: gz"...),
+			want: &TCP{
+				SourcePort:      50000,
+				DestinationPort: 80,
+				Flags:           TCPFlagPSH | TCPFlagACK,
+				HTTP: &http.Request{
+					Method:  "GET",
+					Target:  "/",
+					Version: "HTTP/1.1",
+					Host:    "example.com",
+					Hosts:   []string{"example.com"},
+					Partial: true,
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			got := &TCP{}
-			if err := got.unmarshal(tt.payload); err != nil {
+			if err := got.unmarshal(tt.payload, Options{}); err != nil {
 				if tt.wantErr {
 					return
 				}
@@ -99,3 +142,120 @@ func TestTCP_unmarshal(t *testing.T) {
 		})
 	}
 }
+
+func TestTCP_flags(t *testing.T) {
+	syn := []byte{
+		0xc3, 0x50, 0x01, 0xbb, 0x00, 0x00, 0x00, 0x01,
+		0x00, 0x00, 0x00, 0x00, 0xd0, 0x02, 0xfa, 0xf0,
+		0x00, 0x00, 0x00, 0x00,
+		0x02, 0x04, 0x05, 0xb4, // MSS 1460
+		0x04, 0x02, // SACK permitted
+		0x08, 0x0a, 0x8d, 0x98, 0x88, 0x14, 0x00, 0x00, 0x00, 0x00, // Timestamp
+		0x01, 0x03, 0x03, 0x07, // NOP, window scale 7
+		0x22, 0x0a, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, // Fast Open cookie
+		0x01, 0x01,
+	}
+	synOptions := TCPOptions{
+		MSS:            1460,
+		WindowScale:    7,
+		WindowScaling:  true,
+		SACKPermitted:  true,
+		Timestamp:      true,
+		FastOpen:       true,
+		FastOpenCookie: []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08},
+	}
+	withFlags := func(segment []byte, flags TCPFlags) []byte {
+		segment = append([]byte(nil), segment...)
+		segment[13] = byte(flags)
+		return segment
+	}
+
+	tests := []struct {
+		name        string
+		payload     []byte
+		wantFlags   TCPFlags
+		wantOptions TCPOptions
+		wantSNI     string
+		wantErr     error
+	}{
+		{
+			name:        "SYN",
+			payload:     syn,
+			wantFlags:   TCPFlagSYN,
+			wantOptions: synOptions,
+			wantErr:     tls.UnmarshalNoTLSError,
+		},
+		{
+			name:        "Fast Open SYN with ClientHello",
+			payload:     append(syn[:len(syn):len(syn)], tcpHello4[52:]...),
+			wantFlags:   TCPFlagSYN,
+			wantOptions: synOptions,
+			wantSNI:     "dns.google",
+		},
+		{
+			name: "Experimental Fast Open cookie request",
+			payload: append([]byte{
+				0xc3, 0x50, 0x01, 0xbb, 0x00, 0x00, 0x00, 0x01,
+				0x00, 0x00, 0x00, 0x00, 0x60, 0x02, 0xfa, 0xf0,
+				0x00, 0x00, 0x00, 0x00, 0xfe, 0x04, 0xf9, 0x89,
+			}, tcpHello4[52:]...),
+			wantFlags:   TCPFlagSYN,
+			wantOptions: TCPOptions{FastOpen: true, FastOpenCookie: []byte{}},
+			wantSNI:     "dns.google",
+		},
+		{
+			name:      "SYN/ACK with data",
+			payload:   withFlags(append(syn[:len(syn):len(syn)], tcpHello4[52:]...), TCPFlagSYN|TCPFlagACK),
+			wantFlags: TCPFlagSYN | TCPFlagACK,
+			wantErr:   unmarshalTCPControlError,
+		},
+		{
+			name:      "RST",
+			payload:   withFlags(tcpHeader(50000, 443, []byte("reset")), TCPFlagRST|TCPFlagACK),
+			wantFlags: TCPFlagRST | TCPFlagACK,
+			wantErr:   unmarshalTCPControlError,
+		},
+		{
+			name:      "FIN",
+			payload:   withFlags(tcpHeader(50000, 443, nil), TCPFlagFIN|TCPFlagACK),
+			wantFlags: TCPFlagFIN | TCPFlagACK,
+			wantErr:   tls.UnmarshalNoTLSError,
+		},
+		{
+			name: "Malformed option",
+			payload: []byte{
+				0xc3, 0x50, 0x01, 0xbb, 0x00, 0x00, 0x00, 0x01,
+				0x00, 0x00, 0x00, 0x00, 0x70, 0x02, 0xfa, 0xf0,
+				0x00, 0x00, 0x00, 0x00, 0x02, 0x04, 0x05, 0xb4,
+				0x08, 0x01, 0x00, 0x00,
+			},
+			wantFlags:   TCPFlagSYN,
+			wantOptions: TCPOptions{MSS: 1460},
+			wantErr:     tls.UnmarshalNoTLSError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := &TCP{}
+			if err := got.unmarshal(tt.payload, Options{}); err != tt.wantErr {
+				t.Fatalf("unexpected error: want %v, got %v", tt.wantErr, err)
+			}
+			if got.Flags != tt.wantFlags {
+				t.Fatalf("unexpected flags: want %s, got %s", tt.wantFlags, got.Flags)
+			}
+			if diff := cmp.Diff(tt.wantOptions, got.Options); diff != "" {
+				t.Fatalf("unexpected options (-want +got):\n%s", diff)
+			}
+			if got.Hello.SNI != tt.wantSNI {
+				t.Fatalf("unexpected SNI: want %q, got %q", tt.wantSNI, got.Hello.SNI)
+			}
+		})
+	}
+}
+
+func TestTCPFlags_String(t *testing.T) {
+	if got := (TCPFlagSYN | TCPFlagACK | TCPFlagCWR).String(); got != "SYN|ACK|CWR" {
+		t.Fatalf("unexpected flags %q", got)
+	}
+}
@@ -1,109 +1,340 @@
 package tls
 
 import (
-	"encoding/binary"
+	"errors"
+	"fmt"
+	"unsafe"
 )
 
+const (
+	recordTypeHandshake      uint8 = 0x16
+	handshakeTypeClientHello uint8 = 0x01
+)
+
+const (
+	extensionServerName           uint16 = 0x0000
+	extensionSupportedGroups      uint16 = 0x000a
+	extensionECPointFormats       uint16 = 0x000b
+	extensionSignatureAlgorithms  uint16 = 0x000d
+	extensionALPN                 uint16 = 0x0010
+	extensionSupportedVersions    uint16 = 0x002b
+	extensionEncryptedClientHello uint16 = 0xfe0d
+)
+
+// Options control how much of a ClientHello is decoded.
+type Options struct {
+	// SNIOnly skips decoding everything but the server name and ECH
+	// extensions. This avoids allocating the metadata slices.
+	SNIOnly bool
+	// NoCopy makes the strings of the hello refer to the payload instead of
+	// copying them. They are only valid as long as the payload is not
+	// modified or reused.
+	NoCopy bool
+}
+
+// string returns b as a string, without copying it when NoCopy is set.
+func (o Options) string(b []byte) string {
+	if o.NoCopy && len(b) > 0 {
+		return unsafe.String(&b[0], len(b))
+	}
+	return string(b)
+}
+
 type ClientHello struct {
 	SNI string
+	// ECH is set when the hello carries an encrypted_client_hello extension.
+	// In that case SNI holds the public (outer) name, not the real destination.
+	ECH *EncryptedClientHello
+	// Partial is set when the hello continues beyond the parsed bytes, the
+	// fields hold whatever was decoded up to that point.
+	Partial bool
+
+	// The fields below are left empty when decoding with Options.SNIOnly.
+	Version             uint16
+	CipherSuites        []uint16
+	Extensions          []uint16
+	ALPN                []string
+	SupportedVersions   []uint16
+	SupportedGroups     []uint16
+	PointFormats        []uint8
+	SignatureAlgorithms []uint16
+	// MalformedMetadata is set when one of the extensions above could not
+	// be decoded. Its values are missing, but the SNI and the other
+	// extensions are still decoded.
+	MalformedMetadata bool
 }
 
+// Reset clears the hello so it can be decoded into again. The slices keep
+// their capacity, which avoids allocating them for every hello.
+func (m *ClientHello) Reset() {
+	*m = ClientHello{
+		CipherSuites:        m.CipherSuites[:0],
+		Extensions:          m.Extensions[:0],
+		ALPN:                m.ALPN[:0],
+		SupportedVersions:   m.SupportedVersions[:0],
+		SupportedGroups:     m.SupportedGroups[:0],
+		PointFormats:        m.PointFormats[:0],
+		SignatureAlgorithms: m.SignatureAlgorithms[:0],
+	}
+}
+
+// Unmarshal decodes the full ClientHello from a TLS record.
 func (m *ClientHello) Unmarshal(payload []byte) error {
-	payloadLength := uint16(len(payload))
-	if payloadLength < uint16(5) {
-		return UnmarshalClientHelloError
+	return m.UnmarshalWithOptions(payload, Options{})
+}
+
+// UnmarshalWithOptions decodes the ClientHello from a TLS record as
+// specified by opts. The payload must start at the record content type.
+func (m *ClientHello) UnmarshalWithOptions(payload []byte, opts Options) error {
+	return m.partial(m.unmarshalRecord(reader{b: payload}, opts))
+}
+
+func (m *ClientHello) unmarshalRecord(r reader, opts Options) error {
+	fragment, err := readHandshakeRecord(&r)
+	if err != nil {
+		return err
 	}
 
-	handshakeProtocol := payload[4]
-	// Only attempt to match on client hellos
-	if handshakeProtocol != 0x01 {
-		// test for GQUIC
-		if payloadLength >= 16 {
-			if payload[4] == 0x43 && payload[5] == 0x48 && payload[6] == 0x4c && payload[7] == 0x4f { // GQUIC's CHLO
-				//tagNum := binary.BigEndian.Uint16(payload[8:10]) // total number of variable length tags
-				tagNum := uint16(payload[8]) + uint16(payload[9])<<8
-				tagOffset := 12 // start of the first tag
-				payloadLength := int(payloadLength)
-
-				for tagNum > 0 && payloadLength >= tagOffset+8 {
-					tagType := binary.LittleEndian.Uint32(payload[tagOffset : tagOffset+4])
-					if tagType == 4804179 {
-						tagLen := binary.LittleEndian.Uint32(payload[tagOffset+4 : tagOffset+8])
-						tagStart := tagOffset + int(tagNum)*8
-						tagEnd := tagStart + int(tagLen)
-						if payloadLength > tagOffset+int(tagNum)*8+tagEnd {
-							m.SNI = string(payload[tagStart:tagEnd])
-							return nil
-						}
-						tagOffset = tagOffset + 8
-					}
-					tagNum--
-				}
-				return UnmarshalNoTLSHandshakeError
+	// The common case is a hello in a single record, which may continue in
+	// the next segment. Parse what we have got.
+	if r.empty() || handshakeComplete(fragment.b) {
+		return m.unmarshalHandshake(fragment, opts)
+	}
+
+	// The hello is split over several records, which is allowed by the spec
+	// and used to evade filters that only look at the first record.
+	// Concatenate the fragments from the consecutive handshake records.
+	message := append([]byte(nil), fragment.b...)
+	for !r.empty() && !handshakeComplete(message) {
+		fragment, err := readHandshakeRecord(&r)
+		if err != nil {
+			if err == UnmarshalNoTLSError {
+				// Not a handshake record, the message ends here
+				break
 			}
+			return err
 		}
-		return UnmarshalNoTLSHandshakeError
+		message = append(message, fragment.b...)
 	}
+	return m.unmarshalHandshake(reader{b: message}, opts)
+}
 
-	offset, baseOffset, extensionOffset := uint16(0), uint16(42), uint16(2)
-	if baseOffset+2 > payloadLength {
-		return UnmarshalClientHelloError
+// readHandshakeRecord reads the header of a handshake record and returns a
+// reader for its fragment. The fragment is cut short when the record
+// continues beyond r.
+func readHandshakeRecord(r *reader) (reader, error) {
+	contentType, err := r.uint8("record content type")
+	if err != nil {
+		return reader{}, err
+	}
+	if contentType != recordTypeHandshake {
+		return reader{}, UnmarshalNoTLSError
 	}
+	version, err := r.uint16("record version")
+	if err != nil {
+		return reader{}, err
+	}
+	if version>>8 != 0x03 {
+		return reader{}, UnmarshalNoTLSError
+	}
+	length, err := r.uint16("record length")
+	if err != nil {
+		return reader{}, err
+	}
+	return r.sub(int(length)), nil
+}
 
-	// Get the length of the session ID
-	sessionIDLength := uint16(payload[baseOffset])
-	if (sessionIDLength + baseOffset + 3) > payloadLength {
-		return UnmarshalClientHelloError
+// handshakeComplete reports whether message holds a full handshake message.
+func handshakeComplete(message []byte) bool {
+	if len(message) < 4 {
+		return false
 	}
+	length := int(message[1])<<16 | int(message[2])<<8 | int(message[3])
+	return len(message) >= 4+length
+}
 
-	// Get the length of the ciphers
-	cipherLenStart := baseOffset + sessionIDLength + 1
-	cipherLen := binary.BigEndian.Uint16(payload[cipherLenStart : cipherLenStart+2])
+// UnmarshalHandshake decodes the ClientHello from a handshake message, as
+// carried in QUIC CRYPTO frames. The payload must start at the handshake type.
+func (m *ClientHello) UnmarshalHandshake(payload []byte, opts Options) error {
+	return m.partial(m.unmarshalHandshake(reader{b: payload}, opts))
+}
 
-	offset = baseOffset + sessionIDLength + cipherLen + 2
-	if offset+2 > payloadLength {
-		return UnmarshalClientHelloError
+// partial marks the hello as partial when err is a *TruncatedError.
+func (m *ClientHello) partial(err error) error {
+	if _, ok := err.(*TruncatedError); ok {
+		m.Partial = true
 	}
+	return err
+}
 
-	// Get the length of the compression methods list
-	compressionLen := uint16(payload[offset+1])
-	offset += compressionLen + 2
-	if offset+2 > payloadLength {
-		return UnmarshalClientHelloError
-	}
+// Incomplete reports whether err only signals that the hello continues
+// beyond the parsed bytes while the server name was already found. Callers
+// can then act on the SNI without waiting for the rest of the hello.
+func (m *ClientHello) Incomplete(err error) bool {
+	return m.Partial && m.SNI != "" && errors.Is(err, UnmarshalClientHelloError)
+}
 
-	// Get the length of the extensions
-	extensionsLen := binary.BigEndian.Uint16(payload[offset : offset+2])
+func (m *ClientHello) unmarshalHandshake(r reader, opts Options) error {
+	handshakeType, err := r.uint8("handshake type")
+	if err != nil {
+		return err
+	}
+	// Only attempt to match on client hellos
+	if handshakeType != handshakeTypeClientHello {
+		return UnmarshalNoTLSHandshakeError
+	}
+	length, err := r.uint24("handshake length")
+	if err != nil {
+		return err
+	}
 
-	// Add the full offset to were the extensions start
-	extensionOffset += offset
+	body := r.sub(length)
+	return m.unmarshalBody(body, opts, false)
+}
 
-	if extensionOffset+2 > payloadLength {
+// unmarshalBody decodes the ClientHello body. DTLS hellos have a different
+// version major and carry a cookie after the session id.
+func (m *ClientHello) unmarshalBody(r reader, opts Options, dtls bool) error {
+	version, err := r.uint16("legacy_version")
+	if err != nil {
+		return err
+	}
+	if (!dtls && version>>8 != 0x03) || (dtls && version>>8 != 0xfe) {
+		return UnmarshalTLSVersionError
+	}
+	if _, err := r.bytes(32, "random"); err != nil {
+		return err
+	}
+	sessionID, err := r.vector8("legacy_session_id")
+	if err != nil {
+		return err
+	}
+	if len(sessionID) > 32 {
 		return UnmarshalClientHelloError
 	}
+	if dtls {
+		if _, err := r.vector8("cookie"); err != nil {
+			return err
+		}
+	}
+	cipherSuites, err := r.vector16("cipher_suites")
+	if err != nil {
+		return err
+	}
+	if len(cipherSuites)%2 != 0 {
+		return UnmarshalClientHelloError
+	}
+	if _, err := r.vector8("legacy_compression_methods"); err != nil {
+		return err
+	}
 
-	for extensionOffset < extensionsLen+offset && extensionOffset+13 <= payloadLength {
-		extensionID := binary.BigEndian.Uint16(payload[extensionOffset : extensionOffset+2])
-		extensionOffset += 2
-
-		extensionLen := binary.BigEndian.Uint16(payload[extensionOffset : extensionOffset+2])
-		extensionOffset += 2
+	if !opts.SNIOnly {
+		m.Version = version
+		m.CipherSuites = appendUint16List(m.CipherSuites[:0], cipherSuites)
+	}
 
-		if extensionID == 0 {
-			// We don't need the server name list length or name_type, so skip that
-			extensionOffset += 3
+	// A hello without extensions is valid, but carries no SNI
+	if r.empty() {
+		return nil
+	}
+	extensionsLength, err := r.uint16("extensions length")
+	if err != nil {
+		return err
+	}
+	extensions := r.sub(int(extensionsLength))
+	err = m.unmarshalExtensions(extensions, opts)
+	if m.ECH != nil {
+		m.ECH.OuterSNI = m.SNI
+	}
+	if err != nil {
+		return err
+	}
+	if int(extensionsLength) > len(extensions.b) {
+		// The remainder of the extensions is in a later packet
+		return extensions.truncated("extensions", int(extensionsLength))
+	}
+	return nil
+}
 
-			// Get the length of the domain name
-			nameLength := binary.BigEndian.Uint16(payload[extensionOffset : extensionOffset+2])
-			extensionOffset += 2
+func (m *ClientHello) unmarshalExtensions(extensions reader, opts Options) error {
+	for !extensions.empty() {
+		extensionID, err := extensions.uint16("extension type")
+		if err != nil {
+			return err
+		}
+		extensionLength, err := extensions.uint16("extension length")
+		if err != nil {
+			return err
+		}
+		if len(extensions.b)-extensions.off < int(extensionLength) {
+			return extensions.truncated(extensionName(extensionID), int(extensionLength))
+		}
+		data := extensions.sub(int(extensionLength))
+		extension := data.b
 
-			if extensionOffset+nameLength > payloadLength || extensionOffset+nameLength < extensionOffset {
-				return UnmarshalClientHelloError
+		switch extensionID {
+		case extensionServerName:
+			if err := m.unmarshalServerName(data, opts); err != nil {
+				return err
 			}
-			m.SNI = string(payload[extensionOffset : extensionOffset+nameLength])
+		case extensionEncryptedClientHello:
+			m.ECH = &EncryptedClientHello{}
+			if err := m.ECH.unmarshal(extension); err != nil {
+				return err
+			}
+		}
+		if !opts.SNIOnly {
+			m.Extensions = append(m.Extensions, extensionID)
+			if err := m.unmarshalExtension(extensionID, extension, opts); err != nil {
+				// The metadata is not needed to match the hello, so a
+				// malformed extension must not hide the SNI
+				m.MalformedMetadata = true
+			}
+		}
+	}
+	return nil
+}
+
+// unmarshalServerName extracts the host_name from a server_name extension.
+func (m *ClientHello) unmarshalServerName(r reader, opts Options) error {
+	names, err := r.subVector16("server_name_list")
+	if err != nil {
+		return err
+	}
+	for !names.empty() {
+		nameType, err := names.uint8("server_name name_type")
+		if err != nil {
+			return err
+		}
+		name, err := names.vector16("server_name host_name")
+		if err != nil {
+			return err
+		}
+		// host_name is the only defined name type
+		if nameType == 0 {
+			m.SNI = opts.string(name)
 			return nil
 		}
-		extensionOffset += extensionLen
 	}
 	return nil
 }
+
+func extensionName(id uint16) string {
+	switch id {
+	case extensionServerName:
+		return "extension server_name"
+	case extensionSupportedGroups:
+		return "extension supported_groups"
+	case extensionECPointFormats:
+		return "extension ec_point_formats"
+	case extensionSignatureAlgorithms:
+		return "extension signature_algorithms"
+	case extensionALPN:
+		return "extension application_layer_protocol_negotiation"
+	case extensionSupportedVersions:
+		return "extension supported_versions"
+	case extensionEncryptedClientHello:
+		return "extension encrypted_client_hello"
+	}
+	return fmt.Sprintf("extension %#04x", id)
+}
@@ -1,15 +1,19 @@
 package tls
 
 import (
+	"encoding/binary"
+	"errors"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 )
 
 func Test_clientHelloMsg_unmarshalTCP(t *testing.T) {
 	tests := []struct {
 		name    string
 		quic    bool
+		opts    Options
 		want    *ClientHello
 		payload []byte
 		wantErr bool
@@ -17,10 +21,17 @@ func Test_clientHelloMsg_unmarshalTCP(t *testing.T) {
 		{
 			name: "TCP ClientHello",
 			want: &ClientHello{
-				SNI: "api.dropboxapi.com",
+				SNI:                 "api.dropboxapi.com",
+				Version:             0x0303,
+				CipherSuites:        []uint16{0x00ff, 0xc02c, 0xc02b, 0xc024, 0xc023, 0xc00a, 0xc009, 0xc008, 0xc030, 0xc02f, 0xc028, 0xc027, 0xc014, 0xc013, 0xc012, 0x009f, 0x009e, 0x006b, 0x0067, 0x0039, 0x0033, 0x0016, 0x009d, 0x009c, 0x003d, 0x003c, 0x0035, 0x002f, 0x000a, 0x00af, 0x00ae, 0x008d, 0x008c, 0x008b},
+				Extensions:          []uint16{0x0000, 0x000a, 0x000b, 0x000d, 0x0010, 0x0005, 0x0012, 0x0017},
+				ALPN:                []string{"http/1.1"},
+				SupportedGroups:     []uint16{0x0017, 0x0018, 0x0019},
+				PointFormats:        []uint8{0x00},
+				SignatureAlgorithms: []uint16{0x0401, 0x0201, 0x0501, 0x0601, 0x0403, 0x0203, 0x0503, 0x0603},
 			},
 			payload: []byte{
-				0x03, 0x01, 0x00, 0xd4, 0x01, 0x00, 0x00,
+				0x16, 0x03, 0x01, 0x00, 0xd4, 0x01, 0x00, 0x00,
 				0xd0, 0x03, 0x03, 0x60, 0xdf, 0x27, 0x98, 0x68,
 				0xff, 0x4b, 0x46, 0x1f, 0xdd, 0x43, 0x03, 0xf7,
 				0xba, 0xb4, 0xd5, 0x1e, 0xaa, 0xce, 0xe8, 0xcd,
@@ -53,9 +64,19 @@ func Test_clientHelloMsg_unmarshalTCP(t *testing.T) {
 		},
 		{
 			name: "Quic ClientHello 1",
-			want: &ClientHello{SNI: "r2---sn-fxc25nn-nwje.googlevideo.com"},
+			quic: true,
+			want: &ClientHello{
+				SNI:                 "r2---sn-fxc25nn-nwje.googlevideo.com",
+				Version:             0x0303,
+				CipherSuites:        []uint16{0x1301, 0x1302, 0x1303},
+				Extensions:          []uint16{0x0000, 0x000a, 0x0010, 0x000d, 0x0033, 0x002d, 0x002b, 0x0039, 0x001b, 0x4469},
+				ALPN:                []string{"h3"},
+				SupportedVersions:   []uint16{0x0304},
+				SupportedGroups:     []uint16{0x001d, 0x0017, 0x0018},
+				SignatureAlgorithms: []uint16{0x0403, 0x0804, 0x0401, 0x0503, 0x0805, 0x0501, 0x0806, 0x0601, 0x0201},
+			},
 			payload: []byte{
-				0x06, 0x00, 0x41, 0x54, 0x01, 0x00, 0x01, 0x50,
+				0x01, 0x00, 0x01, 0x50,
 				0x03, 0x03, 0x0e, 0x04, 0x01, 0xdc, 0xe9, 0xfe,
 				0xc9, 0x3f, 0x34, 0x84, 0x5e, 0xc3, 0xe2, 0x31,
 				0x40, 0x61, 0x7c, 0x23, 0x20, 0xfc, 0xe4, 0xfb,
@@ -104,9 +125,19 @@ func Test_clientHelloMsg_unmarshalTCP(t *testing.T) {
 		},
 		{
 			name: "QUIC ClientHello 2",
-			want: &ClientHello{SNI: "easylist.to"},
+			quic: true,
+			want: &ClientHello{
+				SNI:                 "easylist.to",
+				Version:             0x0303,
+				CipherSuites:        []uint16{0x1301, 0x1303, 0x1302},
+				Extensions:          []uint16{0x0000, 0x0017, 0xff01, 0x000a, 0x0010, 0x0005, 0x0022, 0x0033, 0x002b, 0x000d, 0x002d, 0x001c, 0xffa5, 0x0015},
+				ALPN:                []string{"h3-27"},
+				SupportedVersions:   []uint16{0x0304},
+				SupportedGroups:     []uint16{0x001d, 0x0017, 0x0018, 0x0019, 0x0100, 0x0101, 0x0102, 0x0103, 0x0104},
+				SignatureAlgorithms: []uint16{0x0403, 0x0503, 0x0603, 0x0203, 0x0804, 0x0805, 0x0806, 0x0401, 0x0501, 0x0601, 0x0201},
+			},
 			payload: []byte{
-				0x06, 0x00, 0x42, 0x00, 0x01, 0x00, 0x01, 0xfc,
+				0x01, 0x00, 0x01, 0xfc,
 				0x03, 0x03, 0x12, 0x3e, 0x93, 0x0d, 0xdb, 0xdb,
 				0x28, 0xa9, 0xc2, 0xa8, 0x27, 0xc2, 0xd9, 0xb6,
 				0x45, 0x36, 0x12, 0xb8, 0xe5, 0x98, 0x3d, 0x90,
@@ -174,12 +205,120 @@ func Test_clientHelloMsg_unmarshalTCP(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "TCP ClientHello with ECH",
+			want: &ClientHello{
+				SNI: "public.example",
+				ECH: &EncryptedClientHello{
+					Type:     ECHTypeOuter,
+					KDFID:    0x0001,
+					AEADID:   0x0001,
+					ConfigID: 0x2a,
+					OuterSNI: "public.example",
+				},
+				Version:             0x0303,
+				CipherSuites:        []uint16{0x1301, 0x1302, 0x1303},
+				Extensions:          []uint16{0x0000, 0x0012, 0xfe0d, 0x0005, 0x000a, 0x000d, 0x0032, 0x0010, 0x002b, 0x0033},
+				ALPN:                []string{"h2", "http/1.1"},
+				SupportedVersions:   []uint16{0x0304},
+				SupportedGroups:     []uint16{0x001d},
+				SignatureAlgorithms: []uint16{0x0904, 0x0905, 0x0906, 0x0804, 0x0403, 0x0807, 0x0805, 0x0806, 0x0503, 0x0603},
+			},
+			payload: []byte{
+				0x16, 0x03, 0x01, 0x01, 0x9e, 0x01, 0x00, 0x01, 0x9a, 0x03, 0x03, 0xbb, 0xc0, 0x6b, 0x38, 0xec, 0x27,
+				0x6d, 0x33, 0xc2, 0x5e, 0x86, 0x46, 0x13, 0x26, 0xa7, 0xad, 0x4b, 0xcf, 0x3a, 0xaa, 0x37, 0xca,
+				0xf9, 0x21, 0x18, 0x08, 0x55, 0x05, 0x95, 0x57, 0xfc, 0xb5, 0x20, 0x0c, 0x79, 0x67, 0x74, 0x8b,
+				0xfb, 0x34, 0xad, 0x77, 0x96, 0xdb, 0x55, 0x57, 0xd3, 0xbf, 0xf1, 0xdc, 0x60, 0x7b, 0x0c, 0x50,
+				0x4b, 0x58, 0x05, 0x9e, 0x6a, 0x6a, 0x5a, 0x5c, 0x37, 0xe1, 0xf5, 0x00, 0x06, 0x13, 0x01, 0x13,
+				0x02, 0x13, 0x03, 0x01, 0x00, 0x01, 0x4b, 0x00, 0x00, 0x00, 0x13, 0x00, 0x11, 0x00, 0x00, 0x0e,
+				0x70, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x2e, 0x65, 0x78, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x00, 0x12,
+				0x00, 0x00, 0xfe, 0x0d, 0x00, 0x9a, 0x00, 0x00, 0x01, 0x00, 0x01, 0x2a, 0x00, 0x20, 0x61, 0xe1,
+				0x0c, 0x59, 0xcf, 0x3f, 0x27, 0x5d, 0xb5, 0x07, 0xb1, 0x9c, 0xdb, 0x85, 0x33, 0x87, 0xe4, 0x9f,
+				0x76, 0x41, 0xeb, 0xd5, 0x25, 0x1b, 0x22, 0xdc, 0x50, 0xc8, 0x41, 0xb7, 0x07, 0x3c, 0x00, 0x70,
+				0x27, 0xbf, 0x18, 0x6d, 0x6d, 0x40, 0x5f, 0xec, 0x5b, 0x4b, 0x7a, 0xe1, 0x64, 0x36, 0x50, 0xc3,
+				0x64, 0xb6, 0xb4, 0xf3, 0x88, 0xf1, 0xe2, 0x3d, 0xca, 0x4f, 0xb8, 0xc0, 0x03, 0xbd, 0xae, 0x28,
+				0x80, 0x31, 0x74, 0x86, 0x02, 0xb8, 0x02, 0xf0, 0x38, 0xf7, 0x95, 0x4c, 0xfa, 0xbc, 0x5b, 0xa4,
+				0xd5, 0xba, 0x13, 0xe1, 0x56, 0x2d, 0x29, 0x2e, 0x6f, 0xf0, 0x6d, 0xd3, 0xc4, 0xf4, 0xa7, 0xbe,
+				0xee, 0xd1, 0xf2, 0x5d, 0x39, 0xc1, 0x10, 0x17, 0x69, 0xcb, 0xbf, 0xfd, 0x06, 0x04, 0x56, 0xf0,
+				0x42, 0xb0, 0xf1, 0xcd, 0xa9, 0x86, 0x4c, 0xc0, 0x09, 0x53, 0xb1, 0x23, 0x7c, 0xf5, 0x4b, 0x4c,
+				0xaf, 0x22, 0x48, 0xec, 0x24, 0x1a, 0xd9, 0xaf, 0xc7, 0x5f, 0xea, 0xc0, 0x62, 0x19, 0x20, 0xf8,
+				0x00, 0x05, 0x00, 0x05, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x0a, 0x00, 0x04, 0x00, 0x02, 0x00,
+				0x1d, 0x00, 0x0d, 0x00, 0x16, 0x00, 0x14, 0x09, 0x04, 0x09, 0x05, 0x09, 0x06, 0x08, 0x04, 0x04,
+				0x03, 0x08, 0x07, 0x08, 0x05, 0x08, 0x06, 0x05, 0x03, 0x06, 0x03, 0x00, 0x32, 0x00, 0x20, 0x00,
+				0x1e, 0x09, 0x04, 0x09, 0x05, 0x09, 0x06, 0x08, 0x04, 0x04, 0x03, 0x08, 0x07, 0x08, 0x05, 0x08,
+				0x06, 0x04, 0x01, 0x05, 0x01, 0x06, 0x01, 0x05, 0x03, 0x06, 0x03, 0x02, 0x01, 0x02, 0x03, 0x00,
+				0x10, 0x00, 0x0e, 0x00, 0x0c, 0x02, 0x68, 0x32, 0x08, 0x68, 0x74, 0x74, 0x70, 0x2f, 0x31, 0x2e,
+				0x31, 0x00, 0x2b, 0x00, 0x03, 0x02, 0x03, 0x04, 0x00, 0x33, 0x00, 0x26, 0x00, 0x24, 0x00, 0x1d,
+				0x00, 0x20, 0x35, 0x3b, 0xe8, 0x1e, 0xcd, 0xa9, 0xf8, 0xa7, 0x77, 0x08, 0x5c, 0x02, 0xf2, 0x78,
+				0x74, 0x35, 0x75, 0xaf, 0x71, 0xa4, 0xe9, 0xee, 0xa9, 0xb7, 0xd3, 0xdf, 0x10, 0x95, 0x64, 0x3c,
+				0xed, 0x7e,
+			},
+			wantErr: false,
+		},
+		{
+			name: "TCP ClientHello SNI only",
+			opts: Options{SNIOnly: true},
+			want: &ClientHello{
+				SNI: "api.dropboxapi.com",
+			},
+			payload: []byte{
+				0x16, 0x03, 0x01, 0x00, 0xd4, 0x01, 0x00, 0x00,
+				0xd0, 0x03, 0x03, 0x60, 0xdf, 0x27, 0x98, 0x68,
+				0xff, 0x4b, 0x46, 0x1f, 0xdd, 0x43, 0x03, 0xf7,
+				0xba, 0xb4, 0xd5, 0x1e, 0xaa, 0xce, 0xe8, 0xcd,
+				0xa5, 0xe7, 0xca, 0x70, 0xb7, 0xea, 0x88, 0xad,
+				0x69, 0xb3, 0x5d, 0x00, 0x00, 0x44, 0x00, 0xff,
+				0xc0, 0x2c, 0xc0, 0x2b, 0xc0, 0x24, 0xc0, 0x23,
+				0xc0, 0x0a, 0xc0, 0x09, 0xc0, 0x08, 0xc0, 0x30,
+				0xc0, 0x2f, 0xc0, 0x28, 0xc0, 0x27, 0xc0, 0x14,
+				0xc0, 0x13, 0xc0, 0x12, 0x00, 0x9f, 0x00, 0x9e,
+				0x00, 0x6b, 0x00, 0x67, 0x00, 0x39, 0x00, 0x33,
+				0x00, 0x16, 0x00, 0x9d, 0x00, 0x9c, 0x00, 0x3d,
+				0x00, 0x3c, 0x00, 0x35, 0x00, 0x2f, 0x00, 0x0a,
+				0x00, 0xaf, 0x00, 0xae, 0x00, 0x8d, 0x00, 0x8c,
+				0x00, 0x8b, 0x01, 0x00, 0x00, 0x63, 0x00, 0x00,
+				0x00, 0x17, 0x00, 0x15, 0x00, 0x00, 0x12, 0x61,
+				0x70, 0x69, 0x2e, 0x64, 0x72, 0x6f, 0x70, 0x62,
+				0x6f, 0x78, 0x61, 0x70, 0x69, 0x2e, 0x63, 0x6f,
+				0x6d, 0x00, 0x0a, 0x00, 0x08, 0x00, 0x06, 0x00,
+				0x17, 0x00, 0x18, 0x00, 0x19, 0x00, 0x0b, 0x00,
+				0x02, 0x01, 0x00, 0x00, 0x0d, 0x00, 0x12, 0x00,
+				0x10, 0x04, 0x01, 0x02, 0x01, 0x05, 0x01, 0x06,
+				0x01, 0x04, 0x03, 0x02, 0x03, 0x05, 0x03, 0x06,
+				0x03, 0x00, 0x10, 0x00, 0x0b, 0x00, 0x09, 0x08,
+				0x68, 0x74, 0x74, 0x70, 0x2f, 0x31, 0x2e, 0x31,
+				0x00, 0x05, 0x00, 0x05, 0x01, 0x00, 0x00, 0x00,
+				0x00, 0x00, 0x12, 0x00, 0x00, 0x00, 0x17, 0x00,
+				0x00,
+			},
+			wantErr: false,
+		},
+		{
+			name: "TCP ClientHello crypto/tls",
+			want: &ClientHello{
+				SNI:                 "example.com",
+				Version:             0x0303,
+				CipherSuites:        []uint16{0xc02b, 0xc02f, 0xc02c, 0xc030, 0xcca9, 0xcca8, 0xc009, 0xc013, 0xc00a, 0xc014, 0x1301, 0x1302, 0x1303},
+				Extensions:          []uint16{0x0000, 0x000b, 0xff01, 0x0017, 0x0012, 0x0005, 0x000a, 0x000d, 0x0032, 0x0010, 0x002b, 0x0033},
+				ALPN:                []string{"h2", "http/1.1"},
+				SupportedVersions:   []uint16{0x0304, 0x0303},
+				SupportedGroups:     []uint16{0x001d},
+				PointFormats:        []uint8{0x00},
+				SignatureAlgorithms: []uint16{0x0904, 0x0905, 0x0906, 0x0804, 0x0403, 0x0807, 0x0805, 0x0806, 0x0401, 0x0501, 0x0601, 0x0503, 0x0603},
+			},
+			payload: cryptoTLSHello,
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			got := &ClientHello{}
 
-			if err := got.Unmarshal(tt.payload); err != nil {
+			unmarshal := got.UnmarshalWithOptions
+			if tt.quic {
+				unmarshal = got.UnmarshalHandshake
+			}
+			if err := unmarshal(tt.payload, tt.opts); err != nil {
 				if tt.wantErr {
 					return
 				}
@@ -194,3 +333,240 @@ func Test_clientHelloMsg_unmarshalTCP(t *testing.T) {
 		})
 	}
 }
+
+func Test_clientHelloMsg_truncated(t *testing.T) {
+	tests := []struct {
+		name   string
+		length int
+		field  string
+		sni    string
+	}{
+		{
+			name:   "Record header",
+			length: 2,
+			field:  "record version",
+		},
+		{
+			name:   "Handshake header",
+			length: 5,
+			field:  "handshake type",
+		},
+		{
+			name:   "Random",
+			length: 20,
+			field:  "random",
+		},
+		{
+			name:   "Session ID",
+			length: 50,
+			field:  "legacy_session_id",
+		},
+		{
+			name:   "Cipher suites",
+			length: 100,
+			field:  "cipher_suites",
+		},
+		{
+			name:   "Extensions after SNI",
+			length: 200,
+			field:  "extension 0x0032",
+			sni:    "example.com",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := &ClientHello{}
+			err := got.Unmarshal(cryptoTLSHello[:tt.length])
+
+			var truncated *TruncatedError
+			if !errors.As(err, &truncated) {
+				t.Fatalf("Unmarshal() error = %v, want *TruncatedError", err)
+			}
+			if !errors.Is(err, UnmarshalClientHelloError) {
+				t.Errorf("Unmarshal() error = %v, want UnmarshalClientHelloError", err)
+			}
+			if truncated.Field != tt.field {
+				t.Errorf("TruncatedError.Field = %q, want %q", truncated.Field, tt.field)
+			}
+			if got.SNI != tt.sni {
+				t.Errorf("SNI = %q, want %q", got.SNI, tt.sni)
+			}
+			if !got.Partial {
+				t.Errorf("Partial = false, want true")
+			}
+			if incomplete := got.Incomplete(err); incomplete != (tt.sni != "") {
+				t.Errorf("Incomplete() = %v, want %v", incomplete, tt.sni != "")
+			}
+		})
+	}
+}
+
+func Test_clientHelloMsg_malformedMetadata(t *testing.T) {
+	extensions := []struct {
+		name string
+		id   uint16
+		data []byte
+	}{
+		{name: "ALPN", id: extensionALPN, data: []byte{0x00, 0x05, 0x02}},
+		{name: "Supported versions", id: extensionSupportedVersions, data: []byte{0x03, 0x03, 0x04}},
+		{name: "Supported groups", id: extensionSupportedGroups, data: []byte{0x00, 0x01, 0x00}},
+		{name: "Point formats", id: extensionECPointFormats, data: []byte{0x02, 0x00}},
+		{name: "Signature algorithms", id: extensionSignatureAlgorithms, data: []byte{0x00, 0x04, 0x04, 0x03}},
+	}
+	for _, ext := range extensions {
+		t.Run(ext.name, func(t *testing.T) {
+			// A hello with the malformed extension before the SNI
+			sni := []byte{0x00, 0x00, 0x00, 0x14, 0x00, 0x12, 0x00, 0x00, 0x0f}
+			sni = append(sni, "blocked.example"...)
+			extension := binary.BigEndian.AppendUint16(nil, ext.id)
+			extension = binary.BigEndian.AppendUint16(extension, uint16(len(ext.data)))
+			extension = append(extension, ext.data...)
+
+			body := append([]byte{0x03, 0x03}, make([]byte, 32)...)
+			body = append(body, 0x00, 0x00, 0x02, 0xc0, 0x2b, 0x01, 0x00)
+			body = binary.BigEndian.AppendUint16(body, uint16(len(extension)+len(sni)))
+			body = append(append(body, extension...), sni...)
+			record := []byte{0x16, 0x03, 0x01}
+			record = binary.BigEndian.AppendUint16(record, uint16(4+len(body)))
+			record = append(record, 0x01, 0x00, byte(len(body)>>8), byte(len(body)))
+			record = append(record, body...)
+
+			got := &ClientHello{}
+			if err := got.Unmarshal(record); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			want := &ClientHello{
+				SNI:               "blocked.example",
+				Version:           0x0303,
+				CipherSuites:      []uint16{0xc02b},
+				Extensions:        []uint16{ext.id, extensionServerName},
+				MalformedMetadata: true,
+			}
+			if diff := cmp.Diff(want, got, cmpopts.EquateEmpty()); diff != "" {
+				t.Fatalf("unexpected ClientHello (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_clientHelloMsg_fragmented(t *testing.T) {
+	tests := []struct {
+		name    string
+		sizes   []int
+		cut     int
+		sni     string
+		partial bool
+	}{
+		{
+			name:  "1-byte first record",
+			sizes: []int{1},
+			sni:   "example.com",
+		},
+		{
+			name:  "Split handshake header",
+			sizes: []int{2, 2},
+			sni:   "example.com",
+		},
+		{
+			name:  "Split inside SNI",
+			sizes: []int{50, 70, 5},
+			sni:   "example.com",
+		},
+		{
+			name:  "Record per byte up to the extensions",
+			sizes: ones(100),
+			sni:   "example.com",
+		},
+		{
+			name:    "Last record in next segment",
+			sizes:   []int{1, 150},
+			cut:     20,
+			sni:     "example.com",
+			partial: true,
+		},
+		{
+			name:    "SNI in next segment",
+			sizes:   []int{1, 50},
+			cut:     200,
+			partial: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := fragmentRecords(t, cryptoTLSHello, tt.sizes)
+			payload = payload[:len(payload)-tt.cut]
+
+			got := &ClientHello{}
+			err := got.Unmarshal(payload)
+			if tt.partial != (err != nil) {
+				t.Fatalf("Unmarshal() error = %v, partial %v", err, tt.partial)
+			}
+			if got.SNI != tt.sni {
+				t.Errorf("SNI = %q, want %q", got.SNI, tt.sni)
+			}
+			if got.Partial != tt.partial {
+				t.Errorf("Partial = %v, want %v", got.Partial, tt.partial)
+			}
+			if tt.partial {
+				return
+			}
+
+			want := &ClientHello{}
+			if err := want.Unmarshal(cryptoTLSHello); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Fatalf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// ones returns n record sizes of 1 byte.
+func ones(n int) []int {
+	sizes := make([]int, n)
+	for i := range sizes {
+		sizes[i] = 1
+	}
+	return sizes
+}
+
+// fragmentRecords splits the handshake message in record over several
+// records of the given sizes, the last record holds the remainder.
+func fragmentRecords(t testing.TB, record []byte, sizes []int) []byte {
+	t.Helper()
+	message := record[5:]
+	var payload []byte
+	for _, size := range append(sizes, len(message)) {
+		if size > len(message) {
+			size = len(message)
+		}
+		payload = append(payload, record[0], record[1], record[2], byte(size>>8), byte(size))
+		payload = append(payload, message[:size]...)
+		message = message[size:]
+	}
+	return payload
+}
+
+// cryptoTLSHello is a ClientHello for example.com sent by crypto/tls
+var cryptoTLSHello = []byte{
+	0x16, 0x03, 0x01, 0x01, 0x28, 0x01, 0x00, 0x01, 0x24, 0x03, 0x03, 0xf6, 0xac, 0x70, 0xe1, 0xfe, 0x91,
+	0x68, 0x4e, 0xf0, 0xa7, 0x2a, 0xb5, 0x1a, 0x99, 0x47, 0x6b, 0xe9, 0xe9, 0x2b, 0xd3, 0x66, 0x31,
+	0xa8, 0x0d, 0x07, 0x5d, 0xe0, 0x03, 0x89, 0x55, 0xf8, 0x73, 0x20, 0x45, 0x1a, 0x3c, 0x06, 0x43,
+	0x81, 0xe0, 0x13, 0xef, 0xd0, 0x11, 0x8d, 0x83, 0xbb, 0xb4, 0x3c, 0xab, 0xda, 0x8b, 0x2f, 0x80,
+	0x14, 0xf3, 0x3d, 0x2b, 0x02, 0xa0, 0x04, 0xdd, 0xd4, 0xa4, 0xa6, 0x00, 0x1a, 0xc0, 0x2b, 0xc0,
+	0x2f, 0xc0, 0x2c, 0xc0, 0x30, 0xcc, 0xa9, 0xcc, 0xa8, 0xc0, 0x09, 0xc0, 0x13, 0xc0, 0x0a, 0xc0,
+	0x14, 0x13, 0x01, 0x13, 0x02, 0x13, 0x03, 0x01, 0x00, 0x00, 0xc1, 0x00, 0x00, 0x00, 0x10, 0x00,
+	0x0e, 0x00, 0x00, 0x0b, 0x65, 0x78, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x2e, 0x63, 0x6f, 0x6d, 0x00,
+	0x0b, 0x00, 0x02, 0x01, 0x00, 0xff, 0x01, 0x00, 0x01, 0x00, 0x00, 0x17, 0x00, 0x00, 0x00, 0x12,
+	0x00, 0x00, 0x00, 0x05, 0x00, 0x05, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x0a, 0x00, 0x04, 0x00,
+	0x02, 0x00, 0x1d, 0x00, 0x0d, 0x00, 0x1c, 0x00, 0x1a, 0x09, 0x04, 0x09, 0x05, 0x09, 0x06, 0x08,
+	0x04, 0x04, 0x03, 0x08, 0x07, 0x08, 0x05, 0x08, 0x06, 0x04, 0x01, 0x05, 0x01, 0x06, 0x01, 0x05,
+	0x03, 0x06, 0x03, 0x00, 0x32, 0x00, 0x20, 0x00, 0x1e, 0x09, 0x04, 0x09, 0x05, 0x09, 0x06, 0x08,
+	0x04, 0x04, 0x03, 0x08, 0x07, 0x08, 0x05, 0x08, 0x06, 0x04, 0x01, 0x05, 0x01, 0x06, 0x01, 0x05,
+	0x03, 0x06, 0x03, 0x02, 0x01, 0x02, 0x03, 0x00, 0x10, 0x00, 0x0e, 0x00, 0x0c, 0x02, 0x68, 0x32,
+	0x08, 0x68, 0x74, 0x74, 0x70, 0x2f, 0x31, 0x2e, 0x31, 0x00, 0x2b, 0x00, 0x05, 0x04, 0x03, 0x04,
+	0x03, 0x03, 0x00, 0x33, 0x00, 0x26, 0x00, 0x24, 0x00, 0x1d, 0x00, 0x20, 0x04, 0xf7, 0x23, 0x72,
+	0x94, 0x2b, 0xbe, 0x9d, 0x99, 0xc3, 0x6b, 0xc7, 0x1b, 0x7a, 0x1a, 0x63, 0x88, 0xa5, 0xfa, 0x3f,
+	0x2b, 0x6a, 0xc9, 0xc9, 0x52, 0x02, 0xfd, 0x66, 0xc7, 0xa5, 0x7e, 0x47,
+}
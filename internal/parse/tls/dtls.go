@@ -0,0 +1,122 @@
+package tls
+
+import (
+	"sort"
+)
+
+// dtlsFragment is a piece of a handshake message carried in a DTLS record.
+type dtlsFragment struct {
+	offset int
+	data   []byte
+}
+
+// IsDTLS reports whether payload starts with a DTLS handshake record.
+func IsDTLS(payload []byte) bool {
+	return len(payload) >= 3 && payload[0] == recordTypeHandshake && payload[1] == 0xfe &&
+		(payload[2] == 0xff || payload[2] == 0xfd || payload[2] == 0xfc)
+}
+
+// UnmarshalDTLS decodes the ClientHello from the DTLS records in a datagram.
+// The payload must start at the record content type. The hello may be
+// fragmented over several handshake fragments, which are put back in order
+// as far as they are contiguous.
+func (m *ClientHello) UnmarshalDTLS(payload []byte, opts Options) error {
+	return m.partial(m.unmarshalDTLS(reader{b: payload}, opts))
+}
+
+func (m *ClientHello) unmarshalDTLS(r reader, opts Options) error {
+	length := -1
+	var fragments []dtlsFragment
+	for !r.empty() {
+		contentType, err := r.uint8("record content type")
+		if err != nil {
+			return err
+		}
+		if contentType != recordTypeHandshake {
+			if len(fragments) == 0 {
+				return UnmarshalNoTLSError
+			}
+			// Not a handshake record, the hello ends here
+			break
+		}
+		version, err := r.uint16("record version")
+		if err != nil {
+			return err
+		}
+		if version>>8 != 0xfe {
+			return UnmarshalNoTLSError
+		}
+		if _, err := r.bytes(8, "record epoch and sequence_number"); err != nil {
+			return err
+		}
+		recordLength, err := r.uint16("record length")
+		if err != nil {
+			return err
+		}
+
+		// A record may carry several handshake fragments
+		record := r.sub(int(recordLength))
+		for !record.empty() {
+			handshakeType, err := record.uint8("handshake type")
+			if err != nil {
+				return err
+			}
+			if handshakeType != handshakeTypeClientHello {
+				return UnmarshalNoTLSHandshakeError
+			}
+			messageLength, err := record.uint24("handshake length")
+			if err != nil {
+				return err
+			}
+			if _, err := record.uint16("handshake message_seq"); err != nil {
+				return err
+			}
+			offset, err := record.uint24("handshake fragment_offset")
+			if err != nil {
+				return err
+			}
+			fragmentLength, err := record.uint24("handshake fragment_length")
+			if err != nil {
+				return err
+			}
+			if length >= 0 && messageLength != length {
+				// Fragments of different messages
+				return UnmarshalClientHelloError
+			}
+			if offset+fragmentLength > messageLength {
+				return UnmarshalClientHelloError
+			}
+			length = messageLength
+			fragments = append(fragments, dtlsFragment{offset: offset, data: record.sub(fragmentLength).b})
+		}
+	}
+	if len(fragments) == 0 {
+		return UnmarshalNoTLSError
+	}
+
+	message := dtlsMessage(fragments)
+	body := reader{b: message}
+	return m.unmarshalBody(body.sub(length), opts, true)
+}
+
+// dtlsMessage returns the contiguous start of a handshake message from its
+// fragments.
+func dtlsMessage(fragments []dtlsFragment) []byte {
+	if len(fragments) == 1 && fragments[0].offset == 0 {
+		return fragments[0].data
+	}
+	sort.SliceStable(fragments, func(i, j int) bool {
+		return fragments[i].offset < fragments[j].offset
+	})
+	var message []byte
+	for _, f := range fragments {
+		if f.offset > len(message) {
+			// A fragment is missing
+			break
+		}
+		if end := f.offset + len(f.data); end > len(message) {
+			message = append(message, f.data[len(message)-f.offset:]...)
+		}
+	}
+	return message
+}
@@ -0,0 +1,173 @@
+package tls
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestClientHello_UnmarshalDTLS(t *testing.T) {
+	tests := []struct {
+		name    string
+		sizes   []int
+		order   []int
+		cut     int
+		sni     string
+		partial bool
+	}{
+		{
+			name: "Single fragment",
+			sni:  "example.com",
+		},
+		{
+			name:  "Three fragments",
+			sizes: []int{100, 100},
+			sni:   "example.com",
+		},
+		{
+			name:  "Fragments out of order",
+			sizes: []int{60, 100},
+			order: []int{2, 0, 1},
+			sni:   "example.com",
+		},
+		{
+			name:    "Last fragment missing",
+			sizes:   []int{150, 20},
+			order:   []int{0, 1},
+			sni:     "example.com",
+			partial: true,
+		},
+		{
+			name:    "SNI in missing fragment",
+			sizes:   []int{60, 100},
+			order:   []int{0, 2},
+			partial: true,
+		},
+		{
+			name:    "Datagram cut short",
+			cut:     100,
+			sni:     "example.com",
+			partial: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := dtlsRecords(t, cryptoTLSHello, []byte{0xc0, 0x0c, 0x1e}, tt.sizes, tt.order)
+			payload = payload[:len(payload)-tt.cut]
+			if !IsDTLS(payload) {
+				t.Fatalf("IsDTLS() = false")
+			}
+
+			got := &ClientHello{}
+			err := got.UnmarshalDTLS(payload, Options{})
+			if tt.partial != (err != nil) {
+				t.Fatalf("UnmarshalDTLS() error = %v, partial %v", err, tt.partial)
+			}
+			if got.SNI != tt.sni {
+				t.Errorf("SNI = %q, want %q", got.SNI, tt.sni)
+			}
+			if got.Partial != tt.partial {
+				t.Errorf("Partial = %v, want %v", got.Partial, tt.partial)
+			}
+			if tt.partial {
+				return
+			}
+
+			want := &ClientHello{}
+			if err := want.Unmarshal(cryptoTLSHello); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			want.Version = 0xfefd
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Fatalf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestClientHello_UnmarshalDTLS_errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload []byte
+		wantErr error
+	}{
+		{
+			name:    "TLS record",
+			payload: cryptoTLSHello,
+			wantErr: UnmarshalNoTLSError,
+		},
+		{
+			name: "HelloVerifyRequest",
+			payload: []byte{
+				0x16, 0xfe, 0xff, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x0f,
+				0x03, 0x00, 0x00, 0x03, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x03, 0xfe, 0xff, 0x00,
+			},
+			wantErr: UnmarshalNoTLSHandshakeError,
+		},
+		{
+			name: "Fragment beyond message",
+			payload: []byte{
+				0x16, 0xfe, 0xfd, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x0e,
+				0x01, 0x00, 0x00, 0x04, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x04, 0xfe, 0xfd,
+			},
+			wantErr: UnmarshalClientHelloError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := &ClientHello{}
+			if err := got.UnmarshalDTLS(tt.payload, Options{}); err != tt.wantErr {
+				t.Fatalf("UnmarshalDTLS() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// dtlsRecords turns the ClientHello in a TLS record into a DTLS 1.2 hello
+// with the given cookie. The message is split into fragments of the given
+// sizes, the last fragment holds the remainder. Each fragment is sent in its
+// own record, in order if order is nil.
+func dtlsRecords(t testing.TB, record []byte, cookie []byte, sizes []int, order []int) []byte {
+	t.Helper()
+	// Skip the record and handshake headers
+	body := record[9:]
+	// legacy_version, random and the session id
+	head := 2 + 32 + 1 + int(body[34])
+	var message []byte
+	message = append(message, 0xfe, 0xfd)
+	message = append(message, body[2:head]...)
+	message = append(message, byte(len(cookie)))
+	message = append(message, cookie...)
+	message = append(message, body[head:]...)
+
+	type fragment struct {
+		offset int
+		data   []byte
+	}
+	var fragments []fragment
+	rest := message
+	for _, size := range append(sizes, len(rest)) {
+		size = min(size, len(rest))
+		fragments = append(fragments, fragment{offset: len(message) - len(rest), data: rest[:size]})
+		rest = rest[size:]
+	}
+	if order == nil {
+		for i := range fragments {
+			order = append(order, i)
+		}
+	}
+
+	var payload []byte
+	for seq, i := range order {
+		f := fragments[i]
+		length := 12 + len(f.data)
+		payload = append(payload, 0x16, 0xfe, 0xfd, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, byte(seq))
+		payload = append(payload, byte(length>>8), byte(length))
+		payload = append(payload, handshakeTypeClientHello, byte(len(message)>>16), byte(len(message)>>8), byte(len(message)))
+		payload = append(payload, 0x00, 0x00)
+		payload = append(payload, byte(f.offset>>16), byte(f.offset>>8), byte(f.offset))
+		payload = append(payload, byte(len(f.data)>>16), byte(len(f.data)>>8), byte(len(f.data)))
+		payload = append(payload, f.data...)
+	}
+	return payload
+}
@@ -0,0 +1,42 @@
+package tls
+
+import (
+	"encoding/binary"
+)
+
+// ECH client hello types as defined in draft-ietf-tls-esni.
+const (
+	ECHTypeOuter uint8 = 0
+	ECHTypeInner uint8 = 1
+)
+
+// EncryptedClientHello holds the cleartext parts of the encrypted_client_hello
+// extension. The encrypted inner hello itself cannot be inspected.
+type EncryptedClientHello struct {
+	Type     uint8
+	KDFID    uint16
+	AEADID   uint16
+	ConfigID uint8
+	// OuterSNI is the public name the client sent in the outer hello.
+	OuterSNI string
+}
+
+func (e *EncryptedClientHello) unmarshal(payload []byte) error {
+	if len(payload) < 1 {
+		return UnmarshalECHError
+	}
+	e.Type = payload[0]
+	if e.Type != ECHTypeOuter {
+		// An inner hello only carries the type byte.
+		return nil
+	}
+
+	// cipher_suite (kdf_id, aead_id) and config_id
+	if len(payload) < 6 {
+		return UnmarshalECHError
+	}
+	e.KDFID = binary.BigEndian.Uint16(payload[1:3])
+	e.AEADID = binary.BigEndian.Uint16(payload[3:5])
+	e.ConfigID = payload[5]
+	return nil
+}
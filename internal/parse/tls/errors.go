@@ -1,7 +1,36 @@
 package tls
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var UnmarshalNoTLSHandshakeError = errors.New("TLS handshake not found")
 var UnmarshalNoTLSError = errors.New("not a TLS packet")
 var UnmarshalClientHelloError = errors.New("insufficient bytes to Unmarshal clienthello")
+var UnmarshalTLSVersionError = errors.New("unsupported TLS version in clienthello")
+var UnmarshalECHError = errors.New("insufficient bytes to Unmarshal encrypted_client_hello")
+var UnmarshalExtensionError = errors.New("malformed clienthello extension")
+
+// TruncatedError is returned when a field of a TLS record or ClientHello
+// extends beyond the available bytes. It matches UnmarshalClientHelloError
+// when compared with errors.Is.
+type TruncatedError struct {
+	// Field is the name of the field that could not be read.
+	Field string
+	// Offset is the position of the field in the parsed payload, or in the
+	// reassembled handshake message when it spans several records.
+	Offset int
+	// Need is the number of bytes the field requires, Have is the number
+	// of bytes that were left.
+	Need int
+	Have int
+}
+
+func (e *TruncatedError) Error() string {
+	return fmt.Sprintf("truncated clienthello: %s at offset %d needs %d bytes, have %d", e.Field, e.Offset, e.Need, e.Have)
+}
+
+func (e *TruncatedError) Is(target error) bool {
+	return target == UnmarshalClientHelloError
+}
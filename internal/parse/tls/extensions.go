@@ -0,0 +1,74 @@
+package tls
+
+import (
+	"encoding/binary"
+)
+
+// unmarshalExtension decodes the metadata extensions of a ClientHello.
+// Unknown extensions are ignored.
+func (m *ClientHello) unmarshalExtension(id uint16, payload []byte, opts Options) error {
+	switch id {
+	case extensionALPN:
+		list, ok := readVector16(payload)
+		if !ok {
+			return UnmarshalExtensionError
+		}
+		for len(list) > 0 {
+			nameLength := int(list[0])
+			if nameLength == 0 || 1+nameLength > len(list) {
+				return UnmarshalExtensionError
+			}
+			m.ALPN = append(m.ALPN, opts.string(list[1:1+nameLength]))
+			list = list[1+nameLength:]
+		}
+	case extensionSupportedVersions:
+		if len(payload) < 1 || int(payload[0])+1 > len(payload) || payload[0]%2 != 0 {
+			return UnmarshalExtensionError
+		}
+		m.SupportedVersions = appendUint16List(m.SupportedVersions[:0], payload[1:1+int(payload[0])])
+	case extensionSupportedGroups:
+		list, ok := readVector16(payload)
+		if !ok || len(list)%2 != 0 {
+			return UnmarshalExtensionError
+		}
+		m.SupportedGroups = appendUint16List(m.SupportedGroups[:0], list)
+	case extensionECPointFormats:
+		if len(payload) < 1 || int(payload[0])+1 > len(payload) {
+			return UnmarshalExtensionError
+		}
+		if m.PointFormats == nil {
+			m.PointFormats = []uint8{}
+		}
+		m.PointFormats = append(m.PointFormats[:0], payload[1:1+int(payload[0])]...)
+	case extensionSignatureAlgorithms:
+		list, ok := readVector16(payload)
+		if !ok || len(list)%2 != 0 {
+			return UnmarshalExtensionError
+		}
+		m.SignatureAlgorithms = appendUint16List(m.SignatureAlgorithms[:0], list)
+	}
+	return nil
+}
+
+// readVector16 returns the contents of a vector with a 2 byte length prefix.
+func readVector16(payload []byte) ([]byte, bool) {
+	if len(payload) < 2 {
+		return nil, false
+	}
+	length := int(binary.BigEndian.Uint16(payload[:2]))
+	if 2+length > len(payload) {
+		return nil, false
+	}
+	return payload[2 : 2+length], true
+}
+
+// appendUint16List appends a list of big endian uint16 values to list.
+func appendUint16List(list []uint16, payload []byte) []uint16 {
+	if list == nil {
+		list = make([]uint16, 0, len(payload)/2)
+	}
+	for i := 0; i+1 < len(payload); i += 2 {
+		list = append(list, binary.BigEndian.Uint16(payload[i:i+2]))
+	}
+	return list
+}
@@ -0,0 +1,197 @@
+package tls
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Transport identifiers used as the first character of a JA4 fingerprint.
+const (
+	JA4TCP  byte = 't'
+	JA4QUIC byte = 'q'
+	JA4DTLS byte = 'd'
+)
+
+// isGREASE reports whether v is a GREASE value as defined in RFC 8701.
+func isGREASE(v uint16) bool {
+	return v&0x0f0f == 0x0a0a && v>>8 == v&0xff
+}
+
+// hasMetadata reports whether the hello was fully decoded with the metadata
+// needed for fingerprinting.
+func (m *ClientHello) hasMetadata() bool {
+	return m.Version != 0 && !m.Partial
+}
+
+// JA3String returns the unhashed JA3 fingerprint of the hello.
+// It returns an empty string when the metadata was not decoded.
+func (m *ClientHello) JA3String() string {
+	if !m.hasMetadata() {
+		return ""
+	}
+	points := make([]uint16, 0, len(m.PointFormats))
+	for _, p := range m.PointFormats {
+		points = append(points, uint16(p))
+	}
+	return strconv.Itoa(int(m.Version)) + "," +
+		joinUint16(m.CipherSuites, "-", false) + "," +
+		joinUint16(m.Extensions, "-", false) + "," +
+		joinUint16(m.SupportedGroups, "-", false) + "," +
+		joinUint16(points, "-", false)
+}
+
+// JA3 returns the MD5 hashed JA3 fingerprint of the hello.
+// It returns an empty string when the metadata was not decoded.
+func (m *ClientHello) JA3() string {
+	ja3 := m.JA3String()
+	if ja3 == "" {
+		return ""
+	}
+	sum := md5.Sum([]byte(ja3))
+	return hex.EncodeToString(sum[:])
+}
+
+// JA4 returns the JA4 fingerprint of the hello for the given transport
+// (JA4TCP, JA4QUIC or JA4DTLS).
+// It returns an empty string when the metadata was not decoded.
+func (m *ClientHello) JA4(transport byte) string {
+	if !m.hasMetadata() {
+		return ""
+	}
+
+	sni := byte('i')
+	var ciphers, extensions []uint16
+	for _, c := range m.CipherSuites {
+		if !isGREASE(c) {
+			ciphers = append(ciphers, c)
+		}
+	}
+	for _, e := range m.Extensions {
+		if isGREASE(e) {
+			continue
+		}
+		extensions = append(extensions, e)
+		if e == extensionServerName {
+			sni = 'd'
+		}
+	}
+
+	a := fmt.Sprintf("%c%s%c%02d%02d%s",
+		transport, ja4Version(m), sni, min(len(ciphers), 99), min(len(extensions), 99), ja4ALPN(m.ALPN))
+
+	// The cipher and extension lists are sorted, SNI and ALPN are left out
+	// of the extensions as they are already represented in the first part.
+	sort.Slice(ciphers, func(i, j int) bool { return ciphers[i] < ciphers[j] })
+	b := ja4Hash(joinUint16(ciphers, ",", true))
+
+	sorted := make([]uint16, 0, len(extensions))
+	for _, e := range extensions {
+		if e != extensionServerName && e != extensionALPN {
+			sorted = append(sorted, e)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	c := joinUint16(sorted, ",", true)
+	if len(m.SignatureAlgorithms) > 0 {
+		c += "_" + joinUint16(m.SignatureAlgorithms, ",", true)
+	}
+	if len(sorted) == 0 {
+		c = ""
+	}
+
+	return a + "_" + b + "_" + ja4Hash(c)
+}
+
+// newerVersion reports whether protocol version a is newer than b. DTLS
+// versions count down from 0xfeff.
+func newerVersion(a, b uint16) bool {
+	if a>>8 == 0xfe && b>>8 == 0xfe {
+		return a < b
+	}
+	return a > b
+}
+
+// MaxVersion returns the newest protocol version offered by the hello, taken
+// from the supported_versions extension when present. It returns 0 when the
+// metadata was not decoded.
+func (m *ClientHello) MaxVersion() uint16 {
+	version := m.Version
+	for _, v := range m.SupportedVersions {
+		if !isGREASE(v) && newerVersion(v, version) {
+			version = v
+		}
+	}
+	return version
+}
+
+func ja4Version(m *ClientHello) string {
+	switch m.MaxVersion() {
+	case 0x0304:
+		return "13"
+	case 0x0303:
+		return "12"
+	case 0x0302:
+		return "11"
+	case 0x0301:
+		return "10"
+	case 0x0300:
+		return "s3"
+	case 0x0002:
+		return "s2"
+	case 0xfeff:
+		return "d1"
+	case 0xfefd:
+		return "d2"
+	case 0xfefc:
+		return "d3"
+	}
+	return "00"
+}
+
+func ja4ALPN(alpn []string) string {
+	if len(alpn) == 0 || len(alpn[0]) == 0 {
+		return "00"
+	}
+	first, last := alpn[0][0], alpn[0][len(alpn[0])-1]
+	if !isAlphaNumeric(first) || !isAlphaNumeric(last) {
+		return hex.EncodeToString([]byte{first})[:1] + hex.EncodeToString([]byte{last})[1:]
+	}
+	return string([]byte{first, last})
+}
+
+func isAlphaNumeric(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func ja4Hash(s string) string {
+	if s == "" {
+		return "000000000000"
+	}
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// joinUint16 joins the non-GREASE values in list, either as decimal (JA3) or
+// as 4 character hex (JA4).
+func joinUint16(list []uint16, sep string, hexadecimal bool) string {
+	var b strings.Builder
+	for _, v := range list {
+		if isGREASE(v) {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString(sep)
+		}
+		if hexadecimal {
+			fmt.Fprintf(&b, "%04x", v)
+		} else {
+			b.WriteString(strconv.Itoa(int(v)))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,102 @@
+package tls
+
+import (
+	"testing"
+)
+
+func TestClientHello_fingerprint(t *testing.T) {
+	tests := []struct {
+		name      string
+		hello     *ClientHello
+		transport byte
+		ja3       string
+		ja4       string
+	}{
+		{
+			name:      "No metadata",
+			hello:     &ClientHello{SNI: "api.dropboxapi.com"},
+			transport: JA4TCP,
+			ja3:       "",
+			ja4:       "",
+		},
+		{
+			name: "TCP ClientHello",
+			hello: &ClientHello{
+				SNI:     "api.dropboxapi.com",
+				Version: 0x0303,
+				CipherSuites: []uint16{
+					0x00ff, 0xc02c, 0xc02b, 0xc024, 0xc023, 0xc00a, 0xc009, 0xc008, 0xc030, 0xc02f,
+					0xc028, 0xc027, 0xc014, 0xc013, 0xc012, 0x009f, 0x009e, 0x006b, 0x0067, 0x0039,
+					0x0033, 0x0016, 0x009d, 0x009c, 0x003d, 0x003c, 0x0035, 0x002f, 0x000a, 0x00af,
+					0x00ae, 0x008d, 0x008c, 0x008b,
+				},
+				Extensions:          []uint16{0x0000, 0x000a, 0x000b, 0x000d, 0x0010, 0x0005, 0x0012, 0x0017},
+				ALPN:                []string{"http/1.1"},
+				SupportedGroups:     []uint16{0x0017, 0x0018, 0x0019},
+				PointFormats:        []uint8{0x00},
+				SignatureAlgorithms: []uint16{0x0401, 0x0201, 0x0501, 0x0601, 0x0403, 0x0203, 0x0503, 0x0603},
+			},
+			transport: JA4TCP,
+			ja3:       "aaff0f715426f3a10d6a7184954803cb",
+			ja4:       "t12d3408h1_c09add56dd4c_3304d8368043",
+		},
+		{
+			name: "QUIC ClientHello",
+			hello: &ClientHello{
+				SNI:                 "r2---sn-fxc25nn-nwje.googlevideo.com",
+				Version:             0x0303,
+				CipherSuites:        []uint16{0x1301, 0x1302, 0x1303},
+				Extensions:          []uint16{0x0000, 0x000a, 0x0010, 0x000d, 0x0033, 0x002d, 0x002b, 0x0039, 0x001b, 0x4469},
+				ALPN:                []string{"h3"},
+				SupportedVersions:   []uint16{0x0304},
+				SupportedGroups:     []uint16{0x001d, 0x0017, 0x0018},
+				SignatureAlgorithms: []uint16{0x0403, 0x0804, 0x0401, 0x0503, 0x0805, 0x0501, 0x0806, 0x0601, 0x0201},
+			},
+			transport: JA4QUIC,
+			ja3:       "9fd1ec3175e3730cd11d0f2a1a9142b5",
+			ja4:       "q13d0310h3_55b375c5d22e_cd85d2d88918",
+		},
+		{
+			name: "GREASE is ignored",
+			hello: &ClientHello{
+				SNI:                 "r2---sn-fxc25nn-nwje.googlevideo.com",
+				Version:             0x0303,
+				CipherSuites:        []uint16{0x3a3a, 0x1301, 0x1302, 0x1303},
+				Extensions:          []uint16{0x8a8a, 0x0000, 0x000a, 0x0010, 0x000d, 0x0033, 0x002d, 0x002b, 0x0039, 0x001b, 0x4469},
+				ALPN:                []string{"h3"},
+				SupportedVersions:   []uint16{0xdada, 0x0304},
+				SupportedGroups:     []uint16{0x4a4a, 0x001d, 0x0017, 0x0018},
+				SignatureAlgorithms: []uint16{0x0403, 0x0804, 0x0401, 0x0503, 0x0805, 0x0501, 0x0806, 0x0601, 0x0201},
+			},
+			transport: JA4QUIC,
+			ja3:       "9fd1ec3175e3730cd11d0f2a1a9142b5",
+			ja4:       "q13d0310h3_55b375c5d22e_cd85d2d88918",
+		},
+		{
+			name: "DTLS 1.3 ClientHello",
+			hello: &ClientHello{
+				SNI:                 "r2---sn-fxc25nn-nwje.googlevideo.com",
+				Version:             0xfefd,
+				CipherSuites:        []uint16{0x1301, 0x1302, 0x1303},
+				Extensions:          []uint16{0x0000, 0x000a, 0x0010, 0x000d, 0x0033, 0x002d, 0x002b, 0x0039, 0x001b, 0x4469},
+				ALPN:                []string{"h3"},
+				SupportedVersions:   []uint16{0xfefc, 0xfefd},
+				SupportedGroups:     []uint16{0x001d, 0x0017, 0x0018},
+				SignatureAlgorithms: []uint16{0x0403, 0x0804, 0x0401, 0x0503, 0x0805, 0x0501, 0x0806, 0x0601, 0x0201},
+			},
+			transport: JA4DTLS,
+			ja3:       "024b3b642da32714660a256ac87d83f4",
+			ja4:       "dd3d0310h3_55b375c5d22e_cd85d2d88918",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.hello.JA3(); got != tt.ja3 {
+				t.Errorf("JA3() = %q, want %q", got, tt.ja3)
+			}
+			if got := tt.hello.JA4(tt.transport); got != tt.ja4 {
+				t.Errorf("JA4() = %q, want %q", got, tt.ja4)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package tls
+
+import (
+	"bytes"
+	"testing"
+)
+
+func FuzzClientHello_Unmarshal(f *testing.F) {
+	const (
+		modeTCP = iota
+		modeHandshake
+		modeDTLS
+		modes
+	)
+	f.Add(cryptoTLSHello, uint8(modeTCP), false)
+	f.Add(fragmentRecords(f, cryptoTLSHello, []int{10, 100}), uint8(modeTCP), true)
+	f.Add(cryptoTLSHello[5:], uint8(modeHandshake), false)
+	f.Add(dtlsRecords(f, cryptoTLSHello, []byte{0xc0, 0x0c, 0x1e}, []int{60, 100}, []int{2, 0, 1}), uint8(modeDTLS), false)
+
+	f.Fuzz(func(t *testing.T, data []byte, mode uint8, sniOnly bool) {
+		orig := bytes.Clone(data)
+		opts := Options{SNIOnly: sniOnly}
+		var m ClientHello
+		switch mode % modes {
+		case modeTCP:
+			_ = m.UnmarshalWithOptions(data, opts)
+		case modeHandshake:
+			_ = m.UnmarshalHandshake(data, opts)
+		case modeDTLS:
+			_ = m.UnmarshalDTLS(data, opts)
+		}
+		if !bytes.Equal(data, orig) {
+			t.Fatalf("payload was modified")
+		}
+	})
+}
+
+func FuzzServerHello_Unmarshal(f *testing.F) {
+	tls12, _ := serverFlight(f, 0x0303, "device.example.com", "api.example.com")
+	tls13, _ := serverFlight(f, 0x0304, "device.example.com")
+	f.Add(tls12)
+	f.Add(tls13)
+	f.Add(cryptoTLSHello)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		orig := bytes.Clone(data)
+		var m ServerHello
+		if err := m.Unmarshal(data); err == nil && m.Version >= versionTLS13 {
+			t.Fatalf("certificate of TLS 1.3 server decoded")
+		}
+		if !bytes.Equal(data, orig) {
+			t.Fatalf("payload was modified")
+		}
+	})
+}
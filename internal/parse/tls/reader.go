@@ -0,0 +1,108 @@
+package tls
+
+import (
+	"encoding/binary"
+)
+
+// reader walks a byte slice and bounds checks every field it reads.
+// Errors are reported as a *TruncatedError naming the field.
+type reader struct {
+	b []byte
+	// off is the position of the next unread byte in b
+	off int
+	// base is the offset of b within the payload passed by the caller,
+	// used when reporting errors
+	base int
+}
+
+func (r *reader) empty() bool {
+	return r.off >= len(r.b)
+}
+
+func (r *reader) truncated(field string, need int) error {
+	return &TruncatedError{
+		Field:  field,
+		Offset: r.base + r.off,
+		Need:   need,
+		Have:   len(r.b) - r.off,
+	}
+}
+
+func (r *reader) uint8(field string) (uint8, error) {
+	if len(r.b)-r.off < 1 {
+		return 0, r.truncated(field, 1)
+	}
+	v := r.b[r.off]
+	r.off++
+	return v, nil
+}
+
+func (r *reader) uint16(field string) (uint16, error) {
+	if len(r.b)-r.off < 2 {
+		return 0, r.truncated(field, 2)
+	}
+	v := binary.BigEndian.Uint16(r.b[r.off:])
+	r.off += 2
+	return v, nil
+}
+
+func (r *reader) uint24(field string) (int, error) {
+	if len(r.b)-r.off < 3 {
+		return 0, r.truncated(field, 3)
+	}
+	v := int(r.b[r.off])<<16 | int(r.b[r.off+1])<<8 | int(r.b[r.off+2])
+	r.off += 3
+	return v, nil
+}
+
+func (r *reader) bytes(n int, field string) ([]byte, error) {
+	if len(r.b)-r.off < n {
+		return nil, r.truncated(field, n)
+	}
+	v := r.b[r.off : r.off+n]
+	r.off += n
+	return v, nil
+}
+
+// vector8 reads a vector with a 1 byte length prefix.
+func (r *reader) vector8(field string) ([]byte, error) {
+	n, err := r.uint8(field)
+	if err != nil {
+		return nil, err
+	}
+	return r.bytes(int(n), field)
+}
+
+// vector16 reads a vector with a 2 byte length prefix.
+func (r *reader) vector16(field string) ([]byte, error) {
+	n, err := r.uint16(field)
+	if err != nil {
+		return nil, err
+	}
+	return r.bytes(int(n), field)
+}
+
+// subVector16 reads a vector with a 2 byte length prefix and returns a reader
+// for its contents.
+func (r *reader) subVector16(field string) (reader, error) {
+	n, err := r.uint16(field)
+	if err != nil {
+		return reader{}, err
+	}
+	if len(r.b)-r.off < int(n) {
+		return reader{}, r.truncated(field, int(n))
+	}
+	return r.sub(int(n)), nil
+}
+
+// sub returns a reader for the next n bytes, or for the remainder of r if it
+// holds fewer than n bytes.
+func (r *reader) sub(n int) reader {
+	end := r.off + n
+	if end > len(r.b) {
+		end = len(r.b)
+	}
+	s := reader{b: r.b[r.off:end], base: r.base + r.off}
+	r.off = end
+	return s
+}
@@ -0,0 +1,195 @@
+package tls
+
+import (
+	"crypto/x509"
+	"errors"
+	"slices"
+)
+
+const (
+	handshakeTypeServerHello uint8 = 0x02
+	handshakeTypeCertificate uint8 = 0x0b
+
+	// versionTLS13 is the first version that encrypts the certificate
+	versionTLS13 uint16 = 0x0304
+)
+
+var UnmarshalServerHelloError = errors.New("insufficient bytes to Unmarshal serverhello and certificate")
+var UnmarshalEncryptedCertificateError = errors.New("server certificate is encrypted in TLS 1.3")
+var UnmarshalNoCertificateError = errors.New("server sent no certificate")
+var UnmarshalCertificateError = errors.New("malformed server certificate")
+
+// ServerHello is what a server sends in cleartext at the start of a TLS 1.2
+// handshake: its hello and, unless the session is resumed, the certificate
+// it identifies itself with.
+type ServerHello struct {
+	// Version is the negotiated version, from the supported_versions
+	// extension when the server sent it.
+	Version     uint16
+	CipherSuite uint16
+
+	// CommonName and DNSNames are the subject common name and the DNS
+	// subject alternative names of the leaf certificate. They are empty
+	// unless the certificate was found.
+	CommonName string
+	DNSNames   []string
+}
+
+// Names returns the names the leaf certificate is valid for, the common name
+// first, without duplicates.
+func (m *ServerHello) Names() []string {
+	names := make([]string, 0, 1+len(m.DNSNames))
+	if m.CommonName != "" {
+		names = append(names, m.CommonName)
+	}
+	for _, name := range m.DNSNames {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Unmarshal decodes the ServerHello and the leaf certificate from the start
+// of the stream of a server. The payload must start at the record content
+// type and may hold several records. UnmarshalServerHelloError is returned
+// when the certificate continues beyond the payload, and
+// UnmarshalEncryptedCertificateError when the server chose TLS 1.3.
+func (m *ServerHello) Unmarshal(payload []byte) error {
+	messages, ended, err := handshakeMessages(reader{b: payload})
+	if err != nil {
+		return err
+	}
+
+	r := reader{b: messages}
+	body, err := readHandshakeMessage(&r, ended)
+	if err != nil {
+		return err
+	}
+	if body.b == nil || body.b[0] != handshakeTypeServerHello {
+		return UnmarshalNoTLSHandshakeError
+	}
+	body.off = 4
+	if err := m.unmarshalHello(body); err != nil {
+		return err
+	}
+	if m.Version >= versionTLS13 {
+		return UnmarshalEncryptedCertificateError
+	}
+
+	body, err = readHandshakeMessage(&r, ended)
+	if err != nil {
+		return err
+	}
+	if body.b == nil || body.b[0] != handshakeTypeCertificate {
+		// A resumed session or an anonymous key exchange
+		return UnmarshalNoCertificateError
+	}
+	body.off = 4
+	return m.unmarshalCertificate(body)
+}
+
+// handshakeMessages concatenates the fragments of the consecutive handshake
+// records at the start of r. ended is set when a record of another type
+// follows them, so the handshake messages cannot continue.
+func handshakeMessages(r reader) (messages []byte, ended bool, err error) {
+	for !r.empty() {
+		fragment, err := readHandshakeRecord(&r)
+		switch {
+		case err == UnmarshalNoTLSError && messages != nil:
+			return messages, true, nil
+		case errors.Is(err, UnmarshalClientHelloError):
+			return messages, false, nil
+		case err != nil:
+			return nil, false, err
+		}
+		messages = append(messages, fragment.b...)
+	}
+	return messages, false, nil
+}
+
+// readHandshakeMessage returns a reader for the next handshake message of r,
+// including its header. The reader is empty when there are no more messages
+// and ended is set.
+func readHandshakeMessage(r *reader, ended bool) (reader, error) {
+	if r.empty() && ended {
+		return reader{}, nil
+	}
+	if !handshakeComplete(r.b[r.off:]) {
+		if ended {
+			return reader{}, UnmarshalNoTLSHandshakeError
+		}
+		return reader{}, UnmarshalServerHelloError
+	}
+	length := int(r.b[r.off+1])<<16 | int(r.b[r.off+2])<<8 | int(r.b[r.off+3])
+	return r.sub(4 + length), nil
+}
+
+func (m *ServerHello) unmarshalHello(r reader) error {
+	var err error
+	if m.Version, err = r.uint16("server version"); err != nil {
+		return UnmarshalNoTLSHandshakeError
+	}
+	if _, err = r.bytes(32, "server random"); err != nil {
+		return UnmarshalNoTLSHandshakeError
+	}
+	if _, err = r.vector8("session id"); err != nil {
+		return UnmarshalNoTLSHandshakeError
+	}
+	if m.CipherSuite, err = r.uint16("cipher suite"); err != nil {
+		return UnmarshalNoTLSHandshakeError
+	}
+	if _, err = r.uint8("compression method"); err != nil {
+		return UnmarshalNoTLSHandshakeError
+	}
+	if r.empty() {
+		// Servers of TLS 1.0 may omit the extensions
+		return nil
+	}
+
+	extensions, err := r.subVector16("extensions")
+	if err != nil {
+		return UnmarshalExtensionError
+	}
+	for !extensions.empty() {
+		extensionType, err := extensions.uint16("extension type")
+		if err != nil {
+			return UnmarshalExtensionError
+		}
+		data, err := extensions.vector16("extension data")
+		if err != nil {
+			return UnmarshalExtensionError
+		}
+		if extensionType == extensionSupportedVersions {
+			// The server selects a single version (RFC 8446, section 4.2.1)
+			if len(data) != 2 {
+				return UnmarshalExtensionError
+			}
+			m.Version = uint16(data[0])<<8 | uint16(data[1])
+		}
+	}
+	return nil
+}
+
+func (m *ServerHello) unmarshalCertificate(r reader) error {
+	list, err := r.uint24("certificate list length")
+	if err != nil || list == 0 {
+		return UnmarshalNoCertificateError
+	}
+	// The leaf certificate comes first (RFC 5246, section 7.4.2)
+	length, err := r.uint24("certificate length")
+	if err != nil {
+		return UnmarshalCertificateError
+	}
+	der, err := r.bytes(length, "certificate")
+	if err != nil {
+		return UnmarshalCertificateError
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return UnmarshalCertificateError
+	}
+	m.CommonName = leaf.Subject.CommonName
+	m.DNSNames = leaf.DNSNames
+	return nil
+}
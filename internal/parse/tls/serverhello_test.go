@@ -0,0 +1,175 @@
+package tls
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestServerHello_Unmarshal(t *testing.T) {
+	// A ServerHello that resumes a session, followed by ChangeCipherSpec
+	resumed := []byte{
+		0x16, 0x03, 0x03, 0x00, 0x2a, 0x02, 0x00, 0x00,
+		0x26, 0x03, 0x03, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0xc0, 0x2b, 0x00, 0x14,
+		0x03, 0x03, 0x00, 0x01, 0x01,
+	}
+
+	tests := []struct {
+		name      string
+		version   uint16
+		payload   []byte
+		want      *ServerHello
+		wantNames []string
+		wantErr   error
+	}{
+		{
+			name:    "TLS 1.2",
+			version: tls.VersionTLS12,
+			want: &ServerHello{
+				Version:    tls.VersionTLS12,
+				CommonName: "device.example.com",
+				DNSNames:   []string{"device.example.com", "api.example.com"},
+			},
+			wantNames: []string{"device.example.com", "api.example.com"},
+		},
+		{
+			name:    "TLS 1.3",
+			version: tls.VersionTLS13,
+			want:    &ServerHello{Version: tls.VersionTLS13},
+			wantErr: UnmarshalEncryptedCertificateError,
+		},
+		{
+			name:    "Resumed session",
+			payload: resumed,
+			want:    &ServerHello{Version: tls.VersionTLS12, CipherSuite: 0xc02b},
+			wantErr: UnmarshalNoCertificateError,
+		},
+		{
+			name:    "Hello cut short",
+			payload: resumed[:30],
+			want:    &ServerHello{},
+			wantErr: UnmarshalServerHelloError,
+		},
+		{
+			name:    "ClientHello",
+			payload: cryptoTLSHello,
+			want:    &ServerHello{},
+			wantErr: UnmarshalNoTLSHandshakeError,
+		},
+		{
+			name:    "Application data",
+			payload: []byte{0x17, 0x03, 0x03, 0x00, 0x02, 0xde, 0xad},
+			want:    &ServerHello{},
+			wantErr: UnmarshalNoTLSError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := tt.payload
+			if payload == nil {
+				var suite uint16
+				payload, suite = serverFlight(t, tt.version, "device.example.com", "api.example.com")
+				tt.want.CipherSuite = suite
+			}
+			got := &ServerHello{}
+			err := got.Unmarshal(payload)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Fatalf("unexpected ServerHello (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantNames, got.Names()); tt.wantNames != nil && diff != "" {
+				t.Fatalf("unexpected Names() (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestServerHello_Unmarshal_truncated(t *testing.T) {
+	flight, _ := serverFlight(t, tls.VersionTLS12, "device.example.com")
+
+	// The certificate is found once the stream is long enough, until then
+	// more bytes are needed
+	found := false
+	for n := 0; n <= len(flight); n++ {
+		got := &ServerHello{}
+		err := got.Unmarshal(flight[:n])
+		switch {
+		case err == nil:
+			found = true
+		case found || err != UnmarshalServerHelloError:
+			t.Fatalf("Unmarshal() of %d bytes: unexpected error %v", n, err)
+		}
+	}
+	if !found {
+		t.Fatalf("certificate not found")
+	}
+}
+
+// serverFlight returns what a crypto/tls server of the given version sends
+// to a client without SNI, with a certificate for the given names, and the
+// negotiated cipher suite.
+func serverFlight(t testing.TB, version uint16, names ...string) ([]byte, uint16) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: names[0]},
+		DNSNames:     names,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("creating certificate: %v", err)
+	}
+
+	// Relay the connection, recording what the server sends
+	client, clientRelay := net.Pipe()
+	server, serverRelay := net.Pipe()
+	var flight bytes.Buffer
+	done := make(chan struct{})
+	go func() { _, _ = io.Copy(serverRelay, clientRelay) }()
+	go func() {
+		_, _ = io.Copy(io.MultiWriter(clientRelay, &flight), serverRelay)
+		close(done)
+	}()
+	go func() {
+		conn := tls.Server(server, &tls.Config{
+			Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+			MinVersion:   version,
+			MaxVersion:   version,
+		})
+		_ = conn.Handshake()
+	}()
+
+	conn := tls.Client(client, &tls.Config{InsecureSkipVerify: true, MaxVersion: version})
+	if err := conn.Handshake(); err != nil {
+		t.Fatalf("handshake: %v", err)
+	}
+	suite := conn.ConnectionState().CipherSuite
+	_ = client.Close()
+	_ = server.Close()
+	<-done
+	return flight.Bytes(), suite
+}
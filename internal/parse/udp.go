@@ -1,28 +1,60 @@
 package parse
 
 import (
+	"bytes"
 	"encoding/binary"
 	"errors"
 	"fmt"
 
-	"github.com/jsimonetti/sniqueue/internal/parse/quic"
+	"github.com/jsimonetti/sniqueue/internal/parse/dns"
 	"github.com/jsimonetti/sniqueue/internal/parse/tls"
 )
 
 var unmarshalUDPError = errors.New("insufficient bytes to Unmarshal UDP")
 var errTruncatedPacket = errors.New("truncated packet")
 
+// dnsPort is the port of DNS over UDP and TCP
+const dnsPort = 53
+
 type UDP struct {
 	SourcePort      uint16
 	DestinationPort uint16
 	Hello           tls.ClientHello
+	// DTLS is set when Hello was carried in DTLS instead of QUIC.
+	DTLS bool
+	// DNS is set for DNS messages instead of Hello.
+	DNS *dns.Message
+	// QUIC describes the QUIC long header packet of the datagram.
+	QUIC QUICPacket
+	// Detected is the protocol the data looks like by its content.
+	Detected Detection
+	// VPN is set when the datagram starts a WireGuard or OpenVPN session.
+	VPN Classification
 }
 
 func (p *UDP) domainName() string {
+	if p.DNS != nil {
+		return p.DNS.Name()
+	}
 	return p.Hello.SNI
 }
 
-func (p *UDP) unmarshal(payload []byte) error {
+func (p *UDP) dnsMessage() *dns.Message {
+	return p.DNS
+}
+
+func (p *UDP) clientHello() *tls.ClientHello {
+	return &p.Hello
+}
+
+func (p *UDP) ja4() string {
+	if p.DTLS {
+		return p.Hello.JA4(tls.JA4DTLS)
+	}
+	return p.Hello.JA4(tls.JA4QUIC)
+}
+
+func (p *UDP) unmarshal(payload []byte, opts Options) error {
 	if len(payload) < 8 { // truncated/fragmented
 		return unmarshalUDPError
 	}
@@ -34,13 +66,58 @@ func (p *UDP) unmarshal(payload []byte) error {
 		return unmarshalUDPError
 	}
 	if length > len(payload) { // truncated/fragmented
-		return fmt.Errorf("%s %d > %d", errTruncatedPacket, length, len(payload))
+		return fmt.Errorf("%w %d > %d", errTruncatedPacket, length, len(payload))
+	}
+
+	if p.SourcePort == dnsPort || p.DestinationPort == dnsPort {
+		p.DNS = &dns.Message{}
+		return opts.wrapError(LayerDNS, 8, p.DNS.Unmarshal(payload[8:length]))
+	}
+
+	// WireGuard and OpenVPN carry no name, their classification is the
+	// result
+	if p.VPN = ClassifyUDP(payload[8:length]); p.VPN.Protocol != VPNNone {
+		return nil
+	}
+
+	// DTLS and QUIC are recognised by their content, so they are parsed on
+	// any port, and the other datagrams are not decrypted in vain
+	p.Detected = DetectUDP(payload[8:length])
+	switch p.Detected.Protocol {
+	case DetectedDTLS:
+		p.DTLS = true
+		err := p.Hello.UnmarshalDTLS(payload[8:length], opts.tls())
+		if p.Hello.Incomplete(err) {
+			// The hello continues in the next datagram, but we have the SNI
+			return nil
+		}
+		return opts.wrapError(LayerDTLS, 8, err)
+	case DetectedUnknown:
+		return opts.wrapError(LayerUDP, 8, tls.UnmarshalNoTLSError)
 	}
 
-	quick := &quic.Quic{}
-	if err := quick.Unmarshal(payload[8:]); err != nil {
-		return err
+	quick := opts.quic(p.Hello)
+	err := quick.UnmarshalWithOpeners(payload[8:], opts.tls(), opts.openers())
+	if quick.Header != nil {
+		p.QUIC = QUICPacket{
+			Version:                 quick.Header.Version,
+			Type:                    quick.Header.Type,
+			DestinationConnectionID: quick.Header.DestConnectionID,
+			SourceConnectionID:      quick.Header.SrcConnectionID,
+			Token:                   quick.Header.Token,
+			Versions:                quick.Header.Versions,
+			ZeroRTT:                 quick.ZeroRTT,
+		}
+		if opts.parser == nil {
+			// The header refers to the payload, like the hello of a Parser
+			p.QUIC.DestinationConnectionID = bytes.Clone(p.QUIC.DestinationConnectionID)
+			p.QUIC.SourceConnectionID = bytes.Clone(p.QUIC.SourceConnectionID)
+			p.QUIC.Token = bytes.Clone(p.QUIC.Token)
+		}
+	}
+	if err != nil {
+		return opts.wrapError(LayerQUIC, 8, err)
 	}
-	p.Hello.SNI = quick.Hello.SNI
+	p.Hello = quick.Hello
 	return nil
 }
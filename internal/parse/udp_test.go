@@ -3,6 +3,8 @@ package parse
 import (
 	"testing"
 
+	"github.com/jsimonetti/sniqueue/internal/parse/dns"
+	"github.com/jsimonetti/sniqueue/internal/parse/quic"
 	"github.com/jsimonetti/sniqueue/internal/parse/tls"
 
 	"github.com/google/go-cmp/cmp"
@@ -197,8 +199,70 @@ func TestUDP_unmarshal(t *testing.T) {
 			want: &UDP{
 				SourcePort:      52832,
 				DestinationPort: 443,
+				Detected:        Detection{Protocol: DetectedQUIC, Confidence: ConfidenceHigh},
 				Hello: tls.ClientHello{
-					SNI: "r2---sn-fxc25nn-nwje.googlevideo.com",
+					SNI:                 "r2---sn-fxc25nn-nwje.googlevideo.com",
+					Version:             0x0303,
+					CipherSuites:        []uint16{0x1301, 0x1302, 0x1303},
+					Extensions:          []uint16{0x0000, 0x000a, 0x0010, 0x000d, 0x0033, 0x002d, 0x002b, 0x0039, 0x001b, 0x4469},
+					ALPN:                []string{"h3"},
+					SupportedVersions:   []uint16{0x0304},
+					SupportedGroups:     []uint16{0x001d, 0x0017, 0x0018},
+					SignatureAlgorithms: []uint16{0x0403, 0x0804, 0x0401, 0x0503, 0x0805, 0x0501, 0x0806, 0x0601, 0x0201},
+				},
+				QUIC: QUICPacket{
+					Version:                 quic.Version1,
+					DestinationConnectionID: []byte{0xc3, 0xc3, 0xa5, 0x0f, 0xa4, 0x2a, 0xe0, 0x7d},
+					Token:                   []byte{},
+				},
+			},
+		},
+		{
+			name: "DTLS",
+			payload: []byte{
+				0xc3, 0x50, 0x01, 0xbb, 0x00, 0x66, 0x00, 0x00,
+				0x16, 0xfe, 0xfd, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x00, 0x51, 0x01, 0x00, 0x00,
+				0x45, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x45, 0xfe, 0xfd, 0x00, 0x01, 0x02, 0x03, 0x04,
+				0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c,
+				0x0d, 0x0e, 0x0f, 0x10, 0x11, 0x12, 0x13, 0x14,
+				0x15, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c,
+				0x1d, 0x1e, 0x1f, 0x00, 0x00, 0x00, 0x02, 0xc0,
+				0x2b, 0x01, 0x00, 0x00, 0x19, 0x00, 0x00, 0x00,
+				0x15, 0x00, 0x13, 0x00, 0x00, 0x10, 0x74, 0x75,
+				0x72, 0x6e, 0x2e, 0x65, 0x78, 0x61, 0x6d, 0x70,
+				0x6c, 0x65, 0x2e, 0x6f, 0x72, 0x67,
+			},
+			want: &UDP{
+				SourcePort:      50000,
+				DestinationPort: 443,
+				DTLS:            true,
+				Detected:        Detection{Protocol: DetectedDTLS, Confidence: ConfidenceHigh},
+				Hello: tls.ClientHello{
+					SNI:          "turn.example.org",
+					Version:      0xfefd,
+					CipherSuites: []uint16{0xc02b},
+					Extensions:   []uint16{0x0000},
+				},
+			},
+		},
+		{
+			name: "DNS query",
+			payload: []byte{
+				0x9c, 0x40, 0x00, 0x35, 0x00, 0x29, 0x00, 0x00,
+				0xbe, 0xef, 0x01, 0x00, 0x00, 0x01, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x00, 0x03, 0x61, 0x64, 0x73,
+				0x07, 0x65, 0x78, 0x61, 0x6d, 0x70, 0x6c, 0x65,
+				0x03, 0x6e, 0x65, 0x74, 0x00, 0x00, 0x1c, 0x00,
+				0x01,
+			},
+			want: &UDP{
+				SourcePort:      40000,
+				DestinationPort: 53,
+				DNS: &dns.Message{
+					ID:        0xbeef,
+					Questions: []dns.Question{{Name: "ads.example.net", Type: dns.TypeAAAA, Class: 1}},
 				},
 			},
 		},
@@ -206,7 +270,7 @@ func TestUDP_unmarshal(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			got := &UDP{}
-			if err := got.unmarshal(tt.payload); err != nil {
+			if err := got.unmarshal(tt.payload, Options{}); err != nil {
 				if tt.wantErr {
 					return
 				}
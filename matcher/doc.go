@@ -0,0 +1,10 @@
+// Package matcher matches the names and fingerprints extracted by package
+// sniparse against lists loaded from files or built in memory.
+//
+// A Tree holds domain names, with "*." wildcard entries for whole domains.
+// A Set holds entries that must match exactly, such as JA3 and JA4
+// fingerprints. Both are safe for concurrent Match calls once loaded, but
+// must not be appended to while being matched against.
+//
+// The API is not stable before v1, see the sniparse package documentation.
+package matcher
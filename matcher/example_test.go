@@ -0,0 +1,29 @@
+package matcher_test
+
+import (
+	"fmt"
+
+	"github.com/jsimonetti/sniqueue/matcher"
+)
+
+func ExampleTree() {
+	list := matcher.New()
+	list.Append([]string{"dns.google", "*.example.com"})
+
+	fmt.Println(list.Match("dns.google"))
+	fmt.Println(list.Match("www.example.com"))
+	fmt.Println(list.Match("example.org"))
+	// Output:
+	// true
+	// true
+	// false
+}
+
+func ExampleSet() {
+	fingerprints := matcher.NewSet()
+	fingerprints.Append([]string{"t13d1516h2_8daaf6152771_e5627efa2ab1"})
+
+	fmt.Println(fingerprints.Match("T13D1516H2_8DAAF6152771_E5627EFA2AB1"))
+	// Output:
+	// true
+}
@@ -1,4 +1,4 @@
-package tree
+package matcher
 
 import (
 	"bufio"
@@ -7,6 +7,7 @@ import (
 	"os"
 )
 
+// LoadFile adds the domain names in a file, one per line.
 func (t *Tree) LoadFile(filename string) error {
 	list, err := readLines(filename)
 	if err != nil {
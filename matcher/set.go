@@ -0,0 +1,54 @@
+package matcher
+
+import (
+	"strings"
+)
+
+// Set is a list of entries that only match exactly, such as TLS fingerprints.
+type Set struct {
+	entries map[string]struct{}
+}
+
+// NewSet returns an empty Set.
+func NewSet() Set {
+	return Set{
+		entries: make(map[string]struct{}),
+	}
+}
+
+// Size returns the number of distinct entries.
+func (s *Set) Size() int {
+	return len(s.entries)
+}
+
+// Match reports whether entry is in the set, ignoring case.
+func (s *Set) Match(entry string) bool {
+	if len(entry) < 1 {
+		return false
+	}
+	_, found := s.entries[strings.ToLower(entry)]
+	return found
+}
+
+// Append adds the entries in list to the set. Surrounding white space is
+// removed and empty entries are skipped.
+func (s *Set) Append(list []string) *Set {
+	for _, entry := range list {
+		entry = strings.TrimSpace(entry)
+		if len(entry) < 1 {
+			continue
+		}
+		s.entries[strings.ToLower(entry)] = struct{}{}
+	}
+	return s
+}
+
+// LoadFile adds the entries in a file, one per line.
+func (s *Set) LoadFile(filename string) error {
+	list, err := readLines(filename)
+	if err != nil {
+		return err
+	}
+	s.Append(list)
+	return nil
+}
@@ -0,0 +1,71 @@
+package matcher
+
+import (
+	"testing"
+)
+
+func TestSet_Match(t *testing.T) {
+	tests := []struct {
+		name        string
+		set         *Set
+		fingerprint string
+		found       bool
+	}{
+		{
+			name:        "Not in list",
+			set:         testSet(t),
+			fingerprint: "t13d1516h2_8daaf6152771_e5627efa2ab1",
+			found:       false,
+		},
+		{
+			name:        "Not in list empty",
+			set:         testSet(t),
+			fingerprint: "",
+			found:       false,
+		},
+		{
+			name:        "JA3 in list",
+			set:         testSet(t),
+			fingerprint: "aaff0f715426f3a10d6a7184954803cb",
+			found:       true,
+		},
+		{
+			name:        "JA4 in list",
+			set:         testSet(t),
+			fingerprint: "q13d0310h3_55b375c5d22e_cd85d2d88918",
+			found:       true,
+		},
+		{
+			name:        "Case insensitive",
+			set:         testSet(t),
+			fingerprint: "AAFF0F715426F3A10D6A7184954803CB",
+			found:       true,
+		},
+		{
+			name:        "In list after append",
+			set:         testSet(t).Append([]string{" t13d1516h2_8daaf6152771_e5627efa2ab1 "}),
+			fingerprint: "t13d1516h2_8daaf6152771_e5627efa2ab1",
+			found:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t1 *testing.T) {
+			if found := tt.set.Match(tt.fingerprint); found != tt.found {
+				t1.Errorf("Match(%s) = %v, want %v", tt.fingerprint, found, tt.found)
+			}
+		})
+	}
+}
+
+func testSet(t *testing.T) *Set {
+	t.Helper()
+	set := NewSet()
+	set.Append(fingerprints)
+	return &set
+}
+
+var fingerprints = []string{
+	"aaff0f715426f3a10d6a7184954803cb",
+	"q13d0310h3_55b375c5d22e_cd85d2d88918",
+	"",
+}
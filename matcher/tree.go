@@ -1,4 +1,4 @@
-package tree
+package matcher
 
 import (
 	"unicode"
@@ -6,25 +6,33 @@ import (
 	"github.com/Lochnair/go-patricia/patricia"
 )
 
+// Tree is a list of domain names, stored reversed in a trie so they are
+// compared from the last label to the first. The zero value is not usable,
+// create a Tree with New.
 type Tree struct {
 	domainTrie *patricia.Trie
 	size       int
 }
 
+// New returns an empty Tree.
 func New() Tree {
 	return Tree{
 		domainTrie: patricia.NewTrie(),
 	}
 }
+
+// Size returns the number of entries that were added.
 func (t *Tree) Size() int {
 	return t.size
 }
 
+// Match reports whether domainName matches an entry of the tree. An entry
+// that starts with "*." also matches all subdomains of the rest of it.
 func (t *Tree) Match(domainName string) bool {
 	if len(domainName) < 1 {
 		return false
 	}
-	reversedDomain := Reverse(domainName)
+	reversedDomain := reverseText(domainName)
 	_, _, found, leftover := t.domainTrie.FindSubtree(patricia.Prefix(reversedDomain))
 
 	/*
@@ -34,17 +42,18 @@ func (t *Tree) Match(domainName string) bool {
 	return found || (len(leftover) > 0 && leftover[0] == 42)
 }
 
+// Append adds the domain names in list to the tree.
 func (t *Tree) Append(list []string) *Tree {
 	for _, domain := range list {
-		reversedDomain := Reverse(domain)
+		reversedDomain := reverseText(domain)
 		t.domainTrie.Insert(patricia.Prefix(reversedDomain), 0)
 		t.size++
 	}
 	return t
 }
 
-// Reverse reverses the input while respecting UTF8 encoding and combined characters
-func Reverse(text string) string {
+// reverseText reverses the input while respecting UTF8 encoding and combined characters
+func reverseText(text string) string {
 	textRunes := []rune(text)
 	textRunesLength := len(textRunes)
 	if textRunesLength <= 1 {
@@ -54,11 +63,11 @@ func Reverse(text string) string {
 	i, j := 0, 0
 	for i < textRunesLength && j < textRunesLength {
 		j = i + 1
-		for j < textRunesLength && IsMark(textRunes[j]) {
+		for j < textRunesLength && isMark(textRunes[j]) {
 			j++
 		}
 
-		if IsMark(textRunes[j-1]) {
+		if isMark(textRunes[j-1]) {
 			// Reverses Combined Characters
 			reverse(textRunes[i:j], j-i)
 		}
@@ -72,7 +81,8 @@ func Reverse(text string) string {
 	return string(textRunes)
 }
 
-func IsMark(r rune) bool {
+// isMark reports whether r is a combining mark.
+func isMark(r rune) bool {
 	return unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Me, r) || unicode.Is(unicode.Mc, r)
 }
 
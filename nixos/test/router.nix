@@ -65,17 +65,17 @@ in
 
       chain sniqueue {
         type filter hook forward priority -2; policy accept;
-        ip daddr @blocklist4 tcp dport 443 reject
+        ip daddr @blocklist4 tcp dport { 80, 443 } reject
         ip daddr @blocklist4 udp dport 443 reject
-        ip6 daddr @blocklist6 tcp dport 443 reject
+        ip6 daddr @blocklist6 tcp dport { 80, 443 } reject
         ip6 daddr @blocklist6 udp dport 443 reject
 
         ct mark 101 accept comment "Accept known good SNI not yet offloaded"
         ct mark 100 reject comment "Reject known bad SNI"
-        tcp dport 443 ct mark set 102 comment "Mark all unjudged packets"
+        tcp dport { 80, 443 } ct mark set 102 comment "Mark all unjudged packets"
         udp dport 443 ct mark set 102 comment "Mark all unjudged packets"
         meta mark set ct mark
-        tcp dport 443 ct original packets <20 queue num 100 bypass
+        tcp dport { 80, 443 } ct original packets <20 queue num 100 bypass
         udp dport 443 ct original packets <20 queue num 100 bypass
       }
 
@@ -0,0 +1,37 @@
+// Package sniparse extracts the server name and the handshake metadata of
+// the connections in IP packets, as delivered by NFQUEUE, a TUN device or a
+// packet capture with Ethernet or Linux cooked capture headers.
+//
+// It recognises TLS ClientHellos over TCP, cleartext HTTP requests, DNS
+// messages, DTLS ClientHellos and QUIC Initial packets, which are decrypted
+// to reach their ClientHello, including the Google QUIC versions. Optionally
+// it strips tunnel headers, reassembles IP fragments, follows STARTTLS
+// upgrades of mail and chat protocols, reassembles HTTP requests split
+// before their Host header and extracts the certificate of TLS 1.2
+// servers whose clients send no SNI.
+//
+// Parse and ParseWithOptions return a new Packet for every call. A Parser
+// reuses its state instead, which avoids allocating for the common cases of
+// a TLS ClientHello in a single TCP segment and of a QUIC Initial whose keys
+// are cached with Options.QUICKeys:
+//
+//	parser := sniparse.NewParser(sniparse.Options{SNIOnly: true})
+//	for payload := range packets {
+//		p, err := parser.Parse(payload)
+//		if err != nil {
+//			continue
+//		}
+//		fmt.Println(p.Destination, p.Name)
+//	}
+//
+// Package matcher matches the extracted names and fingerprints against
+// domain and fingerprint lists.
+//
+// # Versioning
+//
+// The module has no tagged release, and the API of sniparse and matcher is
+// not stable before v1: it may change in incompatible ways in any commit.
+// Many types of sniparse, such as ClientHello, Error and the trackers, are
+// aliases of the types of the internal parsers, so a Packet is filled
+// without copying. Their fields and methods change with the parsers.
+package sniparse
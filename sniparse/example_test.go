@@ -0,0 +1,73 @@
+package sniparse_test
+
+import (
+	"fmt"
+
+	"github.com/jsimonetti/sniqueue/matcher"
+	"github.com/jsimonetti/sniqueue/sniparse"
+)
+
+func ExampleParse() {
+	// An IPv4 packet with a cleartext HTTP request
+	packet := []byte{
+		0x45, 0x00, 0x00, 0x4d, 0x00, 0x00, 0x40, 0x00,
+		0x40, 0x06, 0x00, 0x00, 192, 0, 2, 1,
+		198, 51, 100, 1,
+
+		0xc7, 0x38, 0x00, 0x50, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x50, 0x18, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00,
+	}
+	packet = append(packet, "GET / HTTP/1.1\r\nHost: example.com\r\n\r\n"...)
+
+	p, err := sniparse.Parse(packet)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(p.Protocol, p.Transport, p.Destination, p.DestinationPort, p.Name)
+	// Output:
+	// http tcp 198.51.100.1 80 example.com
+}
+
+func ExampleParser() {
+	list := matcher.New()
+	list.Append([]string{"*.example.com"})
+	parser := sniparse.NewParser(sniparse.Options{SNIOnly: true})
+
+	// handle reports whether a packet may pass
+	handle := func(payload []byte) bool {
+		p, err := parser.Parse(payload)
+		if err != nil {
+			// Not interesting or malformed, let it pass
+			return true
+		}
+		// p is only valid until the next call to Parse
+		return !list.Match(p.Name)
+	}
+
+	for _, host := range []string{"www.example.com", "example.org"} {
+		fmt.Println(host, handle(httpPacket(host)))
+	}
+	fmt.Println("not IP", handle([]byte{0x00}))
+	// Output:
+	// www.example.com false
+	// example.org true
+	// not IP true
+}
+
+// httpPacket returns an IPv4 packet with a cleartext HTTP request for host.
+func httpPacket(host string) []byte {
+	request := "GET / HTTP/1.1\r\nHost: " + host + "\r\n\r\n"
+	length := 40 + len(request)
+	packet := []byte{
+		0x45, 0x00, byte(length >> 8), byte(length), 0x00, 0x00, 0x40, 0x00,
+		0x40, 0x06, 0x00, 0x00, 192, 0, 2, 1,
+		198, 51, 100, 1,
+
+		0xc7, 0x38, 0x00, 0x50, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x50, 0x18, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00,
+	}
+	return append(packet, request...)
+}
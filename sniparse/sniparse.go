@@ -0,0 +1,343 @@
+package sniparse
+
+import (
+	"net"
+
+	"github.com/jsimonetti/sniqueue/internal/parse"
+	"github.com/jsimonetti/sniqueue/internal/parse/dns"
+	"github.com/jsimonetti/sniqueue/internal/parse/tls"
+)
+
+// Protocol is the application protocol a packet was recognised as.
+type Protocol int
+
+const (
+	// ProtocolUnknown is a packet without anything to extract, such as a
+	// TCP segment without data.
+	ProtocolUnknown Protocol = iota
+	ProtocolTLS
+	ProtocolHTTP
+	ProtocolDNS
+	ProtocolDTLS
+	// ProtocolQUIC is an IETF or Google QUIC Initial packet.
+	ProtocolQUIC
+)
+
+func (p Protocol) String() string {
+	switch p {
+	case ProtocolTLS:
+		return "tls"
+	case ProtocolHTTP:
+		return "http"
+	case ProtocolDNS:
+		return "dns"
+	case ProtocolDTLS:
+		return "dtls"
+	case ProtocolQUIC:
+		return "quic"
+	}
+	return "unknown"
+}
+
+// Transport is the transport protocol of a packet, by its IP protocol number.
+type Transport int
+
+const (
+	TCP Transport = 6
+	UDP Transport = 17
+)
+
+func (t Transport) String() string {
+	switch t {
+	case TCP:
+		return "tcp"
+	case UDP:
+		return "udp"
+	}
+	return "unknown"
+}
+
+// Packet is the result of parsing an IP packet.
+type Packet struct {
+	// SourceMAC and DestinationMAC are the addresses of the link layer
+	// header, when parsed with a link type that has them.
+	SourceMAC      net.HardwareAddr
+	DestinationMAC net.HardwareAddr
+	// VLANs lists the IDs of the 802.1Q and 802.1ad VLAN tags of the frame,
+	// outermost first.
+	VLANs []uint16
+
+	// IPVersion is 4 or 6.
+	IPVersion   int
+	Source      net.IP
+	Destination net.IP
+
+	Transport       Transport
+	SourcePort      uint16
+	DestinationPort uint16
+	// TCPFlags and TCPOptions are the control bits and the options of a
+	// TCP segment.
+	TCPFlags   TCPFlags
+	TCPOptions TCPOptions
+
+	Protocol Protocol
+	// Detected is the protocol the payload looks like by its content,
+	// whatever its ports, and Confidence is how sure that is. Unlike
+	// Protocol, it is also set when the payload could not be parsed. Only
+	// ProtocolTLS, ProtocolDTLS and ProtocolQUIC are detected.
+	Detected   Protocol
+	Confidence Confidence
+	// VPN is the tunnel or proxy protocol whose handshake the packet
+	// carries, and VPNConfidence how sure that is. Packets of WireGuard,
+	// OpenVPN and SSH are parsed without error and without Name. Tor is
+	// recognised with low confidence by the server name of its TLS hello.
+	VPN           VPNProtocol
+	VPNConfidence Confidence
+	// Name is the name of the server the client connects to: the SNI of a
+	// TLS, DTLS or QUIC hello, the Host of an HTTP request or the question
+	// of a DNS message. It is empty when the packet does not carry one.
+	Name string
+	// TLSVersion is the newest TLS or DTLS version offered by the hello. It
+	// is 0 when the hello was parsed with Options.SNIOnly.
+	TLSVersion uint16
+	// ALPN lists the application protocols offered by the hello.
+	ALPN []string
+	// QUIC describes the QUIC long header packet of a datagram, which is
+	// also set for the packets that are not Initials.
+	QUIC QUICPacket
+
+	// Hello holds everything that was decoded from the ClientHello. It is
+	// nil unless Protocol is ProtocolTLS, ProtocolDTLS or ProtocolQUIC.
+	Hello *ClientHello
+	// Server holds the ServerHello and the names of the certificate of a
+	// TLS 1.2 server, on the segments of the server from the one that
+	// completes the certificate until the connection expires. It is only
+	// set for connections followed by Options.ServerCertificates, whose
+	// hello had no server name.
+	Server *ServerHello
+	// HTTP is the HTTP request for ProtocolHTTP. Its Hosts lists every
+	// host the request names, which may differ from Name.
+	HTTP *HTTPRequest
+	// DNS is the DNS message for ProtocolDNS.
+	DNS *DNSMessage
+	// Tunnels lists the tunnel headers that were stripped to reach the
+	// packet, outermost first.
+	Tunnels []Tunnel
+}
+
+// ECH reports whether the hello uses Encrypted Client Hello. Name then
+// holds the public name of the client facing server, not the real one.
+func (p *Packet) ECH() bool {
+	return p.Hello != nil && p.Hello.ECH != nil
+}
+
+// JA3 returns the JA3 fingerprint of the hello, or an empty string when
+// there is no fully decoded hello.
+func (p *Packet) JA3() string {
+	if p.Hello == nil {
+		return ""
+	}
+	return p.Hello.JA3()
+}
+
+// JA4 returns the JA4 fingerprint of the hello, or an empty string when
+// there is no fully decoded hello.
+func (p *Packet) JA4() string {
+	if p.Hello == nil || p.Hello.Version == 0 {
+		return ""
+	}
+	switch p.Protocol {
+	case ProtocolDTLS:
+		return p.Hello.JA4(tls.JA4DTLS)
+	case ProtocolQUIC:
+		return p.Hello.JA4(tls.JA4QUIC)
+	}
+	return p.Hello.JA4(tls.JA4TCP)
+}
+
+// Options control how much of a packet is parsed.
+type Options struct {
+	// Link is the link layer header in front of the IP header. The default
+	// LinkRaw expects the packet to start with the IP header.
+	Link LinkType
+	// SNIOnly only extracts the server name (and ECH presence) from
+	// ClientHellos, skipping the other metadata and the fingerprints.
+	SNIOnly bool
+	// MaxTunnels is the number of nested IPIP, IPv6 in IP, GRE and VXLAN
+	// headers that are stripped to reach the inner packet. Zero disables
+	// decapsulation.
+	MaxTunnels int
+	// StartTLS follows flows on its ports until they upgrade to TLS. While
+	// waiting, their segments return StartTLSPendingError.
+	StartTLS *StartTLS
+	// HTTP follows the cleartext HTTP requests whose Host header is not in
+	// their first segment. While waiting, their segments return
+	// HTTPPendingError.
+	HTTP *HTTPRequests
+	// QUICKeys is the number of QUIC connections a Parser caches the
+	// Initial keys of. Zero disables the cache.
+	QUICKeys int
+	// QUIC follows QUIC connections, to correlate the Initials of a client
+	// with the Retry and Version Negotiation packets of the server.
+	QUIC *QUICConnections
+	// ServerCertificates follows the TLS connections whose ClientHello has
+	// no server name, to extract the names of the certificate of the
+	// server instead. While waiting, the segments of the server return
+	// ServerCertificatePendingError.
+	ServerCertificates *ServerCertificates
+}
+
+func (o Options) parse() parse.Options {
+	return parse.Options{
+		Link:       o.Link,
+		SNIOnly:    o.SNIOnly,
+		MaxTunnels: o.MaxTunnels,
+		StartTLS:   o.StartTLS,
+		HTTP:       o.HTTP,
+		QUICKeys:   o.QUICKeys,
+		QUIC:       o.QUIC,
+
+		ServerCertificates: o.ServerCertificates,
+	}
+}
+
+// Parse parses payload, which must start at the IP header, using the default
+// Options.
+func Parse(payload []byte) (*Packet, error) {
+	return ParseWithOptions(payload, Options{})
+}
+
+// ParseFrame parses a frame that starts with a link layer header of the
+// given type, such as a packet read from a pcap file.
+func ParseFrame(frame []byte, link LinkType) (*Packet, error) {
+	return ParseWithOptions(frame, Options{Link: link})
+}
+
+// ParseWithOptions parses payload, which must start at the header selected by
+// Options.Link. When
+// the packet is not interesting or malformed, the returned Packet holds what
+// was parsed before the error, or is nil when payload is not an IP packet.
+// QUIC Initials are decrypted in place, which modifies payload.
+func ParseWithOptions(payload []byte, opts Options) (*Packet, error) {
+	p, err := parse.ParseWithOptions(payload, opts.parse())
+	if p == nil {
+		return nil, err
+	}
+	packet := &Packet{}
+	packet.fill(p)
+	return packet, err
+}
+
+// Parser parses packets into state that is reused from one packet to the
+// next. The Packet returned by Parse, including its strings and slices, is
+// only valid until the next call to Parse and as long as the payload is not
+// modified or reused. So is the *Error it returns, which is overwritten by
+// the error of the next packet: format or copy it to keep it. A Parser is
+// not safe for concurrent use, use one per goroutine.
+type Parser struct {
+	parser *parse.Parser
+	packet Packet
+}
+
+// NewParser returns a Parser that parses packets as specified by opts.
+func NewParser(opts Options) *Parser {
+	return &Parser{parser: parse.NewParser(opts.parse())}
+}
+
+// Parse parses payload like ParseWithOptions, reusing the state of the
+// previous packet.
+func (p *Parser) Parse(payload []byte) (*Packet, error) {
+	pkt, err := p.parser.Parse(payload)
+	if pkt == nil {
+		return nil, err
+	}
+	p.packet.fill(pkt)
+	return &p.packet, err
+}
+
+// packet is what the internal parser returns for IP packets.
+type packet interface {
+	DomainName() string
+	Hello() *tls.ClientHello
+	DNS() *dns.Message
+}
+
+// fill sets the fields of p from the internal representation of a packet.
+func (p *Packet) fill(pkt packet) {
+	*p = Packet{Name: pkt.DomainName()}
+
+	var inet *parse.Inet
+	switch v := pkt.(type) {
+	case *parse.IPv4:
+		inet = &v.Inet
+	case *parse.IPv6:
+		inet = &v.Inet
+	default:
+		return
+	}
+	p.IPVersion = inet.IPVersion
+	p.Source = inet.Source
+	p.Destination = inet.Destination
+	p.Tunnels = inet.Tunnels
+	if inet.Link != nil {
+		p.SourceMAC = inet.Link.Source
+		p.DestinationMAC = inet.Link.Destination
+		p.VLANs = inet.Link.VLANs
+	}
+
+	var hello *tls.ClientHello
+	switch t := inet.Transport.(type) {
+	case *parse.TCP:
+		p.Transport, p.SourcePort, p.DestinationPort = TCP, t.SourcePort, t.DestinationPort
+		p.TCPFlags, p.TCPOptions = t.Flags, t.Options
+		p.Detected, p.Confidence = detected(t.Detected)
+		p.VPN, p.VPNConfidence = t.VPN.Protocol, t.VPN.Confidence
+		switch {
+		case t.Server != nil:
+			p.Protocol, p.Server = ProtocolTLS, t.Server
+		case t.HTTP != nil:
+			p.Protocol, p.HTTP = ProtocolHTTP, t.HTTP
+		case t.DNS != nil:
+			p.Protocol, p.DNS = ProtocolDNS, t.DNS
+		default:
+			p.Protocol, hello = ProtocolTLS, &t.Hello
+		}
+	case *parse.UDP:
+		p.Transport, p.SourcePort, p.DestinationPort = UDP, t.SourcePort, t.DestinationPort
+		p.QUIC = t.QUIC
+		p.Detected, p.Confidence = detected(t.Detected)
+		p.VPN, p.VPNConfidence = t.VPN.Protocol, t.VPN.Confidence
+		switch {
+		case t.DNS != nil:
+			p.Protocol, p.DNS = ProtocolDNS, t.DNS
+		case t.DTLS:
+			p.Protocol, hello = ProtocolDTLS, &t.Hello
+		default:
+			p.Protocol, hello = ProtocolQUIC, &t.Hello
+		}
+	}
+
+	if hello != nil && hello.SNI == "" && hello.Version == 0 && hello.ECH == nil {
+		// Nothing was decoded, this is not a hello
+		p.Protocol = ProtocolUnknown
+		return
+	}
+	if hello != nil {
+		p.Hello = hello
+		p.TLSVersion = hello.MaxVersion()
+		p.ALPN = hello.ALPN
+	}
+}
+
+// detected returns the Protocol of a detection.
+func detected(d parse.Detection) (Protocol, Confidence) {
+	switch d.Protocol {
+	case parse.DetectedTLS:
+		return ProtocolTLS, d.Confidence
+	case parse.DetectedDTLS:
+		return ProtocolDTLS, d.Confidence
+	case parse.DetectedQUIC:
+		return ProtocolQUIC, d.Confidence
+	}
+	return ProtocolUnknown, ConfidenceNone
+}
@@ -0,0 +1,444 @@
+package sniparse
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestParse(t *testing.T) {
+	hello := clientHello(t, "www.example.com")
+	tests := []struct {
+		name    string
+		payload []byte
+		want    *Packet
+		wantErr bool
+	}{
+		{
+			name:    "Not IP",
+			payload: []byte{0x00},
+			wantErr: true,
+		},
+		{
+			name:    "TLS",
+			payload: ip4Packet(6, tcpSegment(51000, 443, hello)),
+			want: &Packet{
+				IPVersion:       4,
+				Source:          net.IP{192, 0, 2, 1},
+				Destination:     net.IP{198, 51, 100, 1},
+				Transport:       TCP,
+				SourcePort:      51000,
+				DestinationPort: 443,
+				TCPFlags:        TCPFlagPSH | TCPFlagACK,
+				Protocol:        ProtocolTLS,
+				Detected:        ProtocolTLS,
+				Confidence:      ConfidenceHigh,
+				Name:            "www.example.com",
+				TLSVersion:      0x0304,
+				ALPN:            []string{"h2", "http/1.1"},
+			},
+		},
+		{
+			name:    "HTTP",
+			payload: ip4Packet(6, tcpSegment(51000, 80, []byte("GET / HTTP/1.1\r\nHost: www.example.com:8080\r\n\r\n"))),
+			want: &Packet{
+				IPVersion:       4,
+				Source:          net.IP{192, 0, 2, 1},
+				Destination:     net.IP{198, 51, 100, 1},
+				Transport:       TCP,
+				SourcePort:      51000,
+				DestinationPort: 80,
+				TCPFlags:        TCPFlagPSH | TCPFlagACK,
+				Protocol:        ProtocolHTTP,
+				Name:            "www.example.com",
+				HTTP: &HTTPRequest{
+					Method:  "GET",
+					Target:  "/",
+					Version: "HTTP/1.1",
+					Host:    "www.example.com",
+					Hosts:   []string{"www.example.com"},
+				},
+			},
+		},
+		{
+			name: "DNS",
+			payload: ip4Packet(17, udpDatagram(51000, 53, []byte{
+				0x12, 0x34, 0x01, 0x00, 0x00, 0x01, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x00,
+				0x03, 'w', 'w', 'w', 0x07, 'e', 'x', 'a', 'm', 'p', 'l', 'e', 0x03, 'c', 'o', 'm', 0x00,
+				0x00, 0x01, 0x00, 0x01,
+			})),
+			want: &Packet{
+				IPVersion:       4,
+				Source:          net.IP{192, 0, 2, 1},
+				Destination:     net.IP{198, 51, 100, 1},
+				Transport:       UDP,
+				SourcePort:      51000,
+				DestinationPort: 53,
+				Protocol:        ProtocolDNS,
+				Name:            "www.example.com",
+			},
+		},
+		{
+			name:    "WireGuard",
+			payload: ip4Packet(17, udpDatagram(51000, 51820, append([]byte{0x01, 0x00, 0x00, 0x00}, make([]byte, 144)...))),
+			want: &Packet{
+				IPVersion:       4,
+				Source:          net.IP{192, 0, 2, 1},
+				Destination:     net.IP{198, 51, 100, 1},
+				Transport:       UDP,
+				SourcePort:      51000,
+				DestinationPort: 51820,
+				VPN:             VPNWireGuard,
+				VPNConfidence:   ConfidenceHigh,
+			},
+		},
+		{
+			name:    "No data",
+			payload: ip4Packet(6, tcpSegment(51000, 443, nil)),
+			want: &Packet{
+				IPVersion:       4,
+				Source:          net.IP{192, 0, 2, 1},
+				Destination:     net.IP{198, 51, 100, 1},
+				Transport:       TCP,
+				SourcePort:      51000,
+				DestinationPort: 443,
+				TCPFlags:        TCPFlagPSH | TCPFlagACK,
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.payload)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			// The details are covered by the tests of the internal parsers
+			if diff := cmp.Diff(tt.want, got, cmpopts.IgnoreFields(Packet{}, "Hello", "DNS")); diff != "" {
+				t.Fatalf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParse_serverCertificates(t *testing.T) {
+	hello, flight := tls12Handshake(t, "device.example.com", "api.example.com")
+	opts := Options{ServerCertificates: NewServerCertificates(8, time.Minute)}
+
+	// The hello acknowledges the first byte of the server
+	segment := tcpSegment(51000, 443, hello)
+	binary.BigEndian.PutUint32(segment[8:12], 1)
+	p, err := ParseWithOptions(ip4Packet(6, segment), opts)
+	if err != nil || p.Name != "" {
+		t.Fatalf("ParseWithOptions() = %q, %v, want a hello without name", p.Name, err)
+	}
+
+	// The reply of the server, split over two segments
+	reply := func(data []byte, seq uint32) []byte {
+		segment := tcpSegment(443, 51000, data)
+		binary.BigEndian.PutUint32(segment[4:8], seq)
+		packet := ip4Packet(6, segment)
+		copy(packet[12:16], net.IP{198, 51, 100, 1})
+		copy(packet[16:20], net.IP{192, 0, 2, 1})
+		return packet
+	}
+	p, err = ParseWithOptions(reply(flight[:100], 1), opts)
+	if !errors.Is(err, ServerCertificatePendingError) || !errors.Is(err, NotInterestingError) {
+		t.Fatalf("ParseWithOptions() error = %v, want %v", err, ServerCertificatePendingError)
+	}
+	p, err = ParseWithOptions(reply(flight[100:], 101), opts)
+	if err != nil {
+		t.Fatalf("ParseWithOptions() error = %v", err)
+	}
+	if p.Protocol != ProtocolTLS || p.Server == nil {
+		t.Fatalf("unexpected packet without server certificate: %+v", p)
+	}
+	want := []string{"device.example.com", "api.example.com"}
+	if diff := cmp.Diff(want, p.Server.Names()); diff != "" {
+		t.Fatalf("unexpected names (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseFrame(t *testing.T) {
+	frame := []byte{
+		0x02, 0x00, 0x00, 0x00, 0x00, 0x02, 0x02, 0x00, 0x00, 0x00, 0x00, 0x01, // MACs
+		0x81, 0x00, 0x00, 0x0a, 0x08, 0x00, // VLAN 10, IPv4
+	}
+	frame = append(frame, ip4Packet(6, tcpSegment(51000, 443, clientHello(t, "www.example.com")))...)
+
+	p, err := ParseFrame(frame, LinkEthernet)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := &Packet{
+		SourceMAC:      net.HardwareAddr{0x02, 0x00, 0x00, 0x00, 0x00, 0x01},
+		DestinationMAC: net.HardwareAddr{0x02, 0x00, 0x00, 0x00, 0x00, 0x02},
+		VLANs:          []uint16{10},
+		Name:           "www.example.com",
+	}
+	got := &Packet{SourceMAC: p.SourceMAC, DestinationMAC: p.DestinationMAC, VLANs: p.VLANs, Name: p.Name}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected result (-want +got):\n%s", diff)
+	}
+}
+
+func TestParse_errors(t *testing.T) {
+	hello := clientHello(t, "www.example.com")
+	tests := []struct {
+		name    string
+		payload []byte
+		want    Error
+		wantErr error
+		class   error
+	}{
+		{
+			name:    "No data",
+			payload: ip4Packet(6, tcpSegment(51000, 443, nil)),
+			want:    Error{Layer: LayerTCP, Reason: ReasonNotInteresting, Offset: 20},
+			wantErr: NoTLSError,
+			class:   NotInterestingError,
+		},
+		{
+			name:    "Truncated hello",
+			payload: ip4Packet(6, tcpSegment(51000, 443, hello[:3])),
+			// The record header ends in its length field
+			want:  Error{Layer: LayerTLS, Reason: ReasonTruncated, Offset: 43},
+			class: TruncatedError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.payload)
+			if !errors.Is(err, tt.class) {
+				t.Fatalf("Parse() error = %v, want %v", err, tt.class)
+			}
+			var got *Error
+			if !errors.As(err, &got) {
+				t.Fatalf("Parse() error = %T, want *Error", err)
+			}
+			if diff := cmp.Diff(tt.want, *got, cmpopts.IgnoreFields(Error{}, "Err")); diff != "" {
+				t.Fatalf("unexpected error (-want +got):\n%s", diff)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParse_greasedQUICVersion(t *testing.T) {
+	initial := []byte{
+		0xc0, 0x1a, 0x2a, 0x3a, 0x4a, // Initial of a greased version
+		0x04, 0x01, 0x02, 0x03, 0x04, 0x00,
+	}
+	p, err := Parse(ip4Packet(17, udpDatagram(51000, 443, append(initial, make([]byte, 1200)...))))
+	if !errors.Is(err, UnsupportedQUICVersionError) || !errors.Is(err, UnsupportedError) {
+		t.Fatalf("Parse() error = %v, want %v", err, UnsupportedQUICVersionError)
+	}
+	if p.QUIC.Version != 0x1a2a3a4a || !IsGreasedQUICVersion(p.QUIC.Version) {
+		t.Fatalf("unexpected QUIC version %#x", p.QUIC.Version)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	hello := clientHello(t, "www.example.com")
+	tests := []struct {
+		name       string
+		transport  Transport
+		data       []byte
+		protocol   Protocol
+		confidence Confidence
+	}{
+		{name: "TLS", transport: TCP, data: hello, protocol: ProtocolTLS, confidence: ConfidenceHigh},
+		{name: "TLS over UDP", transport: UDP, data: hello, protocol: ProtocolUnknown, confidence: ConfidenceNone},
+		{name: "HTTP", transport: TCP, data: []byte("GET / HTTP/1.1\r\n"), protocol: ProtocolUnknown, confidence: ConfidenceNone},
+		{name: "QUIC short header", transport: UDP, data: []byte{0x40, 0x01, 0x02}, protocol: ProtocolQUIC, confidence: ConfidenceLow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			protocol, confidence := Detect(tt.transport, tt.data)
+			if protocol != tt.protocol || confidence != tt.confidence {
+				t.Fatalf("Detect() = %s, %s, want %s, %s", protocol, confidence, tt.protocol, tt.confidence)
+			}
+		})
+	}
+
+	// The hello is found on any port
+	p, err := Parse(ip4Packet(6, tcpSegment(51000, 8443, hello)))
+	if err != nil || p.Name != "www.example.com" || p.Detected != ProtocolTLS {
+		t.Fatalf("unexpected packet %+v, error %v", p, err)
+	}
+}
+
+func TestPacket_fingerprints(t *testing.T) {
+	payload := ip4Packet(6, tcpSegment(51000, 443, clientHello(t, "www.example.com")))
+	p, err := Parse(payload)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.JA3() == "" || p.JA4()[0] != 't' || p.ECH() {
+		t.Fatalf("unexpected fingerprints: ja3 %q, ja4 %q, ech %v", p.JA3(), p.JA4(), p.ECH())
+	}
+
+	p, err = ParseWithOptions(payload, Options{SNIOnly: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Name != "www.example.com" || p.JA3() != "" || p.JA4() != "" || p.TLSVersion != 0 {
+		t.Fatalf("unexpected SNI only packet: %+v", p)
+	}
+}
+
+func TestParser_allocs(t *testing.T) {
+	payload := ip4Packet(6, tcpSegment(51000, 443, clientHello(t, "www.example.com")))
+	parser := NewParser(Options{SNIOnly: true})
+	allocs := testing.AllocsPerRun(100, func() {
+		p, err := parser.Parse(payload)
+		if err != nil || p.Name != "www.example.com" {
+			t.Fatalf("unexpected result %q, error %v", p.Name, err)
+		}
+	})
+	if allocs != 0 {
+		t.Fatalf("expected no allocations, got %v per packet", allocs)
+	}
+}
+
+func TestParseClientHello(t *testing.T) {
+	record := clientHello(t, "www.example.com")
+	hello, err := ParseClientHello(record)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hello.SNI != "www.example.com" || hello.MaxVersion() != 0x0304 {
+		t.Fatalf("unexpected hello: %+v", hello)
+	}
+
+	// The SNI is known before the end of the hello
+	hello, err = ParseClientHello(record[:len(record)-10])
+	if err != nil || !hello.Partial || hello.SNI != "www.example.com" {
+		t.Fatalf("unexpected partial hello: %+v, error %v", hello, err)
+	}
+}
+
+// clientHello returns the first TLS record sent by crypto/tls when
+// connecting to serverName.
+func clientHello(t *testing.T, serverName string) []byte {
+	t.Helper()
+	client, server := net.Pipe()
+	defer server.Close()
+
+	go func() {
+		conn := tls.Client(client, &tls.Config{ServerName: serverName, NextProtos: []string{"h2", "http/1.1"}})
+		_ = conn.Handshake()
+		_ = conn.Close()
+	}()
+
+	return readRecord(t, server)
+}
+
+// tls12Handshake returns the ClientHello of a crypto/tls client that sends
+// no SNI, and the first flight of a TLS 1.2 server with a certificate for
+// the given names.
+func tls12Handshake(t *testing.T, names ...string) (hello, flight []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: names[0]},
+		DNSNames:     names,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("creating certificate: %v", err)
+	}
+
+	client, clientPeer := net.Pipe()
+	server, serverPeer := net.Pipe()
+	defer clientPeer.Close()
+	defer serverPeer.Close()
+	go func() {
+		_ = tls.Client(client, &tls.Config{InsecureSkipVerify: true, MaxVersion: tls.VersionTLS12}).Handshake()
+		_ = client.Close()
+	}()
+	go func() {
+		config := &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+		_ = tls.Server(server, config).Handshake()
+		_ = server.Close()
+	}()
+
+	hello = readRecord(t, clientPeer)
+	if _, err := serverPeer.Write(hello); err != nil {
+		t.Fatalf("writing hello: %v", err)
+	}
+	// The flight ends with ServerHelloDone
+	for !bytes.HasSuffix(flight, []byte{0x0e, 0x00, 0x00, 0x00}) {
+		flight = append(flight, readRecord(t, serverPeer)...)
+	}
+	return hello, flight
+}
+
+// readRecord reads a TLS record from conn.
+func readRecord(t *testing.T, conn net.Conn) []byte {
+	t.Helper()
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		t.Fatalf("reading record header: %v", err)
+	}
+	record := make([]byte, 5+int(binary.BigEndian.Uint16(header[3:5])))
+	copy(record, header)
+	if _, err := io.ReadFull(conn, record[5:]); err != nil {
+		t.Fatalf("reading record: %v", err)
+	}
+	return record
+}
+
+// ip4Packet returns an IPv4 packet from 192.0.2.1 to 198.51.100.1.
+func ip4Packet(protocol byte, payload []byte) []byte {
+	packet := []byte{
+		0x45, 0x00, 0x00, 0x00, 0x00, 0x00, 0x40, 0x00,
+		0x40, protocol, 0x00, 0x00, 192, 0, 2, 1,
+		198, 51, 100, 1,
+	}
+	packet = append(packet, payload...)
+	binary.BigEndian.PutUint16(packet[2:4], uint16(len(packet)))
+	return packet
+}
+
+func tcpSegment(src, dst uint16, data []byte) []byte {
+	segment := make([]byte, 20, 20+len(data))
+	binary.BigEndian.PutUint16(segment[0:2], src)
+	binary.BigEndian.PutUint16(segment[2:4], dst)
+	segment[12] = 5 << 4
+	segment[13] = 0x18 // PSH, ACK
+	return append(segment, data...)
+}
+
+func udpDatagram(src, dst uint16, data []byte) []byte {
+	datagram := make([]byte, 8, 8+len(data))
+	binary.BigEndian.PutUint16(datagram[0:2], src)
+	binary.BigEndian.PutUint16(datagram[2:4], dst)
+	binary.BigEndian.PutUint16(datagram[4:6], uint16(8+len(data)))
+	return append(datagram, data...)
+}
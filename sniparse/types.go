@@ -0,0 +1,337 @@
+package sniparse
+
+import (
+	"time"
+
+	"github.com/jsimonetti/sniqueue/internal/parse"
+	"github.com/jsimonetti/sniqueue/internal/parse/dns"
+	"github.com/jsimonetti/sniqueue/internal/parse/http"
+	"github.com/jsimonetti/sniqueue/internal/parse/quic"
+	"github.com/jsimonetti/sniqueue/internal/parse/tls"
+)
+
+// ClientHello holds the decoded fields of a TLS or DTLS ClientHello. The
+// fields below SNI, ECH and Partial are left empty with Options.SNIOnly.
+type ClientHello = tls.ClientHello
+
+// EncryptedClientHello holds the cleartext parts of an Encrypted Client
+// Hello extension.
+type EncryptedClientHello = tls.EncryptedClientHello
+
+// ServerHello holds the ServerHello of a TLS 1.2 server and the names of its
+// leaf certificate.
+type ServerHello = tls.ServerHello
+
+// HTTPRequest holds the request line and the hosts of a cleartext HTTP
+// request.
+type HTTPRequest = http.Request
+
+// DNSMessage is a DNS query or response with its questions and the address
+// and CNAME records of its answers.
+type DNSMessage = dns.Message
+
+// LinkType is the link layer header in front of the IP header.
+type LinkType = parse.LinkType
+
+// The link layer headers that can be parsed.
+const (
+	// LinkRaw is a packet that starts with the IP header, as delivered by
+	// NFQUEUE for the inet, ip and ip6 families and by TUN devices.
+	LinkRaw = parse.LinkRaw
+	// LinkEthernet is an Ethernet II frame, optionally with 802.1Q and
+	// 802.1ad VLAN tags.
+	LinkEthernet = parse.LinkEthernet
+	// LinkSLL is the Linux cooked capture header (DLT_LINUX_SLL).
+	LinkSLL = parse.LinkSLL
+	// LinkSLL2 is the version 2 Linux cooked capture header
+	// (DLT_LINUX_SLL2).
+	LinkSLL2 = parse.LinkSLL2
+)
+
+// TCPFlags are the control bits of a TCP segment.
+type TCPFlags = parse.TCPFlags
+
+const (
+	TCPFlagFIN = parse.TCPFlagFIN
+	TCPFlagSYN = parse.TCPFlagSYN
+	TCPFlagRST = parse.TCPFlagRST
+	TCPFlagPSH = parse.TCPFlagPSH
+	TCPFlagACK = parse.TCPFlagACK
+	TCPFlagURG = parse.TCPFlagURG
+	TCPFlagECE = parse.TCPFlagECE
+	TCPFlagCWR = parse.TCPFlagCWR
+)
+
+// TCPOptions are the decoded options of a TCP segment, including the cookie
+// of TCP Fast Open.
+type TCPOptions = parse.TCPOptions
+
+// Tunnel describes a tunnel header that was stripped to reach a packet.
+type Tunnel = parse.Tunnel
+
+// Error is the error returned by Parse, ParseFrame, ParseWithOptions and
+// Parser.Parse. It holds the layer that failed, the Reason and the offset in
+// the packet, and wraps the error of the layer. Use errors.As to inspect it.
+type Error = parse.Error
+
+// Layer is the protocol layer an Error occurred in.
+type Layer = parse.Layer
+
+const (
+	LayerLink        = parse.LayerLink
+	LayerIP          = parse.LayerIP
+	LayerTunnel      = parse.LayerTunnel
+	LayerTCP         = parse.LayerTCP
+	LayerUDP         = parse.LayerUDP
+	LayerTLS         = parse.LayerTLS
+	LayerDTLS        = parse.LayerDTLS
+	LayerQUIC        = parse.LayerQUIC
+	LayerHTTP        = parse.LayerHTTP
+	LayerDNS         = parse.LayerDNS
+	LayerStartTLS    = parse.LayerStartTLS
+	LayerCertificate = parse.LayerCertificate
+)
+
+// Reason classifies an Error.
+type Reason = parse.Reason
+
+const (
+	ReasonNotInteresting = parse.ReasonNotInteresting
+	ReasonMalformed      = parse.ReasonMalformed
+	ReasonTruncated      = parse.ReasonTruncated
+	ReasonUnsupported    = parse.ReasonUnsupported
+)
+
+// The classes of errors. Every Error matches the class of its Reason when
+// compared with errors.Is.
+var (
+	// NotInterestingError matches packets that are valid, but carry
+	// nothing to extract, such as TCP segments without data, QUIC packets
+	// other than Initials and protocols that are not supported.
+	NotInterestingError = parse.NotInterestingError
+	// MalformedError matches packets that violate their protocol.
+	MalformedError = parse.MalformedError
+	// TruncatedError matches packets that end before a header or message
+	// is complete.
+	TruncatedError = parse.TruncatedError
+	// UnsupportedError matches packets with a protocol version or feature
+	// that cannot be parsed, or that exceed a configured limit.
+	UnsupportedError = parse.UnsupportedError
+)
+
+// Errors wrapped for packets without anything to extract. Compare them with
+// errors.Is.
+var (
+	// NoTLSError is returned for TCP and UDP payloads that are not
+	// recognised as any of the supported protocols.
+	NoTLSError = tls.UnmarshalNoTLSError
+	// NoTLSHandshakeError is returned for TLS records that do not carry a
+	// ClientHello.
+	NoTLSHandshakeError = tls.UnmarshalNoTLSHandshakeError
+)
+
+// ParseClientHello decodes the ClientHello at the start of a TLS stream, as
+// seen by a proxy that accepted the connection. A hello that continues
+// beyond payload returns an error, but the fields decoded so far are set and
+// Partial is true.
+func ParseClientHello(payload []byte) (*ClientHello, error) {
+	hello := &ClientHello{}
+	err := hello.Unmarshal(payload)
+	if hello.Incomplete(err) {
+		return hello, nil
+	}
+	return hello, err
+}
+
+// ParseQUICInitial decrypts a QUIC Initial packet, given as the payload of a
+// UDP datagram, and decodes its ClientHello. The packet is decrypted in
+// place, which modifies payload.
+func ParseQUICInitial(payload []byte) (*ClientHello, error) {
+	q := &quic.Quic{}
+	if err := q.Unmarshal(payload); err != nil {
+		return nil, err
+	}
+	return &q.Hello, nil
+}
+
+// Reassembler collects IPv4 and IPv6 fragments until their datagram is
+// complete. It is safe for concurrent use.
+type Reassembler = parse.Reassembler
+
+// NewReassembler returns a Reassembler that holds at most size incomplete
+// datagrams, each for at most timeout.
+func NewReassembler(size int, timeout time.Duration) *Reassembler {
+	return parse.NewReassembler(size, timeout)
+}
+
+// IsFragment reports whether packet is an IPv4 or IPv6 fragment.
+func IsFragment(packet []byte) bool {
+	return parse.IsFragment(packet)
+}
+
+// StartTLS follows plaintext mail and chat connections until they are
+// upgraded to TLS. It is safe for concurrent use.
+type StartTLS = parse.StartTLS
+
+// StartTLSProtocol is a plaintext protocol that can be upgraded to TLS.
+type StartTLSProtocol = parse.StartTLSProtocol
+
+// The protocols StartTLS can follow.
+const (
+	SMTP = parse.SMTP
+	IMAP = parse.IMAP
+	POP3 = parse.POP3
+	XMPP = parse.XMPP
+)
+
+// Errors wrapped for the segments of followed STARTTLS connections. Compare
+// them with errors.Is.
+var (
+	// StartTLSPendingError is returned for the plaintext segments of a
+	// followed connection before it has been upgraded to TLS.
+	StartTLSPendingError = parse.StartTLSPendingError
+	// StartTLSDeclinedError is returned when a followed connection will
+	// not be upgraded to TLS.
+	StartTLSDeclinedError = parse.StartTLSDeclinedError
+)
+
+// NewStartTLS returns a StartTLS that follows at most size connections to
+// the given server ports, each for at most timeout.
+func NewStartTLS(ports map[uint16]StartTLSProtocol, size int, timeout time.Duration) *StartTLS {
+	return parse.NewStartTLS(ports, size, timeout)
+}
+
+// ParseStartTLSProtocol returns the protocol with the given name: smtp, imap,
+// pop3 or xmpp.
+func ParseStartTLSProtocol(name string) (StartTLSProtocol, error) {
+	return parse.ParseStartTLSProtocol(name)
+}
+
+// Confidence is how sure the content based detection of a protocol is.
+type Confidence = parse.Confidence
+
+const (
+	// ConfidenceNone is returned for payloads that are not the protocol.
+	ConfidenceNone = parse.ConfidenceNone
+	// ConfidenceLow means the first bytes match the record or packet
+	// header of the protocol, as they do for many other payloads.
+	ConfidenceLow = parse.ConfidenceLow
+	// ConfidenceMedium means the header is that of a ClientHello or a QUIC
+	// Initial, with consistent lengths.
+	ConfidenceMedium = parse.ConfidenceMedium
+	// ConfidenceHigh means the start of the hello or the Initial itself is
+	// valid too.
+	ConfidenceHigh = parse.ConfidenceHigh
+)
+
+// Detect returns the protocol the data of a TCP segment or a UDP datagram
+// looks like, whatever its ports. It only checks the record and packet
+// headers, which makes it cheap enough to filter traffic before parsing.
+// Only ProtocolTLS, ProtocolDTLS and ProtocolQUIC are detected, anything else
+// is ProtocolUnknown with ConfidenceNone.
+func Detect(transport Transport, data []byte) (Protocol, Confidence) {
+	switch transport {
+	case TCP:
+		return detected(parse.DetectTCP(data))
+	case UDP:
+		return detected(parse.DetectUDP(data))
+	}
+	return ProtocolUnknown, ConfidenceNone
+}
+
+// VPNProtocol is a tunnel or proxy protocol that can carry traffic past the
+// inspection of the hellos.
+type VPNProtocol = parse.VPNProtocol
+
+const (
+	VPNNone      = parse.VPNNone
+	VPNWireGuard = parse.VPNWireGuard
+	VPNOpenVPN   = parse.VPNOpenVPN
+	VPNSSH       = parse.VPNSSH
+	VPNTor       = parse.VPNTor
+)
+
+// QUICPacket describes the QUIC long header packet of a datagram and, when
+// the connection is followed, what is known about its connection.
+type QUICPacket = parse.QUICPacket
+
+// QUICPacketType is the type of a QUIC long header packet.
+type QUICPacketType = quic.PacketType
+
+const (
+	QUICInitial            = quic.PacketInitial
+	QUIC0RTT               = quic.Packet0RTT
+	QUICHandshake          = quic.PacketHandshake
+	QUICRetry              = quic.PacketRetry
+	QUICVersionNegotiation = quic.PacketVersionNegotiation
+)
+
+// UnsupportedQUICVersionError is wrapped for QUIC packets of a version that
+// cannot be parsed, including the greased versions. Packet.QUIC.Version holds
+// the version.
+var UnsupportedQUICVersionError = quic.UnmarshalQUICUnsupportedVersion
+
+// IsGreasedQUICVersion reports whether v is one of the versions reserved to
+// exercise version negotiation.
+func IsGreasedQUICVersion(v uint32) bool {
+	return quic.IsGreasedVersion(v)
+}
+
+// QUICConnections follows QUIC connections from their first Initial. It is
+// safe for concurrent use.
+type QUICConnections = parse.QUICConnections
+
+// NewQUICConnections returns a QUICConnections that follows at most size
+// connections to the given server ports, until they have been idle for
+// timeout.
+func NewQUICConnections(ports []uint16, size int, timeout time.Duration) *QUICConnections {
+	return parse.NewQUICConnections(ports, size, timeout)
+}
+
+// HTTPRequests follows the cleartext HTTP requests whose Host header is not
+// in their first segment. It is safe for concurrent use.
+type HTTPRequests = parse.HTTPRequests
+
+// HTTPPendingError is wrapped for the segments of a followed HTTP request
+// before its Host header is complete. Compare it with errors.Is.
+var HTTPPendingError = parse.HTTPPendingError
+
+// NewHTTPRequests returns an HTTPRequests that follows at most size
+// connections, each for at most timeout.
+func NewHTTPRequests(size int, timeout time.Duration) *HTTPRequests {
+	return parse.NewHTTPRequests(size, timeout)
+}
+
+// ServerCertificates follows the TLS connections whose ClientHello has no
+// server name until the server sends its certificate. It is safe for
+// concurrent use.
+type ServerCertificates = parse.ServerCertificates
+
+// Errors wrapped for the segments of the servers of followed connections.
+// Compare them with errors.Is.
+var (
+	// ServerCertificatePendingError is returned for the segments of the
+	// server before its certificate is complete.
+	ServerCertificatePendingError = parse.ServerCertificatePendingError
+	// EncryptedCertificateError is returned when the server chose TLS 1.3,
+	// which encrypts the certificate.
+	EncryptedCertificateError = tls.UnmarshalEncryptedCertificateError
+	// NoCertificateError is returned when the server resumed a session or
+	// sent no certificate.
+	NoCertificateError = tls.UnmarshalNoCertificateError
+)
+
+// NewServerCertificates returns a ServerCertificates that follows at most
+// size connections, each for at most timeout.
+func NewServerCertificates(size int, timeout time.Duration) *ServerCertificates {
+	return parse.NewServerCertificates(size, timeout)
+}
+
+// Names remembers the addresses in DNS answers and the names they were
+// resolved for. It is safe for concurrent use.
+type Names = dns.Names
+
+// NewNames returns a Names that remembers at most size addresses.
+func NewNames(size int) *Names {
+	return dns.NewNames(size)
+}